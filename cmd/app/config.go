@@ -0,0 +1,23 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/xoticdsign/returnauf/config"
+)
+
+// Выполняет подкоманду config: выводит действующие настройки со скрытыми секретами и
+// проверяет их
+func runConfig(conf config.Config, out io.Writer) error {
+	err := conf.Print(out)
+	if err != nil {
+		return err
+	}
+
+	err = conf.Validate()
+	if err != nil {
+		return fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/xoticdsign/returnauf/config"
+	"github.com/xoticdsign/returnauf/internal/bloom"
+	"github.com/xoticdsign/returnauf/internal/cache"
+	"github.com/xoticdsign/returnauf/internal/card"
+	"github.com/xoticdsign/returnauf/internal/database"
+	"github.com/xoticdsign/returnauf/internal/importer"
+	"github.com/xoticdsign/returnauf/models/responses"
+)
+
+// Ошибка отклоненного импорта
+var errImportRejected = errors.New("import rejected: file has invalid rows")
+
+// Подсказка на случай, когда запущенные экземпляры не узнают о новых ID: фильтр Блума
+// живет в их памяти, и CLI может только изменить версию набора ID в Redis
+const filterRebuildNotice = "warning: failed to update the bloom filter version, rebuild the filter with POST /admin/filter/rebuild"
+
+// Выполняет подкоманду import [-format] [-policy] [-dry-run] [-map] FILE.
+// Вместо имени файла можно передать "-" для чтения из stdin. CLI не связан с
+// запущенными экземплярами, поэтому подписчики потоков не получают событий об
+// импортированных цитатах, для этого нужно импортировать через POST /admin/import
+func runImport(conf config.Config, args []string, in io.Reader, out io.Writer) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	flags.SetOutput(out)
+
+	format := flags.String("format", "", "формат файла: csv, json или ndjson (по умолчанию по расширению)")
+	policy := flags.String("policy", database.PolicySkip, "политика для существующих ID: upsert или skip")
+	dryRun := flags.Bool("dry-run", false, "проверить импорт без записи в БД")
+	mappingFlag := flags.String("map", "", "отображение колонок на поля цитаты, например text:quote,num:id")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("usage: import [flags] FILE")
+	}
+
+	filename := flags.Arg(0)
+
+	detected, err := importer.DetectFormat(*format, filename, "")
+	if err != nil {
+		return err
+	}
+
+	mapping, err := importer.ParseMapping(*mappingFlag)
+	if err != nil {
+		return err
+	}
+
+	r := in
+	if filename != "-" {
+		file, err := os.Open(filename)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		r = file
+	}
+
+	DB, err := database.OpenGORM(conf.DBAddr)
+	if err != nil {
+		return err
+	}
+	defer DB.Close()
+
+	ctx := context.Background()
+
+	err = DB.MigrateUp(ctx)
+	if err != nil {
+		return err
+	}
+
+	report, result, err := importer.Import(ctx, r, importer.Options{
+		Format:  detected,
+		Policy:  *policy,
+		DryRun:  *dryRun,
+		Mapping: mapping,
+	}, DB)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range report.Errors {
+		fmt.Fprintf(out, "row %d (id %d): %s\n", e.Row, e.ID, e.Message)
+	}
+	if len(report.Errors) > 0 {
+		return errImportRejected
+	}
+
+	fmt.Fprintf(out, "total: %d, inserted: %d, updated: %d, skipped: %d, dry run: %t\n",
+		report.Total, report.Inserted, report.Updated, report.Skipped, report.DryRun)
+
+	if quotes := result.Changed(); !report.DryRun && len(quotes) > 0 {
+		evictImported(ctx, conf, quotes, out)
+	}
+	return nil
+}
+
+// Удаляет импортированные цитаты и все карточки из Кэша и меняет версию набора ID, как
+// при импорте через API, если Redis доступен
+func evictImported(ctx context.Context, conf config.Config, quotes []responses.Quote, out io.Writer) {
+	Cache, err := cache.RunRedis(conf.Redis)
+	if err != nil {
+		fmt.Fprintln(out, "warning: redis is unavailable, cached quotes will expire by TTL")
+		fmt.Fprintln(out, filterRebuildNotice)
+		return
+	}
+	defer Cache.Close()
+
+	keys := make([]string, len(quotes))
+	for i, quote := range quotes {
+		keys[i] = strconv.Itoa(quote.ID)
+	}
+
+	_, err = Cache.Delete(ctx, keys...)
+	if err != nil {
+		fmt.Fprintln(out, "warning: failed to evict imported quotes from cache")
+	}
+
+	_, err = Cache.DeletePattern(ctx, card.KeyPattern)
+	if err != nil {
+		fmt.Fprintln(out, "warning: failed to evict quote cards from cache")
+	}
+
+	err = bloom.Bump(ctx, Cache)
+	if err != nil {
+		fmt.Fprintln(out, filterRebuildNotice)
+	}
+}
@@ -1,7 +1,13 @@
 package main
 
 import (
+	"context"
 	"log"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/joho/godotenv"
 
@@ -10,11 +16,17 @@ import (
 	"github.com/xoticdsign/returnauf/internal/app"
 )
 
+// Коды завершения процесса
+const (
+	exitClean  = 0
+	exitForced = 1
+)
+
 // Общее описание
 //
 // @title                      returnauf
 // @version                    1.0.0
-// @description                REST API с коллекцией самых мемных ауф цитат
+// @description                REST API с коллекцией самых мемных ауф цитат. Ошибки возвращаются в формате RFC 7807 (application/problem+json): type и code определяют вид ошибки и не меняются между версиями, detail описывает конкретный случай, instance содержит UUID запроса.
 // @contact.name               xoti$
 // @contact.url                https://t.me/xoticdsign
 // @contact.email              xoticdollarsign@outlook.com
@@ -28,18 +40,91 @@ import (
 // @securitydefinitions.apikey KeyAuth
 // @in                         query
 // @name                       returnauf-key
+//
+// @securitydefinitions.apikey AdminKeyAuth
+// @in                         query
+// @name                       returnauf-admin-key
 func main() {
 	godotenv.Load()
 
-	conf := config.LoadConfig()
-
-	app, err := app.InitApp(conf)
+	conf, args, err := config.Load(os.Args[1:])
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	err = app.Listen(conf.ServerAddr)
+	if len(args) > 0 {
+		switch args[0] {
+		case "config":
+			err = runConfig(conf, os.Stdout)
+		case "migrate":
+			err = runMigrate(conf, args[1:], os.Stdout)
+		case "seed":
+			err = runSeed(conf, args[1:], os.Stdout)
+		case "import":
+			err = runImport(conf, args[1:], os.Stdin, os.Stdout)
+		default:
+			log.Fatalf("unknown command %q", args[0])
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	err = conf.Validate()
+	if err != nil {
+		log.Fatalf("invalid configuration:\n%v", err)
+	}
+
+	application, err := app.InitApp(conf)
 	if err != nil {
 		log.Fatal(err)
 	}
+
+	serveErr := make(chan error, 2)
+
+	if application.GRPC != nil {
+		listener, err := net.Listen("tcp", conf.GRPCAddr)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		go func() {
+			serveErr <- application.GRPC.Serve(listener)
+		}()
+	}
+
+	go func() {
+		serveErr <- application.HTTP.Listen(conf.ServerAddr)
+	}()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+
+	go config.NewReloader(os.Args[1:], conf.WatchInterval).Watch(ctx, application.Reload)
+
+	select {
+	case err := <-serveErr:
+		log.Fatal(err)
+	case <-ctx.Done():
+	}
+	stop()
+
+	log.Printf("shutting down, waiting up to %s for open requests", conf.ShutdownTimeout)
+
+	os.Exit(shutdown(application, conf.ShutdownTimeout))
+}
+
+// Останавливает приложение и возвращает код завершения: exitForced, если запросы не
+// успели завершиться или ресурсы не закрылись, иначе exitClean
+func shutdown(application *app.App, timeout time.Duration) int {
+	err := application.Shutdown(timeout)
+	if err != nil {
+		log.Print(err)
+
+		return exitForced
+	}
+
+	log.Print("shutdown complete")
+
+	return exitClean
 }
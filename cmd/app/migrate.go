@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/xoticdsign/returnauf/config"
+	"github.com/xoticdsign/returnauf/internal/database"
+)
+
+// Ошибка неверного использования подкоманды migrate
+var errMigrateUsage = errors.New("usage: migrate up|down|status|to N")
+
+// Выполняет подкоманду migrate up|down|status|to N
+func runMigrate(conf config.Config, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errMigrateUsage
+	}
+
+	DB, err := database.OpenGORM(conf.DBAddr)
+	if err != nil {
+		return err
+	}
+	defer DB.Close()
+
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		err = DB.MigrateUp(ctx)
+
+	case "down":
+		err = DB.MigrateDown(ctx)
+
+	case "to":
+		if len(args) < 2 {
+			return errMigrateUsage
+		}
+
+		version, convErr := strconv.Atoi(args[1])
+		if convErr != nil {
+			return errMigrateUsage
+		}
+		err = DB.MigrateTo(ctx, version)
+
+	case "status":
+		return printMigrationStatus(ctx, DB, out)
+
+	default:
+		return errMigrateUsage
+	}
+	if err != nil {
+		return err
+	}
+
+	return printMigrationStatus(ctx, DB, out)
+}
+
+// Печатает состояние миграций в виде таблицы
+func printMigrationStatus(ctx context.Context, DB *database.DB, out io.Writer) error {
+	status, err := DB.MigrationStatus(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+
+	for _, s := range status {
+		appliedAt := "pending"
+		if s.Applied {
+			appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, appliedAt)
+	}
+	return w.Flush()
+}
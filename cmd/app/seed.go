@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/xoticdsign/returnauf/config"
+	"github.com/xoticdsign/returnauf/internal/bloom"
+	"github.com/xoticdsign/returnauf/internal/cache"
+	"github.com/xoticdsign/returnauf/internal/database"
+)
+
+// Выполняет подкоманду seed [-prune], сверяя БД со встроенным набором цитат
+func runSeed(conf config.Config, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("seed", flag.ContinueOnError)
+	flags.SetOutput(out)
+
+	prune := flags.Bool("prune", false, "удалить цитаты, которых нет во встроенном наборе")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	DB, err := database.OpenGORM(conf.DBAddr)
+	if err != nil {
+		return err
+	}
+	defer DB.Close()
+
+	ctx := context.Background()
+
+	err = DB.MigrateUp(ctx)
+	if err != nil {
+		return err
+	}
+
+	result, err := DB.Seed(ctx, *prune)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "inserted: %d, updated: %d, unchanged: %d, deleted: %d\n",
+		result.Inserted, result.Updated, result.Unchanged, result.Deleted)
+
+	if result.Inserted > 0 {
+		bumpFilterVersion(ctx, conf, out)
+	}
+	return nil
+}
+
+// Меняет версию набора ID в Redis, чтобы запущенные экземпляры пересобрали фильтр Блума
+func bumpFilterVersion(ctx context.Context, conf config.Config, out io.Writer) {
+	Cache, err := cache.RunRedis(conf.Redis)
+	if err != nil {
+		fmt.Fprintln(out, filterRebuildNotice)
+		return
+	}
+	defer Cache.Close()
+
+	err = bloom.Bump(ctx, Cache)
+	if err != nil {
+		fmt.Fprintln(out, filterRebuildNotice)
+	}
+}
@@ -1,23 +1,276 @@
 package config
 
-import "os"
+import (
+	"errors"
+	"flag"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+)
 
-// Структура содержащая поля с переменными окружения
+// Таймаут операций с Кэшом и БД по умолчанию
+const DefaultOperationTimeout = time.Second * 5
+
+// Время на завершение открытых соединений при остановке сервера по умолчанию
+const DefaultShutdownTimeout = time.Second * 10
+
+// Время жизни цитат в Кэше по умолчанию
+const DefaultCacheTTL = time.Minute * 1
+
+// Время жизни отметки об отсутствии цитаты в Кэше по умолчанию
+const DefaultNegativeCacheTTL = time.Second * 30
+
+// Максимальная глубина запроса GraphQL по умолчанию
+const DefaultGraphQLMaxDepth = 6
+
+// Максимальная сложность запроса GraphQL по умолчанию
+const DefaultGraphQLMaxComplexity = 500
+
+// Максимальное число одновременных потоков цитат на ключ API по умолчанию
+const DefaultStreamMaxConnections = 5
+
+// Интервал сигналов активности в потоках цитат по умолчанию
+const DefaultStreamHeartbeat = time.Second * 15
+
+// Экспортеры трассировки
+const (
+	TracingExporterNone   = "none"
+	TracingExporterStdout = "stdout"
+	TracingExporterOTLP   = "otlp"
+)
+
+// Уровни журнала
+const (
+	LogLevelDebug = "debug"
+	LogLevelInfo  = "info"
+	LogLevelWarn  = "warn"
+	LogLevelError = "error"
+)
+
+// Форматы журнала
+const (
+	LogEncodingJSON    = "json"
+	LogEncodingConsole = "console"
+)
+
+// Режимы подключения к Redis
+const (
+	RedisModeSingle   = "single"
+	RedisModeSentinel = "sentinel"
+	RedisModeCluster  = "cluster"
+)
+
+// Переменная окружения с путем к файлу настроек, если он не задан флагом -config
+const FileEnv = "CONFIG_FILE"
+
+// Структура содержащая настройки приложения. Тег env задает имя переменной окружения,
+// от которого образуются ключ в файле настроек и имя флага, default - значение по
+// умолчанию, secret - скрытие значения при выводе и запрет передачи флагом, reload -
+// применение нового значения без перезапуска
 type Config struct {
-	ServerAddr    string
-	RedisAddr     string
-	RedisPassword string
-	DBAddr        string
-	ApiKey        string
-}
-
-// Функция подгружающая переменные окружения
-func LoadConfig() Config {
-	return Config{
-		ServerAddr:    os.Getenv("SERVER_ADDRESS"),
-		RedisAddr:     os.Getenv("REDIS_ADDRESS"),
-		RedisPassword: os.Getenv("REDIS_PASSWORD"),
-		DBAddr:        os.Getenv("DB_ADDRESS"),
-		ApiKey:        os.Getenv("API_KEY"),
+	ServerAddr       string   `env:"SERVER_ADDRESS" default:"0.0.0.0:8080" usage:"адрес HTTP сервера"`
+	GRPCAddr         string   `env:"GRPC_ADDRESS" usage:"адрес gRPC сервера, пустой отключает gRPC"`
+	TrustedProxies   []string `env:"TRUSTED_PROXIES" usage:"адреса и подсети прокси через запятую, которым доверяется заголовок PROXY_HEADER"`
+	ProxyHeader      string   `env:"PROXY_HEADER" default:"X-Forwarded-For" usage:"заголовок с адресом клиента от доверенного прокси"`
+	Redis            Redis
+	DBAddr           string        `env:"DB_ADDRESS" default:"db.sqlite" usage:"путь к файлу SQLite"`
+	DBAutoMigrate    bool          `env:"DB_AUTO_MIGRATE" default:"false" usage:"применять новые миграции к существующей БД в фоне после запуска сервера, БД без схемы создается всегда"`
+	ApiKey           string        `env:"API_KEY" reload:"true" secret:"true" usage:"ключ API"`
+	AdminApiKey      string        `env:"ADMIN_API_KEY" reload:"set" secret:"true" usage:"административный ключ API, пустой отключает /admin и /metrics"`
+	OperationTimeout time.Duration `env:"OPERATION_TIMEOUT" default:"5s" usage:"таймаут операций с Кэшом и БД"`
+	ShutdownTimeout  time.Duration `env:"SHUTDOWN_TIMEOUT" default:"10s" usage:"время на завершение запросов при остановке"`
+	CacheTTL         time.Duration `env:"CACHE_TTL" reload:"true" default:"1m" usage:"время жизни цитат в Кэше"`
+	CacheWarmUp      bool          `env:"CACHE_WARMUP" default:"false" usage:"прогревать Кэш при запуске"`
+	CacheWarmUpTop   int           `env:"CACHE_WARMUP_TOP" default:"0" usage:"прогревать только столько самых популярных цитат, 0 - все"`
+	NegativeCacheTTL time.Duration `env:"NEGATIVE_CACHE_TTL" reload:"true" default:"30s" usage:"время жизни отметки об отсутствии цитаты"`
+	BloomFilter      bool          `env:"BLOOM_FILTER" default:"false" usage:"отсекать несуществующие ID фильтром Блума"`
+	WatchInterval    time.Duration `env:"CONFIG_WATCH_INTERVAL" default:"5s" usage:"период проверки изменений файла настроек и секретов, 0 - только по SIGHUP"`
+	GraphQL          GraphQL
+	Stream           Stream
+	Tracing          Tracing
+	Logging          Logging
+	AccessLog        AccessLog
+}
+
+// Действующие настройки. Заменяются целиком при загрузке и перезагрузке
+var current atomic.Pointer[Config]
+
+// Возвращает действующие настройки без повторного чтения источников. Если настройки
+// еще не загружались, один раз собирает их из значений по умолчанию, файла из
+// CONFIG_FILE и переменных окружения, подставляя значения по умолчанию вместо неверных
+func Current() Config {
+	if conf := current.Load(); conf != nil {
+		return *conf
+	}
+
+	conf, _ := build(os.Getenv(FileEnv), nil)
+	current.CompareAndSwap(nil, &conf)
+
+	return *current.Load()
+}
+
+// Заменяет действующие настройки
+func Set(conf Config) {
+	current.Store(&conf)
+}
+
+// Загружает настройки по слоям: значения по умолчанию, файл YAML или TOML, переменные
+// окружения вместе с *_FILE и флаги командной строки. Каждый следующий слой имеет
+// приоритет над предыдущим. Возвращает аргументы, оставшиеся после флагов. Настройки не
+// проверяются, для этого нужен Validate
+func Load(args []string) (Config, []string, error) {
+	conf, rest, _, err := parse(args)
+	if err != nil {
+		return Config{}, nil, err
+	}
+
+	Set(conf)
+
+	return conf, rest, nil
+}
+
+// Разбирает флаги и собирает настройки. Возвращает также путь к файлу настроек
+func parse(args []string) (Config, []string, string, error) {
+	flags := flag.NewFlagSet("returnauf", flag.ContinueOnError)
+
+	file := flags.String("config", os.Getenv(FileEnv), "путь к файлу настроек YAML или TOML")
+
+	var conf Config
+
+	for _, f := range fields(&conf) {
+		if f.secret {
+			continue
+		}
+		flags.String(flagName(f.key), f.def, f.usage)
 	}
+
+	err := flags.Parse(args)
+	if err != nil {
+		return Config{}, nil, "", err
+	}
+
+	flagged := map[string]string{}
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name != "config" {
+			flagged[envName(f.Name)] = f.Value.String()
+		}
+	})
+
+	conf, err = build(*file, flagged)
+	if err != nil {
+		return Config{}, nil, "", err
+	}
+	return conf, flags.Args(), *file, nil
+}
+
+// Собирает настройки из файла, окружения и переданных значений флагов
+func build(file string, flagged map[string]string) (Config, error) {
+	var conf Config
+
+	values := map[string]string{}
+	var errs []error
+
+	if file != "" {
+		fileValues, err := readFile(file)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		merge(values, fileValues)
+	}
+
+	envValues, err := readEnv(fields(&conf))
+	if err != nil {
+		errs = append(errs, err)
+	}
+	merge(values, envValues)
+	merge(values, flagged)
+
+	errs = append(errs, decode(&conf, values))
+
+	conf.Redis.Mode = strings.ToLower(conf.Redis.Mode)
+	conf.Tracing.Exporter = strings.ToLower(conf.Tracing.Exporter)
+	conf.Logging.Level = strings.ToLower(conf.Logging.Level)
+	conf.Logging.Encoding = strings.ToLower(conf.Logging.Encoding)
+
+	return conf, errors.Join(errs...)
+}
+
+// Переносит значения слоя поверх накопленных
+func merge(dst map[string]string, src map[string]string) {
+	for k, v := range src {
+		dst[k] = v
+	}
+}
+
+// Структура содержащая настройки эндпоинта GraphQL. GraphiQL включается только для
+// разработки
+type GraphQL struct {
+	MaxDepth      int  `env:"GRAPHQL_MAX_DEPTH" reload:"true" default:"6" usage:"максимальная глубина запроса GraphQL"`
+	MaxComplexity int  `env:"GRAPHQL_MAX_COMPLEXITY" reload:"true" default:"500" usage:"максимальная сложность запроса GraphQL"`
+	GraphiQL      bool `env:"GRAPHIQL" default:"false" usage:"включить GraphiQL"`
+}
+
+// Структура содержащая настройки потоков цитат SSE и WebSocket. MaxConnections равный 0
+// снимает ограничение
+type Stream struct {
+	MaxConnections int           `env:"STREAM_MAX_CONNECTIONS" reload:"true" default:"5" usage:"одновременных потоков на ключ API, 0 - без ограничения"`
+	Heartbeat      time.Duration `env:"STREAM_HEARTBEAT" default:"15s" usage:"интервал сигналов активности в потоках"`
+}
+
+// Структура содержащая настройки трассировки OpenTelemetry. File задает файл для
+// экспортера stdout, Endpoint - адрес коллектора для OTLP по HTTP
+type Tracing struct {
+	Exporter    string `env:"TRACING_EXPORTER" default:"none" usage:"экспортер трассировки: none, stdout или otlp"`
+	Endpoint    string `env:"TRACING_OTLP_ENDPOINT" usage:"адрес коллектора OTLP"`
+	File        string `env:"TRACING_FILE" usage:"файл для экспортера stdout"`
+	ServiceName string `env:"TRACING_SERVICE_NAME" default:"returnauf" usage:"имя сервиса в трассах"`
+}
+
+// Структура содержащая настройки журнала. Output может содержать stdout, stderr и пути к
+// файлам через запятую. Файлы ротируются по достижении MaxSize мегабайт, при этом
+// хранится не больше MaxBackups прежних файлов. Выборка включается, если SamplingInitial
+// больше 0: за каждую секунду пишутся первые SamplingInitial записей с одинаковым
+// сообщением, а затем каждая SamplingThereafter-я. Service, Version и Instance
+// добавляются к каждой записи, пустой Instance заменяется именем хоста
+type Logging struct {
+	Level              string   `env:"LOG_LEVEL" reload:"true" default:"info" usage:"уровень журнала: debug, info, warn или error"`
+	Encoding           string   `env:"LOG_ENCODING" default:"console" usage:"формат журнала: console или json"`
+	Output             []string `env:"LOG_OUTPUT" default:"stdout" usage:"куда писать журнал через запятую: stdout, stderr или пути к файлам"`
+	MaxSize            int      `env:"LOG_MAX_SIZE" default:"100" usage:"размер файла журнала в МБ для ротации, 0 - без ротации"`
+	MaxBackups         int      `env:"LOG_MAX_BACKUPS" default:"5" usage:"число хранимых прежних файлов журнала, 0 - все"`
+	SamplingInitial    int      `env:"LOG_SAMPLING_INITIAL" default:"0" usage:"записей с одинаковым сообщением в секунду до выборки, 0 - без выборки"`
+	SamplingThereafter int      `env:"LOG_SAMPLING_THEREAFTER" default:"100" usage:"после SamplingInitial пишется каждая такая запись"`
+	Service            string   `env:"LOG_SERVICE" default:"returnauf" usage:"имя сервиса в записях журнала"`
+	Version            string   `env:"LOG_VERSION" usage:"версия сервиса в записях журнала"`
+	Instance           string   `env:"LOG_INSTANCE" usage:"имя экземпляра в записях журнала, пустое - имя хоста"`
+}
+
+// Структура содержащая настройки журнала запросов. Пути в Exclude сравниваются целиком,
+// а заканчивающиеся на * - по префиксу. Запросы дольше SlowThreshold пишутся
+// предупреждением, нулевой SlowThreshold отключает выделение медленных запросов
+type AccessLog struct {
+	Enabled       bool          `env:"ACCESS_LOG_ENABLED" default:"true" usage:"писать в журнал строку на каждый HTTP запрос"`
+	Exclude       []string      `env:"ACCESS_LOG_EXCLUDE" reload:"true" default:"/healthz,/readyz" usage:"пути без записи в журнал запросов через запятую, * в конце - префикс"`
+	SlowThreshold time.Duration `env:"ACCESS_LOG_SLOW_THRESHOLD" reload:"true" default:"1s" usage:"время, после которого запрос считается медленным, 0 - не выделять"`
+}
+
+// Структура содержащая настройки подключения к Redis. REDIS_ADDRESS может содержать
+// несколько адресов через запятую. При пустом TLSServerName сертификат каждого узла
+// проверяется по хосту его адреса
+type Redis struct {
+	Mode             string        `env:"REDIS_MODE" default:"single" usage:"режим Redis: single, sentinel или cluster"`
+	Addrs            []string      `env:"REDIS_ADDRESS" default:"127.0.0.1:6379" usage:"адреса Redis через запятую"`
+	MasterName       string        `env:"REDIS_MASTER_NAME" usage:"имя мастера Sentinel"`
+	Username         string        `env:"REDIS_USERNAME" usage:"пользователь Redis"`
+	Password         string        `env:"REDIS_PASSWORD" secret:"true" usage:"пароль Redis"`
+	SentinelPassword string        `env:"REDIS_SENTINEL_PASSWORD" secret:"true" usage:"пароль Sentinel"`
+	DB               int           `env:"REDIS_DB" default:"0" usage:"номер БД Redis"`
+	TLS              bool          `env:"REDIS_TLS" default:"false" usage:"подключаться к Redis по TLS"`
+	TLSServerName    string        `env:"REDIS_TLS_SERVER_NAME" usage:"имя сервера в сертификате Redis, пустое - хост адреса узла"`
+	TLSCAFile        string        `env:"REDIS_TLS_CA_FILE" usage:"файл PEM с корневыми сертификатами для проверки Redis, пустой - системные"`
+	PoolSize         int           `env:"REDIS_POOL_SIZE" default:"0" usage:"размер пула соединений, 0 - по умолчанию клиента"`
+	DialTimeout      time.Duration `env:"REDIS_DIAL_TIMEOUT" default:"0s" usage:"таймаут подключения к Redis"`
+	ReadTimeout      time.Duration `env:"REDIS_READ_TIMEOUT" default:"0s" usage:"таймаут чтения из Redis"`
+	WriteTimeout     time.Duration `env:"REDIS_WRITE_TIMEOUT" default:"0s" usage:"таймаут записи в Redis"`
 }
@@ -0,0 +1,326 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Записывает файл во временную директорию теста и возвращает путь к нему
+func writeFile(t *testing.T, name string, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+
+	err := os.WriteFile(path, []byte(content), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// Возвращает настройки, проходящие проверку
+func validConfig() Config {
+	conf, _ := build("", nil)
+	conf.ApiKey = "key"
+
+	return conf
+}
+
+// Unit тест для функции Current
+func TestUnitCurrent(t *testing.T) {
+	current.Store(nil)
+	defer current.Store(nil)
+
+	got := Current()
+
+	assert.Equal(t, "0.0.0.0:8080", got.ServerAddr)
+	assert.Equal(t, []string{"127.0.0.1:6379"}, got.Redis.Addrs)
+	assert.Equal(t, RedisModeSingle, got.Redis.Mode)
+	assert.Equal(t, DefaultOperationTimeout, got.OperationTimeout)
+	assert.Equal(t, DefaultShutdownTimeout, got.ShutdownTimeout)
+	assert.Equal(t, DefaultCacheTTL, got.CacheTTL)
+	assert.Equal(t, DefaultNegativeCacheTTL, got.NegativeCacheTTL)
+	assert.Equal(t, DefaultGraphQLMaxDepth, got.GraphQL.MaxDepth)
+	assert.Equal(t, DefaultGraphQLMaxComplexity, got.GraphQL.MaxComplexity)
+	assert.Equal(t, DefaultStreamMaxConnections, got.Stream.MaxConnections)
+	assert.Equal(t, DefaultStreamHeartbeat, got.Stream.Heartbeat)
+	assert.Equal(t, TracingExporterNone, got.Tracing.Exporter)
+
+	assert.Equal(t, LogLevelInfo, got.Logging.Level)
+	assert.Equal(t, LogEncodingConsole, got.Logging.Encoding)
+	assert.Equal(t, []string{"stdout"}, got.Logging.Output)
+
+	// Окружение не перечитывается, пока настройки не заменены
+	t.Setenv("API_KEY", "fromEnv")
+
+	assert.Equal(t, "", Current().ApiKey)
+
+	Set(validConfig())
+
+	assert.Equal(t, "key", Current().ApiKey)
+}
+
+// Unit тест для функции Load
+func TestUnitLoad(t *testing.T) {
+	yamlFile := writeFile(t, "config.yaml", `
+server_address: 127.0.0.1:8000
+redis:
+  address: [10.0.0.1:6379]
+cache:
+  ttl: 2m
+stream:
+  heartbeat: 20s
+`)
+	tomlFile := writeFile(t, "config.toml", `
+server_address = "127.0.0.1:8001"
+
+[redis]
+mode = "CLUSTER"
+address = ["10.0.0.1:6379", "10.0.0.2:6379"]
+`)
+	secretFile := writeFile(t, "api_key", "fromFile\n")
+
+	cases := []struct {
+		name           string
+		args           []string
+		env            map[string]string
+		wantServerAddr string
+		wantRedisAddrs []string
+		wantRedisMode  string
+		wantCacheTTL   time.Duration
+		wantHeartbeat  time.Duration
+		wantApiKey     string
+		wantArgs       []string
+		wantLoadToFail bool
+	}{
+		{
+			name:           "defaults case",
+			wantServerAddr: "0.0.0.0:8080",
+			wantRedisAddrs: []string{"127.0.0.1:6379"},
+			wantRedisMode:  RedisModeSingle,
+			wantCacheTTL:   DefaultCacheTTL,
+			wantHeartbeat:  DefaultStreamHeartbeat,
+			wantArgs:       []string{},
+		},
+		{
+			name:           "yaml file case",
+			args:           []string{"-config", yamlFile},
+			wantServerAddr: "127.0.0.1:8000",
+			wantRedisAddrs: []string{"10.0.0.1:6379"},
+			wantRedisMode:  RedisModeSingle,
+			wantCacheTTL:   time.Minute * 2,
+			wantHeartbeat:  time.Second * 20,
+			wantArgs:       []string{},
+		},
+		{
+			name:           "toml file from env case",
+			env:            map[string]string{FileEnv: tomlFile},
+			wantServerAddr: "127.0.0.1:8001",
+			wantRedisAddrs: []string{"10.0.0.1:6379", "10.0.0.2:6379"},
+			wantRedisMode:  RedisModeCluster,
+			wantCacheTTL:   DefaultCacheTTL,
+			wantHeartbeat:  DefaultStreamHeartbeat,
+			wantArgs:       []string{},
+		},
+		{
+			name:           "env over file and flag over env case",
+			args:           []string{"-config", yamlFile, "-cache-ttl", "3m", "migrate", "up"},
+			env:            map[string]string{"SERVER_ADDRESS": "127.0.0.1:9000", "CACHE_TTL": "4m", "API_KEY": "fromEnv"},
+			wantServerAddr: "127.0.0.1:9000",
+			wantRedisAddrs: []string{"10.0.0.1:6379"},
+			wantRedisMode:  RedisModeSingle,
+			wantCacheTTL:   time.Minute * 3,
+			wantHeartbeat:  time.Second * 20,
+			wantApiKey:     "fromEnv",
+			wantArgs:       []string{"migrate", "up"},
+		},
+		{
+			name:           "secret file case",
+			env:            map[string]string{"API_KEY_FILE": secretFile},
+			wantServerAddr: "0.0.0.0:8080",
+			wantRedisAddrs: []string{"127.0.0.1:6379"},
+			wantRedisMode:  RedisModeSingle,
+			wantCacheTTL:   DefaultCacheTTL,
+			wantHeartbeat:  DefaultStreamHeartbeat,
+			wantApiKey:     "fromFile",
+			wantArgs:       []string{},
+		},
+		{
+			name:           "both value and secret file case",
+			env:            map[string]string{"API_KEY": "fromEnv", "API_KEY_FILE": secretFile},
+			wantLoadToFail: true,
+		},
+		{
+			name:           "secret flag case",
+			args:           []string{"-api-key", "fromFlag"},
+			wantLoadToFail: true,
+		},
+		{
+			name:           "unknown file key case",
+			args:           []string{"-config", writeFile(t, "typo.yaml", "cache_tll: 1m\n")},
+			wantLoadToFail: true,
+		},
+		{
+			name:           "unknown file format case",
+			args:           []string{"-config", writeFile(t, "config.json", "{}")},
+			wantLoadToFail: true,
+		},
+		{
+			name:           "invalid value case",
+			env:            map[string]string{"CACHE_TTL": "often"},
+			wantLoadToFail: true,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			for k, v := range cs.env {
+				t.Setenv(k, v)
+			}
+
+			got, gotArgs, gotErr := Load(cs.args)
+
+			assert.Equal(t, cs.wantLoadToFail, gotErr != nil)
+
+			if !cs.wantLoadToFail {
+				assert.Equal(t, cs.wantServerAddr, got.ServerAddr)
+				assert.Equal(t, cs.wantRedisAddrs, got.Redis.Addrs)
+				assert.Equal(t, cs.wantRedisMode, got.Redis.Mode)
+				assert.Equal(t, cs.wantCacheTTL, got.CacheTTL)
+				assert.Equal(t, cs.wantHeartbeat, got.Stream.Heartbeat)
+				assert.Equal(t, cs.wantApiKey, got.ApiKey)
+				assert.Equal(t, cs.wantArgs, append([]string{}, gotArgs...))
+				assert.Equal(t, got, Current())
+			}
+		})
+	}
+	current.Store(nil)
+}
+
+// Unit тест для функции Validate
+func TestUnitValidate(t *testing.T) {
+	cases := []struct {
+		name               string
+		modify             func(c *Config)
+		wantValidateToFail bool
+	}{
+		{
+			name:               "valid case",
+			modify:             func(c *Config) {},
+			wantValidateToFail: false,
+		},
+		{
+			name:               "missing api key case",
+			modify:             func(c *Config) { c.ApiKey = "" },
+			wantValidateToFail: true,
+		},
+		{
+			name:               "same admin key case",
+			modify:             func(c *Config) { c.AdminApiKey = c.ApiKey },
+			wantValidateToFail: true,
+		},
+		{
+			name:               "bad server address case",
+			modify:             func(c *Config) { c.ServerAddr = "localhost" },
+			wantValidateToFail: true,
+		},
+		{
+			name:               "bad port case",
+			modify:             func(c *Config) { c.GRPCAddr = "0.0.0.0:99999" },
+			wantValidateToFail: true,
+		},
+		{
+			name:               "sentinel without master case",
+			modify:             func(c *Config) { c.Redis.Mode = RedisModeSentinel },
+			wantValidateToFail: true,
+		},
+		{
+			name:               "tls server name without tls case",
+			modify:             func(c *Config) { c.Redis.TLSServerName = "redis.internal" },
+			wantValidateToFail: true,
+		},
+		{
+			name:               "tls ca file without tls case",
+			modify:             func(c *Config) { c.Redis.TLSCAFile = "ca.pem" },
+			wantValidateToFail: true,
+		},
+		{
+			name:               "several addresses in single mode case",
+			modify:             func(c *Config) { c.Redis.Addrs = []string{"a:1", "b:2"} },
+			wantValidateToFail: true,
+		},
+		{
+			name:               "zero operation timeout case",
+			modify:             func(c *Config) { c.OperationTimeout = 0 },
+			wantValidateToFail: true,
+		},
+		{
+			name:               "negative ttl longer than ttl case",
+			modify:             func(c *Config) { c.NegativeCacheTTL = c.CacheTTL + time.Second },
+			wantValidateToFail: true,
+		},
+		{
+			name:               "unknown exporter case",
+			modify:             func(c *Config) { c.Tracing.Exporter = "jaeger" },
+			wantValidateToFail: true,
+		},
+		{
+			name:               "bad trusted proxy case",
+			modify:             func(c *Config) { c.TrustedProxies = []string{"10.0.0.0/33"} },
+			wantValidateToFail: true,
+		},
+		{
+			name:               "trusted proxy subnet case",
+			modify:             func(c *Config) { c.TrustedProxies = []string{"10.0.0.1", "172.16.0.0/12"} },
+			wantValidateToFail: false,
+		},
+		{
+			name:               "negative slow threshold case",
+			modify:             func(c *Config) { c.AccessLog.SlowThreshold = -time.Second },
+			wantValidateToFail: true,
+		},
+		{
+			name:               "unknown log encoding case",
+			modify:             func(c *Config) { c.Logging.Encoding = "logfmt" },
+			wantValidateToFail: true,
+		},
+		{
+			name:               "sampling without thereafter case",
+			modify:             func(c *Config) { c.Logging.SamplingInitial, c.Logging.SamplingThereafter = 100, 0 },
+			wantValidateToFail: true,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			conf := validConfig()
+			cs.modify(&conf)
+
+			gotErr := conf.Validate()
+
+			assert.Equal(t, cs.wantValidateToFail, gotErr != nil)
+		})
+	}
+}
+
+// Unit тест для функции Print
+func TestUnitPrint(t *testing.T) {
+	conf := validConfig()
+	conf.Redis.Password = "password"
+	conf.Redis.Addrs = []string{"a:1", "b:2"}
+
+	var buf bytes.Buffer
+
+	err := conf.Print(&buf)
+
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), `API_KEY = "[redacted]"`+"\n")
+	assert.Contains(t, buf.String(), `REDIS_PASSWORD = "[redacted]"`+"\n")
+	assert.Contains(t, buf.String(), `ADMIN_API_KEY = ""`+"\n")
+	assert.Contains(t, buf.String(), `REDIS_ADDRESS = "a:1,b:2"`+"\n")
+	assert.Contains(t, buf.String(), `CACHE_TTL = "1m0s"`+"\n")
+	assert.NotContains(t, buf.String(), "password")
+}
@@ -0,0 +1,207 @@
+package config
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"reflect"
+	"syscall"
+	"time"
+)
+
+// Изменение одной настройки при перезагрузке. Значения секретов скрыты. Restart
+// означает, что новое значение вступит в силу только после перезапуска
+type Change struct {
+	Key     string
+	Old     string
+	New     string
+	Restart bool
+}
+
+// Функция, получающая результат перезагрузки: новые действующие настройки и изменения
+// или ошибку, при которой действующие настройки не меняются
+type ReloadFunc func(conf Config, changes []Change, err error)
+
+// Отметка файла, по которой замечается его изменение
+type stamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// Структура, перезагружающая настройки по SIGHUP и при изменении файла настроек или
+// файлов секретов *_FILE
+type Reloader struct {
+	args     []string
+	interval time.Duration
+	stamps   map[string]stamp
+	last     Config
+}
+
+// Создает перезагрузчик для аргументов командной строки, с которыми были загружены
+// настройки. interval задает период проверки файлов, 0 отключает проверку
+func NewReloader(args []string, interval time.Duration) *Reloader {
+	r := &Reloader{
+		args:     args,
+		interval: interval,
+		last:     Current(),
+	}
+	r.stamps = r.snapshot()
+
+	return r
+}
+
+// Загружает и проверяет настройки заново. Новые значения настроек без тега reload не
+// применяются до перезапуска и попадают в список изменений один раз, пока не изменятся
+// снова. При ошибке действующие настройки остаются прежними
+func (r *Reloader) Reload() (Config, []Change, error) {
+	next, _, _, err := parse(r.args)
+	if err != nil {
+		return Current(), nil, err
+	}
+
+	err = next.Validate()
+	if err != nil {
+		return Current(), nil, err
+	}
+
+	prev := Current()
+	changes := unreported(Diff(prev, next), Diff(r.last, next))
+	r.last = next
+
+	keepStatic(&next, &prev)
+	Set(next)
+
+	return next, changes, nil
+}
+
+// Убирает из changes изменения, требующие перезапуска, о которых уже сообщалось, то есть
+// отсутствующие среди изменений с прошлой перезагрузки fresh
+func unreported(changes []Change, fresh []Change) []Change {
+	keys := map[string]bool{}
+	for _, change := range fresh {
+		keys[change.Key] = true
+	}
+
+	var result []Change
+	for _, change := range changes {
+		if change.Restart && !keys[change.Key] {
+			continue
+		}
+		result = append(result, change)
+	}
+	return result
+}
+
+// Ждет SIGHUP или изменения файлов и перезагружает настройки, передавая результат в
+// fn. Завершается при отмене контекста
+func (r *Reloader) Watch(ctx context.Context, fn ReloadFunc) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	var tick <-chan time.Time
+
+	if r.interval > 0 {
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-hangup:
+
+		case <-tick:
+			stamps := r.snapshot()
+			if reflect.DeepEqual(stamps, r.stamps) {
+				continue
+			}
+		}
+
+		r.stamps = r.snapshot()
+
+		fn(r.Reload())
+	}
+}
+
+// Возвращает отметки файла настроек и файлов секретов
+func (r *Reloader) snapshot() map[string]stamp {
+	paths := []string{}
+
+	_, _, file, err := parse(r.args)
+	if err == nil && file != "" {
+		paths = append(paths, file)
+	}
+	for _, f := range fields(&Config{}) {
+		path := os.Getenv(f.key + fileSuffix)
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+
+	stamps := map[string]stamp{}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			stamps[path] = stamp{}
+			continue
+		}
+		stamps[path] = stamp{modTime: info.ModTime(), size: info.Size()}
+	}
+	return stamps
+}
+
+// Возвращает изменившиеся настройки в порядке их объявления
+func Diff(prev Config, next Config) []Change {
+	var changes []Change
+
+	nextFields := fields(&next)
+
+	for i, f := range fields(&prev) {
+		old := format(f.value.Interface())
+		new := format(nextFields[i].value.Interface())
+		if old == new {
+			continue
+		}
+
+		restart := !f.reloadable(old, new)
+
+		if f.secret {
+			old, new = redact(old), redact(new)
+		}
+
+		changes = append(changes, Change{
+			Key:     f.key,
+			Old:     old,
+			New:     new,
+			Restart: restart,
+		})
+	}
+	return changes
+}
+
+// Скрывает значение секрета, сохраняя различие между пустым и заданным значением
+func redact(value string) string {
+	if value == "" {
+		return ""
+	}
+	return redacted
+}
+
+// Возвращает в next прежние значения настроек, которые нельзя применить без перезапуска
+func keepStatic(next *Config, prev *Config) {
+	prevFields := fields(prev)
+
+	for i, f := range fields(next) {
+		old := format(prevFields[i].value.Interface())
+		new := format(f.value.Interface())
+
+		if !f.reloadable(old, new) {
+			f.value.Set(prevFields[i].value)
+		}
+	}
+}
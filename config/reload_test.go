@@ -0,0 +1,177 @@
+package config
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Unit тест для функции Diff
+func TestUnitDiff(t *testing.T) {
+	prev := validConfig()
+
+	next := prev
+	next.ApiKey = "rotated"
+	next.AdminApiKey = "admin"
+	next.CacheTTL = time.Minute * 2
+	next.ServerAddr = "0.0.0.0:8081"
+
+	got := Diff(prev, next)
+
+	assert.Equal(t, []Change{
+		{Key: "SERVER_ADDRESS", Old: "0.0.0.0:8080", New: "0.0.0.0:8081", Restart: true},
+		{Key: "API_KEY", Old: redacted, New: redacted, Restart: false},
+		{Key: "ADMIN_API_KEY", Old: "", New: redacted, Restart: true},
+		{Key: "CACHE_TTL", Old: "1m0s", New: "2m0s", Restart: false},
+	}, got)
+	assert.Empty(t, Diff(prev, prev))
+
+	rotated := next
+	rotated.AdminApiKey = "rotated admin"
+
+	assert.Equal(t, []Change{
+		{Key: "ADMIN_API_KEY", Old: redacted, New: redacted, Restart: false},
+	}, Diff(next, rotated))
+}
+
+// Unit тест для функции Reload
+func TestUnitReload(t *testing.T) {
+	cases := []struct {
+		name             string
+		file             string
+		wantApiKey       string
+		wantCacheTTL     time.Duration
+		wantServerAddr   string
+		wantChanges      []string
+		wantReloadToFail bool
+	}{
+		{
+			name:           "reloadable change case",
+			file:           "api_key: rotated\ncache:\n  ttl: 2m\n",
+			wantApiKey:     "rotated",
+			wantCacheTTL:   time.Minute * 2,
+			wantServerAddr: "0.0.0.0:8080",
+			wantChanges:    []string{"API_KEY", "CACHE_TTL"},
+		},
+		{
+			name:           "restart required case",
+			file:           "api_key: key\nserver_address: 0.0.0.0:8081\n",
+			wantApiKey:     "key",
+			wantCacheTTL:   DefaultCacheTTL,
+			wantServerAddr: "0.0.0.0:8080",
+			wantChanges:    []string{"SERVER_ADDRESS"},
+		},
+		{
+			name:           "admin key set case",
+			file:           "api_key: key\nadmin_api_key: admin\n",
+			wantApiKey:     "key",
+			wantCacheTTL:   DefaultCacheTTL,
+			wantServerAddr: "0.0.0.0:8080",
+			wantChanges:    []string{"ADMIN_API_KEY"},
+		},
+		{
+			name:             "invalid config case",
+			file:             "api_key: \"\"\ncache:\n  ttl: 2m\n",
+			wantApiKey:       "key",
+			wantCacheTTL:     DefaultCacheTTL,
+			wantServerAddr:   "0.0.0.0:8080",
+			wantReloadToFail: true,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			path := writeFile(t, "config.yaml", "api_key: key\n")
+
+			_, _, err := Load([]string{"-config", path})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer current.Store(nil)
+
+			os.WriteFile(path, []byte(cs.file), 0o600)
+
+			reloader := NewReloader([]string{"-config", path}, 0)
+
+			_, gotChanges, gotErr := reloader.Reload()
+
+			gotKeys := []string{}
+			for _, change := range gotChanges {
+				gotKeys = append(gotKeys, change.Key)
+			}
+
+			assert.Equal(t, cs.wantReloadToFail, gotErr != nil)
+			assert.Equal(t, cs.wantApiKey, Current().ApiKey)
+			assert.Equal(t, cs.wantCacheTTL, Current().CacheTTL)
+			assert.Equal(t, cs.wantServerAddr, Current().ServerAddr)
+			assert.Empty(t, Current().AdminApiKey)
+			if !cs.wantReloadToFail {
+				assert.Equal(t, cs.wantChanges, gotKeys)
+
+				// Об изменениях, ждущих перезапуска, повторно не сообщается
+				_, gotChanges, _ = reloader.Reload()
+				assert.Empty(t, gotChanges)
+			}
+		})
+	}
+}
+
+// Unit тест для функции Watch
+func TestUnitWatch(t *testing.T) {
+	path := writeFile(t, "config.yaml", "api_key: key\n")
+	secret := writeFile(t, "admin_key", "admin\n")
+
+	t.Setenv("ADMIN_API_KEY_FILE", secret)
+
+	_, _, err := Load([]string{"-config", path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer current.Store(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	reloads := make(chan []Change, 1)
+
+	go NewReloader([]string{"-config", path}, time.Millisecond*10).Watch(ctx, func(conf Config, changes []Change, err error) {
+		reloads <- changes
+	})
+
+	wait := func() []Change {
+		select {
+		case changes := <-reloads:
+			return changes
+		case <-time.After(time.Second):
+			t.Fatal("no reload")
+			return nil
+		}
+	}
+
+	t.Run("config file change case", func(t *testing.T) {
+		os.WriteFile(path, []byte("api_key: rotated\n"), 0o600)
+
+		assert.Equal(t, "API_KEY", wait()[0].Key)
+		assert.Equal(t, "rotated", Current().ApiKey)
+	})
+
+	t.Run("secret file change case", func(t *testing.T) {
+		os.WriteFile(secret, []byte("new admin key\n"), 0o600)
+
+		changes := wait()
+
+		assert.Equal(t, "ADMIN_API_KEY", changes[0].Key)
+		assert.False(t, changes[0].Restart)
+		assert.Equal(t, "new admin key", Current().AdminApiKey)
+	})
+
+	t.Run("hangup case", func(t *testing.T) {
+		syscall.Kill(os.Getpid(), syscall.SIGHUP)
+
+		assert.Empty(t, wait())
+	})
+}
@@ -0,0 +1,268 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Суффикс переменных окружения с путем к файлу значения, например для секретов Docker
+const fileSuffix = "_FILE"
+
+// Ошибка неподдерживаемого формата файла настроек
+var ErrUnknownFormat = errors.New("unknown config file format, expected .yaml, .yml or .toml")
+
+// Поле настроек с описанием из тегов. Тег reload:"true" разрешает менять значение без
+// перезапуска, reload:"set" - только пока оно остается непустым
+type field struct {
+	key       string
+	def       string
+	usage     string
+	secret    bool
+	reload    bool
+	reloadSet bool
+	value     reflect.Value
+}
+
+// Сообщает, применяется ли смена значения old на new без перезапуска
+func (f field) reloadable(old string, new string) bool {
+	return f.reload || f.reloadSet && old != "" && new != ""
+}
+
+// Возвращает поля настроек с тегом env, обходя вложенные структуры
+func fields(conf *Config) []field {
+	return collect(reflect.ValueOf(conf).Elem(), nil)
+}
+
+// Рекурсивно собирает поля структуры
+func collect(v reflect.Value, list []field) []field {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+
+		key, ok := sf.Tag.Lookup("env")
+		if !ok {
+			if sf.Type.Kind() == reflect.Struct {
+				list = collect(v.Field(i), list)
+			}
+			continue
+		}
+
+		list = append(list, field{
+			key:       key,
+			def:       sf.Tag.Get("default"),
+			usage:     sf.Tag.Get("usage"),
+			secret:    sf.Tag.Get("secret") == "true",
+			reload:    sf.Tag.Get("reload") == "true",
+			reloadSet: sf.Tag.Get("reload") == "set",
+			value:     v.Field(i),
+		})
+	}
+	return list
+}
+
+// Возвращает имя флага для переменной окружения: REDIS_ADDRESS -> redis-address
+func flagName(key string) string {
+	return strings.ReplaceAll(strings.ToLower(key), "_", "-")
+}
+
+// Возвращает имя переменной окружения для флага
+func envName(flag string) string {
+	return strings.ReplaceAll(strings.ToUpper(flag), "-", "_")
+}
+
+// Читает файл настроек YAML или TOML. Вложенные ключи объединяются через "_", поэтому
+// redis.address в файле соответствует REDIS_ADDRESS, а списки - значениям через запятую
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := map[string]interface{}{}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return nil, ErrUnknownFormat
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	known := map[string]bool{}
+	for _, f := range fields(&Config{}) {
+		known[f.key] = true
+	}
+
+	values := map[string]string{}
+	flatten("", raw, values)
+
+	var errs []error
+	for _, key := range sortedKeys(values) {
+		if !known[key] {
+			errs = append(errs, fmt.Errorf("%s: unknown setting %s", path, key))
+		}
+	}
+	return values, errors.Join(errs...)
+}
+
+// Раскладывает вложенные таблицы файла в плоские ключи в стиле переменных окружения
+func flatten(prefix string, raw map[string]interface{}, values map[string]string) {
+	for k, v := range raw {
+		key := strings.ToUpper(strings.ReplaceAll(k, "-", "_"))
+		if prefix != "" {
+			key = prefix + "_" + key
+		}
+
+		switch v := v.(type) {
+		case map[string]interface{}:
+			flatten(key, v, values)
+		case []interface{}:
+			items := make([]string, len(v))
+			for i, item := range v {
+				items[i] = fmt.Sprint(item)
+			}
+			values[key] = strings.Join(items, ",")
+		case nil:
+			values[key] = ""
+		default:
+			values[key] = fmt.Sprint(v)
+		}
+	}
+}
+
+// Читает переменные окружения. Пустые переменные считаются незаданными. Вместо KEY
+// можно задать KEY_FILE с путем к файлу, содержимое которого станет значением
+func readEnv(list []field) (map[string]string, error) {
+	values := map[string]string{}
+	var errs []error
+
+	for _, f := range list {
+		value := os.Getenv(f.key)
+		path := os.Getenv(f.key + fileSuffix)
+
+		switch {
+		case value != "" && path != "":
+			errs = append(errs, fmt.Errorf("%s: both %s and %s are set", f.key, f.key, f.key+fileSuffix))
+
+		case path != "":
+			data, err := os.ReadFile(path)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", f.key+fileSuffix, err))
+				continue
+			}
+			values[f.key] = strings.TrimRight(string(data), "\r\n")
+
+		case value != "":
+			values[f.key] = value
+		}
+	}
+	return values, errors.Join(errs...)
+}
+
+// Заполняет поля значениями слоев. Для незаданных и неверных значений используется
+// значение по умолчанию, а ошибки разбора возвращаются вместе
+func decode(conf *Config, values map[string]string) error {
+	var errs []error
+
+	for _, f := range fields(conf) {
+		raw, ok := values[f.key]
+		if !ok {
+			raw = f.def
+		}
+
+		err := set(f.value, raw)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", f.key, err))
+
+			set(f.value, f.def)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Разбирает строку в значение поля
+func set(v reflect.Value, raw string) error {
+	raw = strings.TrimSpace(raw)
+
+	switch v.Interface().(type) {
+	case string:
+		v.SetString(raw)
+
+	case bool:
+		if raw == "" {
+			v.SetBool(false)
+			return nil
+		}
+
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", raw)
+		}
+		v.SetBool(b)
+
+	case int:
+		if raw == "" {
+			v.SetInt(0)
+			return nil
+		}
+
+		i, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		v.SetInt(int64(i))
+
+	case time.Duration:
+		if raw == "" {
+			v.SetInt(0)
+			return nil
+		}
+
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", raw)
+		}
+		v.SetInt(int64(d))
+
+	case []string:
+		var list []string
+
+		for _, item := range strings.Split(raw, ",") {
+			item = strings.TrimSpace(item)
+			if item != "" {
+				list = append(list, item)
+			}
+		}
+		v.Set(reflect.ValueOf(list))
+
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// Возвращает ключи в алфавитном порядке
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
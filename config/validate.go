@@ -0,0 +1,201 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Верхняя граница для таймаутов запросов и остановки
+const maxTimeout = time.Minute * 5
+
+// Заменитель значений секретов при выводе
+const redacted = "[redacted]"
+
+// Проверяет настройки: адреса разбираются, обязательные секреты заданы, длительности
+// и числа находятся в допустимых пределах. Возвращает все найденные ошибки вместе
+func (c Config) Validate() error {
+	var errs []error
+
+	check := func(key string, err error) {
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+		}
+	}
+
+	check("SERVER_ADDRESS", validateAddr(c.ServerAddr))
+	if c.GRPCAddr != "" {
+		check("GRPC_ADDRESS", validateAddr(c.GRPCAddr))
+	}
+	if c.ServerAddr != "" && c.ServerAddr == c.GRPCAddr {
+		check("GRPC_ADDRESS", errors.New("must differ from SERVER_ADDRESS"))
+	}
+
+	for _, proxy := range c.TrustedProxies {
+		check("TRUSTED_PROXIES", validateProxy(proxy))
+	}
+	if len(c.TrustedProxies) > 0 && c.ProxyHeader == "" {
+		check("PROXY_HEADER", errors.New("required with TRUSTED_PROXIES"))
+	}
+
+	check("REDIS_MODE", oneOf(c.Redis.Mode, RedisModeSingle, RedisModeSentinel, RedisModeCluster))
+	if len(c.Redis.Addrs) == 0 {
+		check("REDIS_ADDRESS", errors.New("required"))
+	}
+	if c.Redis.Mode == RedisModeSingle && len(c.Redis.Addrs) > 1 {
+		check("REDIS_ADDRESS", errors.New("single mode takes one address"))
+	}
+	for _, addr := range c.Redis.Addrs {
+		check("REDIS_ADDRESS", validateAddr(addr))
+	}
+	if c.Redis.Mode == RedisModeSentinel && c.Redis.MasterName == "" {
+		check("REDIS_MASTER_NAME", errors.New("required in sentinel mode"))
+	}
+	if !c.Redis.TLS && c.Redis.TLSServerName != "" {
+		check("REDIS_TLS_SERVER_NAME", errors.New("requires REDIS_TLS"))
+	}
+	if !c.Redis.TLS && c.Redis.TLSCAFile != "" {
+		check("REDIS_TLS_CA_FILE", errors.New("requires REDIS_TLS"))
+	}
+	check("REDIS_DB", nonNegative(c.Redis.DB))
+	check("REDIS_POOL_SIZE", nonNegative(c.Redis.PoolSize))
+	check("REDIS_DIAL_TIMEOUT", between(c.Redis.DialTimeout, 0, maxTimeout))
+	check("REDIS_READ_TIMEOUT", between(c.Redis.ReadTimeout, 0, maxTimeout))
+	check("REDIS_WRITE_TIMEOUT", between(c.Redis.WriteTimeout, 0, maxTimeout))
+
+	if c.DBAddr == "" {
+		check("DB_ADDRESS", errors.New("required"))
+	}
+
+	if c.ApiKey == "" {
+		check("API_KEY", errors.New("required"))
+	}
+	if c.AdminApiKey != "" && c.AdminApiKey == c.ApiKey {
+		check("ADMIN_API_KEY", errors.New("must differ from API_KEY"))
+	}
+
+	check("OPERATION_TIMEOUT", between(c.OperationTimeout, time.Millisecond, maxTimeout))
+	check("SHUTDOWN_TIMEOUT", between(c.ShutdownTimeout, time.Second, maxTimeout))
+	check("CACHE_TTL", between(c.CacheTTL, time.Second, time.Hour*24*30))
+	check("CACHE_WARMUP_TOP", nonNegative(c.CacheWarmUpTop))
+	check("NEGATIVE_CACHE_TTL", between(c.NegativeCacheTTL, time.Second, c.CacheTTL))
+
+	check("GRAPHQL_MAX_DEPTH", positive(c.GraphQL.MaxDepth))
+	check("GRAPHQL_MAX_COMPLEXITY", positive(c.GraphQL.MaxComplexity))
+
+	check("STREAM_MAX_CONNECTIONS", nonNegative(c.Stream.MaxConnections))
+	check("STREAM_HEARTBEAT", between(c.Stream.Heartbeat, time.Second, time.Hour))
+
+	check("LOG_LEVEL", oneOf(c.Logging.Level, LogLevelDebug, LogLevelInfo, LogLevelWarn, LogLevelError))
+	check("LOG_ENCODING", oneOf(c.Logging.Encoding, LogEncodingJSON, LogEncodingConsole))
+	if len(c.Logging.Output) == 0 {
+		check("LOG_OUTPUT", errors.New("required"))
+	}
+	check("LOG_MAX_SIZE", nonNegative(c.Logging.MaxSize))
+	check("LOG_MAX_BACKUPS", nonNegative(c.Logging.MaxBackups))
+	check("LOG_SAMPLING_INITIAL", nonNegative(c.Logging.SamplingInitial))
+	if c.Logging.SamplingInitial > 0 {
+		check("LOG_SAMPLING_THEREAFTER", positive(c.Logging.SamplingThereafter))
+	}
+	check("CONFIG_WATCH_INTERVAL", between(c.WatchInterval, 0, time.Hour))
+
+	check("ACCESS_LOG_SLOW_THRESHOLD", between(c.AccessLog.SlowThreshold, 0, maxTimeout))
+
+	check("TRACING_EXPORTER", oneOf(c.Tracing.Exporter, TracingExporterNone, TracingExporterStdout, TracingExporterOTLP))
+	if c.Tracing.Exporter != TracingExporterNone && c.Tracing.ServiceName == "" {
+		check("TRACING_SERVICE_NAME", errors.New("required when tracing is enabled"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Проверяет адрес вида host:port
+func validateAddr(addr string) error {
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid address %q", addr)
+	}
+
+	p, err := strconv.Atoi(port)
+	if err != nil || p < 0 || p > 65535 {
+		return fmt.Errorf("invalid port in %q", addr)
+	}
+	return nil
+}
+
+// Проверяет адрес или подсеть доверенного прокси
+func validateProxy(proxy string) error {
+	if net.ParseIP(proxy) != nil {
+		return nil
+	}
+
+	_, _, err := net.ParseCIDR(proxy)
+	if err != nil {
+		return fmt.Errorf("invalid address or subnet %q", proxy)
+	}
+	return nil
+}
+
+// Проверяет, что значение входит в список допустимых
+func oneOf(value string, allowed ...string) error {
+	for _, a := range allowed {
+		if value == a {
+			return nil
+		}
+	}
+	return fmt.Errorf("%q is not one of %s", value, strings.Join(allowed, ", "))
+}
+
+// Проверяет, что длительность лежит в пределах [min, max]
+func between(d time.Duration, min time.Duration, max time.Duration) error {
+	if d < min || d > max {
+		return fmt.Errorf("%s is out of range [%s, %s]", d, min, max)
+	}
+	return nil
+}
+
+// Проверяет, что число не отрицательно
+func nonNegative(i int) error {
+	if i < 0 {
+		return fmt.Errorf("%d is negative", i)
+	}
+	return nil
+}
+
+// Проверяет, что число больше нуля
+func positive(i int) error {
+	if i <= 0 {
+		return fmt.Errorf("%d must be positive", i)
+	}
+	return nil
+}
+
+// Выводит действующие настройки в формате .env, скрывая значения секретов
+func (c Config) Print(w io.Writer) error {
+	for _, f := range fields(&c) {
+		value := format(f.value.Interface())
+		if f.secret && value != "" {
+			value = redacted
+		}
+
+		_, err := fmt.Fprintf(w, "%s = %q\n", f.key, value)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Форматирует значение поля так же, как оно задается в переменной окружения
+func format(v interface{}) string {
+	switch v := v.(type) {
+	case []string:
+		return strings.Join(v, ",")
+	default:
+		return fmt.Sprint(v)
+	}
+}
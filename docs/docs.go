@@ -35,64 +35,1216 @@ const docTemplate = `{
                     }
                 ],
                 "description": "Возвращает полный список цитат, хранящихся в базе данных. Полезно для получения всех доступных данных для анализа, отображения или других операций. Цитаты возвращаются в формате JSON.",
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "text/plain",
+                    "application/yaml",
+                    "application/msgpack"
+                ],
+                "tags": [
+                    "Операции с цитатами"
+                ],
+                "summary": "Предоставляет все цитаты",
+                "operationId": "list-all",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Формат ответа: json, xml, text, yaml или msgpack. Имеет приоритет над заголовком Accept",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Минимальный ID цитаты",
+                        "name": "from_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Максимальный ID цитаты",
+                        "name": "to_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Подстрока, которую должна содержать цитата",
+                        "name": "contains",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Quote"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "405": {
+                        "description": "Method Not Allowed",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/cache": {
+            "delete": {
+                "security": [
+                    {
+                        "AdminKeyAuth": []
+                    }
+                ],
+                "description": "Удаляет из Кэша все ключи пространства имен returnauf, соответствующие glob-шаблону Redis.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Администрирование Кэша"
+                ],
+                "summary": "Удаляет записи Кэша по шаблону",
+                "operationId": "cache-evict-pattern",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "1*",
+                        "description": "Glob-шаблон ключей",
+                        "name": "pattern",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.CacheResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/cache/flush": {
+            "post": {
+                "security": [
+                    {
+                        "AdminKeyAuth": []
+                    }
+                ],
+                "description": "Удаляет из Кэша все ключи пространства имен returnauf, включая рейтинг популярности цитат для прогрева. Ключи других приложений в том же Redis не затрагиваются.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Администрирование Кэша"
+                ],
+                "summary": "Очищает Кэш",
+                "operationId": "cache-flush",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.CacheResult"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/cache/warmup": {
+            "post": {
+                "security": [
+                    {
+                        "AdminKeyAuth": []
+                    }
+                ],
+                "description": "Повторно загружает цитаты из базы данных в Кэш. Настройка CACHE_WARMUP_TOP ограничивает прогрев самыми популярными цитатами по числу обращений, пока обращений нет - цитатами с наименьшими ID.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Администрирование Кэша"
+                ],
+                "summary": "Прогревает Кэш",
+                "operationId": "cache-warmup",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.CacheResult"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/cache/{key}": {
+            "get": {
+                "security": [
+                    {
+                        "AdminKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает значение ключа в Кэше и оставшееся время его жизни. Полезно для проверки того, что цитата действительно закэширована.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Администрирование Кэша"
+                ],
+                "summary": "Предоставляет запись Кэша по ключу",
+                "operationId": "cache-inspect",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "105",
+                        "description": "Ключ в Кэше без пространства имен",
+                        "name": "key",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.CacheEntry"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "AdminKeyAuth": []
+                    }
+                ],
+                "description": "Удаляет ключ из Кэша. Следующий запрос за этой цитатой будет обслужен из базы данных.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Администрирование Кэша"
+                ],
+                "summary": "Удаляет запись Кэша по ключу",
+                "operationId": "cache-evict",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "105",
+                        "description": "Ключ в Кэше без пространства имен",
+                        "name": "key",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.CacheResult"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/filter/rebuild": {
+            "post": {
+                "security": [
+                    {
+                        "AdminKeyAuth": []
+                    }
+                ],
+                "description": "Пересобирает фильтр Блума по ID цитат в базе данных и меняет версию набора ID в кэше, чтобы фильтры остальных экземпляров пересобрались при следующем отказе. Импорт через API и CLI меняет версию сам, вызывать эндпоинт нужно после добавления цитат в базу данных напрямую.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Администрирование Кэша"
+                ],
+                "summary": "Пересобирает фильтр Блума",
+                "operationId": "filter-rebuild",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.CacheResult"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/import": {
+            "post": {
+                "security": [
+                    {
+                        "AdminKeyAuth": []
+                    }
+                ],
+                "description": "Импортирует цитаты из тела запроса в формате CSV, JSON-массива или NDJSON. Все строки проверяются заранее, и при наличии ошибок база данных не изменяется, а в ответе возвращается отчет по строкам. Импорт выполняется одной транзакцией. Добавленные и измененные цитаты отправляются событиями created и updated в потоки /stream/random и /ws этого экземпляра.",
+                "consumes": [
+                    "text/plain"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Администрирование цитат"
+                ],
+                "summary": "Импортирует цитаты",
+                "operationId": "import",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Формат файла: csv, json или ndjson. По умолчанию определяется по Content-Type",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "skip",
+                        "description": "Политика для существующих ID: upsert или skip",
+                        "name": "policy",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Проверить импорт без записи в базу данных",
+                        "name": "dry_run",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "text:quote,num:id",
+                        "description": "Отображение колонок на поля цитаты",
+                        "name": "map",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ImportReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ImportReport"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/export": {
+            "get": {
+                "security": [
+                    {
+                        "KeyAuth": []
+                    }
+                ],
+                "description": "Выгружает все цитаты, подходящие под фильтры, потоково: строки читаются из базы данных курсором и сразу пишутся в ответ, поэтому сервер не держит всю выборку в памяти. Ответ отдается как файл для скачивания.",
+                "produces": [
+                    "application/json",
+                    "text/plain"
+                ],
+                "tags": [
+                    "Операции с цитатами"
+                ],
+                "summary": "Выгружает цитаты в файл",
+                "operationId": "export",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "ndjson",
+                        "description": "Формат выгрузки: ndjson, csv или json",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Минимальный ID цитаты",
+                        "name": "from_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Максимальный ID цитаты",
+                        "name": "to_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Подстрока, которую должна содержать цитата",
+                        "name": "contains",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/responses.Quote"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "405": {
+                        "description": "Method Not Allowed",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/feed.{format}": {
+            "get": {
+                "security": [
+                    {
+                        "KeyAuth": []
+                    }
+                ],
+                "description": "Возвращает ленту последних добавленных цитат в формате RSS 2.0 или Atom 1.0. Идентификаторы записей стабильны и строятся из ID цитат. Лента поддерживает условные запросы через заголовки If-None-Match и If-Modified-Since: если цитаты не менялись, возвращается 304 без тела.",
+                "produces": [
+                    "text/xml"
+                ],
+                "tags": [
+                    "Ленты"
+                ],
+                "summary": "Предоставляет ленту новых цитат",
+                "operationId": "feed",
+                "parameters": [
+                    {
+                        "enum": [
+                            "rss",
+                            "atom"
+                        ],
+                        "type": "string",
+                        "description": "Формат ленты: rss или atom",
+                        "name": "format",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Количество записей, не больше 100",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "405": {
+                        "description": "Method Not Allowed",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/feed/daily.{format}": {
+            "get": {
+                "security": [
+                    {
+                        "KeyAuth": []
+                    }
+                ],
+                "description": "Возвращает ленту цитат дня за последние дни в формате RSS 2.0 или Atom 1.0. Цитата дня выбирается детерминированно по дате, поэтому одинакова для всех читателей. Лента поддерживает условные запросы: до смены дня или изменения цитат возвращается 304 без тела.",
+                "produces": [
+                    "text/xml"
+                ],
+                "tags": [
+                    "Ленты"
+                ],
+                "summary": "Предоставляет ленту цитат дня",
+                "operationId": "feed-daily",
+                "parameters": [
+                    {
+                        "enum": [
+                            "rss",
+                            "atom"
+                        ],
+                        "type": "string",
+                        "description": "Формат ленты: rss или atom",
+                        "name": "format",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 7,
+                        "description": "Количество дней, не больше 30",
+                        "name": "days",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "405": {
+                        "description": "Method Not Allowed",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/graphql": {
+            "get": {
+                "security": [
+                    {
+                        "KeyAuth": []
+                    }
+                ],
+                "description": "Выполняет запрос GraphQL над цитатами: quote(id), quotes(filter, page), randomQuote(filter) и search(q). Запрос принимается в теле POST в формате JSON или в параметрах GET. Запросы, превышающие ограничения глубины или сложности, отклоняются с кодом 400. Если включен GraphiQL, GET из браузера без параметра query открывает GraphiQL.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/html"
+                ],
+                "tags": [
+                    "GraphQL"
+                ],
+                "summary": "Выполняет запрос GraphQL",
+                "operationId": "graphql",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Текст запроса для GET",
+                        "name": "query",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Имя операции для GET",
+                        "name": "operationName",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Переменные в формате JSON для GET",
+                        "name": "variables",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "405": {
+                        "description": "Method Not Allowed",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "KeyAuth": []
+                    }
+                ],
+                "description": "Выполняет запрос GraphQL над цитатами: quote(id), quotes(filter, page), randomQuote(filter) и search(q). Запрос принимается в теле POST в формате JSON или в параметрах GET. Запросы, превышающие ограничения глубины или сложности, отклоняются с кодом 400. Если включен GraphiQL, GET из браузера без параметра query открывает GraphiQL.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/html"
+                ],
+                "tags": [
+                    "GraphQL"
+                ],
+                "summary": "Выполняет запрос GraphQL",
+                "operationId": "graphql",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Текст запроса для GET",
+                        "name": "query",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Имя операции для GET",
+                        "name": "operationName",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Переменные в формате JSON для GET",
+                        "name": "variables",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "405": {
+                        "description": "Method Not Allowed",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/healthz": {
+            "get": {
+                "description": "Сообщает, что процесс запущен и обрабатывает запросы. Зависимости не проверяются, поэтому эндпоинт подходит для проверки живости в оркестраторе. Аутентификация не требуется.",
                 "produces": [
                     "application/json"
                 ],
+                "tags": [
+                    "Состояние сервиса"
+                ],
+                "summary": "Проверяет живость процесса",
+                "operationId": "healthz",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Health"
+                        }
+                    }
+                }
+            }
+        },
+        "/random": {
+            "get": {
+                "security": [
+                    {
+                        "KeyAuth": []
+                    }
+                ],
+                "description": "Возвращает случайную цитату из базы данных. Если цитата отсутствует в кэше, то она извлекается из базы данных, добавляется в кэш и возвращается пользователю. Позволяет отображать динамическое содержимое, не перегружая базу данных. Случайность обеспечивается генератором случайных чисел: выбирается случайная позиция среди существующих цитат, поэтому каждая цитата выбирается с равной вероятностью, даже если часть ID удалена.",
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "text/plain",
+                    "application/yaml",
+                    "application/msgpack"
+                ],
+                "tags": [
+                    "Операции с цитатами"
+                ],
+                "summary": "Предоставляет случайную цитату",
+                "operationId": "random-quote",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Формат ответа: json, xml, text, yaml или msgpack. Имеет приоритет над заголовком Accept",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Quote"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "405": {
+                        "description": "Method Not Allowed",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/random/image": {
+            "get": {
+                "security": [
+                    {
+                        "KeyAuth": []
+                    }
+                ],
+                "description": "Возвращает случайную цитату, отрисованную на карточке для публикации в соцсетях. Параметры карточки те же, что и у карточки по ID.",
+                "produces": [
+                    "image/png",
+                    "image/svg+xml"
+                ],
                 "tags": [
                     "Операции с цитатами"
                 ],
-                "summary": "Предоставляет все цитаты",
-                "operationId": "list-all",
+                "summary": "Предоставляет карточку случайной цитаты",
+                "operationId": "random-quote-image",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "light",
+                        "description": "Тема карточки: light, dark или sepia",
+                        "name": "theme",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "og",
+                        "description": "Размер карточки: og (1200x630), square (1080x1080) или story (1080x1920)",
+                        "name": "size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "png",
+                        "description": "Формат карточки: png или svg",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/responses.Quote"
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
                         }
                     },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/responses.Error"
+                            "$ref": "#/definitions/responses.Problem"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/responses.Error"
+                            "$ref": "#/definitions/responses.Problem"
                         }
                     },
                     "405": {
                         "description": "Method Not Allowed",
                         "schema": {
-                            "$ref": "#/definitions/responses.Error"
+                            "$ref": "#/definitions/responses.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/responses.Error"
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
                         }
                     }
                 }
             }
         },
-        "/random": {
+        "/readyz": {
+            "get": {
+                "description": "Проверяет Redis и SQLite и возвращает состояние и задержку каждой зависимости в миллисекундах. Приложение не готово, если хотя бы одна зависимость недоступна, применяются миграции или сервер останавливается. Аутентификация не требуется.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Состояние сервиса"
+                ],
+                "summary": "Проверяет готовность принимать запросы",
+                "operationId": "readyz",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Readiness"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Readiness"
+                        }
+                    }
+                }
+            }
+        },
+        "/stream/random": {
             "get": {
                 "security": [
                     {
                         "KeyAuth": []
                     }
                 ],
-                "description": "Возвращает случайную цитату из базы данных. Если цитата отсутствует в кэше, то она извлекается из базы данных, добавляется в кэш и возвращается пользователю. Позволяет отображать динамическое содержимое, не перегружая базу данных. Случайность обеспечивается генератором случайных чисел.",
+                "description": "Открывает поток Server-Sent Events, который сразу и затем с заданным интервалом отправляет случайную цитату (событие random), а также сообщает о добавленных (created) и измененных (updated) цитатах. События created и updated отправляет только импорт через POST /admin/import на этом же экземпляре, импорт через CLI и изменения базы данных напрямую в поток не попадают. Раз в STREAM_HEARTBEAT отправляется комментарий heartbeat. Число одновременных потоков на ключ API ограничено, лишние отклоняются с кодом 429. При остановке сервера поток завершается.",
                 "produces": [
-                    "application/json"
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "Потоки цитат"
+                ],
+                "summary": "Поток случайных цитат и изменений",
+                "operationId": "stream-random",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "10s",
+                        "description": "Интервал случайных цитат: длительность (5s, 1m) или число секунд, от 1s до 1h",
+                        "name": "interval",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/ws": {
+            "get": {
+                "security": [
+                    {
+                        "KeyAuth": []
+                    }
+                ],
+                "description": "Открывает WebSocket с теми же событиями, что и /stream/random, включая ограничения событий created и updated. Сообщения отправляются в формате JSON вида {\"type\": \"random\", \"quote\": {...}}, сигналы активности - кадрами ping. При превышении числа соединений на ключ API соединение закрывается с кодом 1008, при остановке сервера - с кодом 1001.",
+                "tags": [
+                    "Потоки цитат"
+                ],
+                "summary": "WebSocket со случайными цитатами и изменениями",
+                "operationId": "websocket",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "10s",
+                        "description": "Интервал случайных цитат: длительность (5s, 1m) или число секунд, от 1s до 1h",
+                        "name": "interval",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "426": {
+                        "description": "Upgrade Required",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "KeyAuth": []
+                    }
+                ],
+                "description": "Возвращает цитату по её уникальному идентификатору (ID). Если цитата не найдена в кэше, происходит обращение к базе данных. Полученная цитата затем сохраняется в кэш для ускорения последующих запросов. Если запрошенного ID нет в базе данных, возвращается ошибка 404, нечисловой ID отклоняется с кодом 400, а при недоступности базы данных возвращается 503.",
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "text/plain",
+                    "application/yaml",
+                    "application/msgpack"
                 ],
                 "tags": [
                     "Операции с цитатами"
                 ],
-                "summary": "Предоставляет случайную цитату",
-                "operationId": "random-quote",
+                "summary": "Предоставляет цитату по заданному ID",
+                "operationId": "quote-id",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Формат ответа: json, xml, text, yaml или msgpack. Имеет приоритет над заголовком Accept",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "105",
+                        "description": "Позволяет указать ID цитаты",
+                        "name": "id",
+                        "in": "path"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -100,87 +1252,134 @@ const docTemplate = `{
                             "$ref": "#/definitions/responses.Quote"
                         }
                     },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/responses.Error"
+                            "$ref": "#/definitions/responses.Problem"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/responses.Error"
+                            "$ref": "#/definitions/responses.Problem"
                         }
                     },
                     "405": {
                         "description": "Method Not Allowed",
                         "schema": {
-                            "$ref": "#/definitions/responses.Error"
+                            "$ref": "#/definitions/responses.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/responses.Error"
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
                         }
                     }
                 }
             }
         },
-        "/{id}": {
+        "/{id}/image": {
             "get": {
                 "security": [
                     {
                         "KeyAuth": []
                     }
                 ],
-                "description": "Возвращает цитату по её уникальному идентификатору (ID). Если цитата не найдена в кэше, происходит обращение к базе данных. Полученная цитата затем сохраняется в кэш для ускорения последующих запросов. Если запрошенного ID нет в базе данных, возвращается ошибка.",
+                "description": "Возвращает цитату по её ID, отрисованную на карточке для публикации в соцсетях. Текст переносится по словам и уменьшается, чтобы поместиться на карточку. Готовая карточка сохраняется в кэш по ID цитаты, теме, размеру и формату.",
                 "produces": [
-                    "application/json"
+                    "image/png",
+                    "image/svg+xml"
                 ],
                 "tags": [
                     "Операции с цитатами"
                 ],
-                "summary": "Предоставляет цитату по заданному ID",
-                "operationId": "quote-id",
+                "summary": "Предоставляет карточку цитаты по заданному ID",
+                "operationId": "quote-id-image",
                 "parameters": [
                     {
                         "type": "string",
                         "example": "105",
                         "description": "Позволяет указать ID цитаты",
                         "name": "id",
-                        "in": "path"
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "default": "light",
+                        "description": "Тема карточки: light, dark или sepia",
+                        "name": "theme",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "og",
+                        "description": "Размер карточки: og (1200x630), square (1080x1080) или story (1080x1920)",
+                        "name": "size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "png",
+                        "description": "Формат карточки: png или svg",
+                        "name": "format",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/responses.Quote"
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
                         }
                     },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/responses.Error"
+                            "$ref": "#/definitions/responses.Problem"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/responses.Error"
+                            "$ref": "#/definitions/responses.Problem"
                         }
                     },
                     "405": {
                         "description": "Method Not Allowed",
                         "schema": {
-                            "$ref": "#/definitions/responses.Error"
+                            "$ref": "#/definitions/responses.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/responses.Error"
+                            "$ref": "#/definitions/responses.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/responses.Problem"
                         }
                     }
                 }
@@ -188,14 +1387,116 @@ const docTemplate = `{
         }
     },
     "definitions": {
-        "responses.Error": {
+        "responses.CacheEntry": {
             "type": "object",
             "properties": {
-                "code": {
+                "key": {
+                    "type": "string"
+                },
+                "ttl": {
+                    "type": "integer"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "responses.CacheResult": {
+            "type": "object",
+            "properties": {
+                "affected": {
+                    "type": "integer"
+                }
+            }
+        },
+        "responses.DependencyStatus": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "latency": {
+                    "type": "number"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "responses.Health": {
+            "type": "object",
+            "properties": {
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "responses.ImportReport": {
+            "type": "object",
+            "properties": {
+                "dryRun": {
+                    "type": "boolean"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/responses.ImportRowError"
+                    }
+                },
+                "format": {
+                    "type": "string"
+                },
+                "inserted": {
+                    "type": "integer"
+                },
+                "policy": {
+                    "type": "string"
+                },
+                "skipped": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "updated": {
+                    "type": "integer"
+                }
+            }
+        },
+        "responses.ImportRowError": {
+            "type": "object",
+            "properties": {
+                "id": {
                     "type": "integer"
                 },
                 "message": {
                     "type": "string"
+                },
+                "row": {
+                    "type": "integer"
+                }
+            }
+        },
+        "responses.Problem": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "detail": {
+                    "type": "string"
+                },
+                "instance": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
                 }
             }
         },
@@ -209,9 +1510,31 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "responses.Readiness": {
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/responses.DependencyStatus"
+                    }
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
         }
     },
     "securityDefinitions": {
+        "AdminKeyAuth": {
+            "type": "apiKey",
+            "name": "returnauf-admin-key",
+            "in": "query"
+        },
         "KeyAuth": {
             "type": "apiKey",
             "name": "returnauf-key",
@@ -227,7 +1550,7 @@ var SwaggerInfo = &swag.Spec{
 	BasePath:         "/",
 	Schemes:          []string{"http"},
 	Title:            "returnauf",
-	Description:      "REST API с коллекцией самых мемных ауф цитат",
+	Description:      "REST API с коллекцией самых мемных ауф цитат. Ошибки возвращаются в формате RFC 7807 (application/problem+json): type и code определяют вид ошибки и не меняются между версиями, detail описывает конкретный случай, instance содержит UUID запроса.",
 	InfoInstanceName: "swagger",
 	SwaggerTemplate:  docTemplate,
 }
@@ -1,48 +1,294 @@
 package app
 
 import (
+	"context"
+	"errors"
+	"sync"
 	"time"
 
+	"github.com/gofiber/contrib/websocket"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/favicon"
 	"github.com/gofiber/fiber/v2/middleware/keyauth"
 	"github.com/gofiber/fiber/v2/middleware/requestid"
 	"github.com/gofiber/swagger"
 	"github.com/google/uuid"
+	"google.golang.org/grpc"
 
 	"github.com/xoticdsign/returnauf/config"
+	"github.com/xoticdsign/returnauf/internal/bloom"
 	"github.com/xoticdsign/returnauf/internal/cache"
 	"github.com/xoticdsign/returnauf/internal/database"
+	"github.com/xoticdsign/returnauf/internal/gql"
 	"github.com/xoticdsign/returnauf/internal/handlers"
+	"github.com/xoticdsign/returnauf/internal/health"
 	"github.com/xoticdsign/returnauf/internal/logging"
+	"github.com/xoticdsign/returnauf/internal/metrics"
 	"github.com/xoticdsign/returnauf/internal/middleware"
+	"github.com/xoticdsign/returnauf/internal/rpc"
+	"github.com/xoticdsign/returnauf/internal/stream"
+	"github.com/xoticdsign/returnauf/internal/tracing"
 	"github.com/xoticdsign/returnauf/internal/utils"
 )
 
-// Инициализирует приложение
-func InitApp(conf config.Config) (*fiber.App, error) {
-	Cache, err := cache.RunRedis(conf.RedisAddr, conf.RedisPassword)
+// Время на отправку накопленных спанов при остановке
+const tracerFlushTimeout = time.Second * 5
+
+// Ошибка принудительной остановки: не все запросы завершились до истечения времени
+var ErrForcedShutdown = errors.New("shutdown deadline exceeded, connections closed forcibly")
+
+// Структура с серверами приложения и ресурсами, которые закрываются при остановке
+type App struct {
+	HTTP *fiber.App
+	GRPC *grpc.Server
+
+	deps    *handlers.Dependencies
+	health  *health.Health
+	streams *stream.Hub
+	tracer  *tracing.Provider
+	cache   *cache.Cache
+	db      *database.DB
+	log     *logging.Log
+
+	mu             sync.Mutex
+	migrations     sync.WaitGroup
+	stopMigrations context.CancelFunc
+}
+
+// Останавливает приложение. Сначала снимает готовность, завершает потоки цитат, которые
+// иначе держали бы соединения открытыми, и прерывает фоновые миграции, затем перестает
+// принимать соединения и ждет завершения запросов gRPC и HTTP не дольше timeout. После
+// этого отправляет накопленные спаны и закрывает Кэш, БД и Логгер. Если запросы не
+// успели завершиться, возвращает ErrForcedShutdown
+func (a *App) Shutdown(timeout time.Duration) error {
+	a.health.Shutdown()
+	a.streams.Close()
+
+	a.mu.Lock()
+	if a.stopMigrations != nil {
+		a.stopMigrations()
+	}
+	a.mu.Unlock()
+
+	var grpcStopped chan struct{}
+
+	if a.GRPC != nil {
+		grpcStopped = make(chan struct{})
+
+		go func() {
+			a.GRPC.GracefulStop()
+			close(grpcStopped)
+		}()
+	}
+
+	deadline := time.Now().Add(timeout)
+	forced := false
+
+	err := a.HTTP.ShutdownWithTimeout(timeout)
+	if errors.Is(err, context.DeadlineExceeded) {
+		forced, err = true, nil
+	}
+
+	if grpcStopped != nil {
+		select {
+		case <-grpcStopped:
+		case <-time.After(time.Until(deadline)):
+			select {
+			case <-grpcStopped:
+			default:
+				a.GRPC.Stop()
+				forced = true
+			}
+		}
+	}
+
+	a.migrations.Wait()
+
+	err = errors.Join(err, a.close())
+	if forced {
+		return errors.Join(ErrForcedShutdown, err)
+	}
+	return err
+}
+
+// Применяет перезагруженные настройки, которые меняются без перезапуска: уровень
+// журнала, время жизни записей Кэша и ограничения потоков и GraphQL. Ключи API и
+// настройки журнала запросов берутся из действующих настроек и отдельного применения не
+// требуют. Включение и отключение административного ключа ждет перезапуска, так как от
+// него зависит, подключены ли /admin и /metrics. Каждое изменение пишется в журнал, а при
+// ошибке перезагрузки прежние настройки остаются
+func (a *App) Reload(conf config.Config, changes []config.Change, err error) {
+	if err != nil {
+		a.log.Error("Настройки не перезагружены", logging.Err(err))
+		return
+	}
+
+	err = a.log.SetLevel(conf.Logging.Level)
+	if err != nil {
+		a.log.Error("Настройки не перезагружены", logging.Err(err))
+	}
+
+	a.deps.SetCacheTTL(conf.CacheTTL, conf.NegativeCacheTTL)
+	a.deps.Schema.SetConfig(conf.GraphQL)
+	a.streams.SetLimit(conf.Stream.MaxConnections)
+
+	if len(changes) == 0 {
+		a.log.Info("Настройки перезагружены без изменений")
+		return
+	}
+	for _, change := range changes {
+		logChange(a.log, change)
+	}
+}
+
+// Создает лог об изменении настройки при перезагрузке. Изменения, требующие перезапуска,
+// пишутся предупреждением
+func logChange(logger logging.Logger, change config.Change) {
+	fields := []logging.Field{
+		logging.String("Key", change.Key),
+		logging.String("Old", change.Old),
+		logging.String("New", change.New),
+	}
+
+	if change.Restart {
+		logger.Warn("Настройка изменится после перезапуска", fields...)
+		return
+	}
+	logger.Info("Настройка изменена", fields...)
+}
+
+// Отправляет накопленные спаны и закрывает Кэш, БД и Логгер. Логгер закрывается
+// последним, чтобы сбросить записи, сделанные во время остановки
+func (a *App) close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), tracerFlushTimeout)
+	defer cancel()
+
+	return errors.Join(
+		a.tracer.Shutdown(ctx),
+		a.cache.Close(),
+		a.db.Close(),
+		a.log.Close(),
+	)
+}
+
+// Возвращает заголовок с адресом клиента. Без доверенных прокси заголовок не
+// используется, чтобы клиент не мог подменить свой адрес
+func proxyHeader(conf config.Config) string {
+	if len(conf.TrustedProxies) == 0 {
+		return ""
+	}
+	return conf.ProxyHeader
+}
+
+// Стек функций, освобождающих уже открытые ресурсы, если инициализация не удалась
+type cleanup []func() error
+
+// Добавляет функцию освобождения ресурса
+func (c *cleanup) push(release func() error) {
+	*c = append(*c, release)
+}
+
+// Освобождает ресурсы в порядке, обратном открытию, и возвращает err вместе с ошибками
+// освобождения
+func (c cleanup) run(err error) error {
+	for i := len(c) - 1; i >= 0; i-- {
+		err = errors.Join(err, c[i]())
+	}
+	return err
+}
+
+// Инициализирует приложение. Сервер gRPC создается поверх тех же зависимостей, если
+// задан GRPC_ADDRESS, иначе остается nil. При ошибке уже открытые ресурсы закрываются
+func InitApp(conf config.Config) (_ *App, err error) {
+	var release cleanup
+
+	defer func() {
+		if err != nil {
+			err = release.run(err)
+		}
+	}()
+
+	Tracer, err := tracing.Init(conf.Tracing)
+	if err != nil {
+		return nil, err
+	}
+	release.push(func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), tracerFlushTimeout)
+		defer cancel()
+
+		return Tracer.Shutdown(ctx)
+	})
+
+	Cache, err := cache.RunRedis(conf.Redis)
 	if err != nil {
 		return nil, err
 	}
+	release.push(Cache.Close)
 
-	Log, err := logging.RunZap()
+	Log, err := logging.RunZap(conf.Logging)
 	if err != nil {
 		return nil, err
 	}
+	release.push(Log.Close)
+	logging.SetDefault(Log)
 
 	DB, err := database.RunGORM(conf.DBAddr)
 	if err != nil {
 		return nil, err
 	}
+	release.push(DB.Close)
+
+	Metrics := metrics.New()
 
 	dependencies := &handlers.Dependencies{
-		DB:      DB,
-		Cache:   Cache,
-		Logger:  Log,
-		Support: &utils.Support{},
+		DB:         metrics.Queuer(tracing.Queuer(DB, Tracer.Tracer()), Metrics),
+		Importer:   metrics.Importer(tracing.Importer(DB, Tracer.Tracer()), Metrics),
+		Exporter:   metrics.Exporter(tracing.Exporter(DB, Tracer.Tracer()), Metrics),
+		Feeder:     metrics.Feeder(tracing.Feeder(DB, Tracer.Tracer()), Metrics),
+		Batcher:    metrics.Batcher(tracing.Batcher(DB, Tracer.Tracer()), Metrics),
+		Cache:      metrics.Cache(tracing.Cache(Cache, Tracer.Tracer()), Metrics),
+		Popularity: Cache,
+		Logger:     Log,
+		Support:    &utils.Support{},
+		Streams:    stream.New(conf.Stream.MaxConnections),
+		Health:     health.New(conf.OperationTimeout).Add("redis", Cache).Add("sqlite", DB),
+		Heartbeat:  conf.Stream.Heartbeat,
+		Timeout:    conf.OperationTimeout,
+		WarmUpTop:  conf.CacheWarmUpTop,
+	}
+
+	dependencies.SetCacheTTL(conf.CacheTTL, conf.NegativeCacheTTL)
+
+	if conf.CacheWarmUp {
+		ctx, cancel := context.WithTimeout(context.Background(), conf.OperationTimeout)
+		defer cancel()
+
+		// Прогрев необязателен: без него Кэш заполнится по мере обращений
+		n, err := dependencies.WarmUp(ctx)
+		if err != nil {
+			Log.Warn("Кэш не прогрет", logging.Int("Quotes", n), logging.Err(err))
+		}
+	}
+
+	if conf.BloomFilter {
+		ctx, cancel := context.WithTimeout(context.Background(), conf.OperationTimeout)
+		defer cancel()
+
+		Filter := bloom.New(dependencies.DB, dependencies.Cache)
+
+		_, err = Filter.Load(ctx)
+		if err != nil {
+			return nil, err
+		}
+		dependencies.Filter = Filter
 	}
 
+	Schema, err := gql.New(conf.GraphQL)
+	if err != nil {
+		return nil, err
+	}
+	dependencies.Schema = Schema
+
 	app := fiber.New(fiber.Config{
 		StrictRouting: true,
 		CaseSensitive: true,
@@ -50,24 +296,131 @@ func InitApp(conf config.Config) (*fiber.App, error) {
 		WriteTimeout:  time.Second * 20,
 		ErrorHandler:  dependencies.Error,
 		AppName:       "returnauf",
+
+		// Адрес клиента берется из заголовка прокси, только если запрос пришел от
+		// доверенного прокси, иначе используется адрес соединения
+		EnableTrustedProxyCheck: len(conf.TrustedProxies) > 0,
+		TrustedProxies:          conf.TrustedProxies,
+		ProxyHeader:             proxyHeader(conf),
+		EnableIPValidation:      true,
 	})
 
+	app.Use(Metrics.Middleware())
 	app.Use(favicon.New(favicon.ConfigDefault))
 	app.Use(requestid.New(requestid.Config{
 		Generator:  uuid.NewString,
 		ContextKey: "uuid",
 	}))
+	app.Use(Tracer.Middleware())
+	app.Use(logging.Middleware(dependencies.Logger))
+	if conf.AccessLog.Enabled {
+		app.Use(middleware.AccessLog())
+	}
+
+	// /admin и /metrics проверяют административный ключ сами, но только если подключены
+	authFilter := middleware.AuthFiler
+	if conf.AdminApiKey != "" {
+		authFilter = middleware.AdminAuthFiler
+	}
 	app.Use(keyauth.New(keyauth.Config{
-		Next:         middleware.AuthFiler,
+		Next:         authFilter,
 		ErrorHandler: dependencies.Error,
-		KeyLookup:    "query:returnauf-key",
+		KeyLookup:    "query:" + middleware.KeyParam,
 		Validator:    middleware.KeyauthValidator,
 	}))
 
 	app.Get("/swagger/*", swagger.HandlerDefault)
+	app.Get("/healthz", dependencies.Healthz)
+	app.Get("/readyz", dependencies.Readyz)
+
+	if conf.AdminApiKey != "" {
+		adminAuth := keyauth.New(keyauth.Config{
+			ErrorHandler: dependencies.Error,
+			KeyLookup:    "query:" + middleware.AdminKeyParam,
+			Validator:    middleware.AdminKeyauthValidator,
+		})
+
+		app.Get("/metrics", adminAuth, Metrics.Handler())
+
+		admin := app.Group("/admin", adminAuth)
+
+		admin.Get("/cache/:key", dependencies.CacheInspect)
+		admin.Delete("/cache/:key", dependencies.CacheEvict)
+		admin.Delete("/cache", dependencies.CacheEvictPattern)
+		admin.Post("/cache/flush", dependencies.CacheFlush)
+		admin.Post("/cache/warmup", dependencies.CacheWarmUp)
+		admin.Post("/filter/rebuild", dependencies.FilterRebuild)
+		admin.Post("/import", dependencies.Import)
+	}
+
 	app.Get("/", dependencies.ListAll)
 	app.Get("/random", dependencies.RandomQuote)
+	app.Get("/random/image", dependencies.RandomQuoteImage)
+	app.Get("/export", dependencies.Export)
+	app.Get("/graphql", dependencies.GraphQL)
+	app.Post("/graphql", dependencies.GraphQL)
+	app.Get("/feed.:format", dependencies.Feed)
+	app.Get("/feed/daily.:format", dependencies.DailyFeed)
+	app.Get("/stream/random", dependencies.StreamRandom)
+	app.Get("/ws", dependencies.WebSocketUpgrade, websocket.New(dependencies.WebSocket))
 	app.Get("/:id", dependencies.QuoteID)
+	app.Get("/:id/image", dependencies.QuoteIDImage)
+
+	var server *grpc.Server
+
+	if conf.GRPCAddr != "" {
+		server = rpc.New(dependencies, Log)
+	}
+
+	application := &App{
+		HTTP:    app,
+		GRPC:    server,
+		deps:    dependencies,
+		health:  dependencies.Health,
+		streams: dependencies.Streams,
+		tracer:  Tracer,
+		cache:   Cache,
+		db:      DB,
+		log:     Log,
+	}
+
+	// Миграции запускаются, когда /readyz уже может сообщить о них
+	if conf.DBAutoMigrate {
+		ctx, cancel := context.WithCancel(context.Background())
+		application.stopMigrations = cancel
+
+		app.Hooks().OnListen(func(fiber.ListenData) error {
+			application.migrate(ctx)
+
+			return nil
+		})
+	}
+	return application, nil
+}
+
+// Применяет непримененные миграции в фоне. Пока они применяются, /readyz сообщает о
+// неготовности. Остановка приложения отменяет ctx и дожидается завершения миграций
+func (a *App) migrate(ctx context.Context) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// Остановка началась раньше запуска сервера
+	if ctx.Err() != nil {
+		return
+	}
+
+	done := a.db.MigrateUpAsync(ctx)
+
+	a.migrations.Add(1)
+
+	go func() {
+		defer a.migrations.Done()
 
-	return app, nil
+		err := <-done
+		if err != nil {
+			a.log.Error("Миграции не применены", logging.Err(err))
+			return
+		}
+		a.log.Info("Миграции применены")
+	}()
 }
@@ -0,0 +1,109 @@
+package app
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/xoticdsign/returnauf/config"
+	"github.com/xoticdsign/returnauf/internal/database"
+)
+
+// Unit тест для функции InitApp
+func TestUnitInitAppCleanup(t *testing.T) {
+	cases := []struct {
+		name   string
+		modify func(*config.Config)
+	}{
+		{
+			name:   "logger fails after cache case",
+			modify: func(c *config.Config) { c.Logging.Level = "verbose" },
+		},
+		{
+			name:   "db fails after logger case",
+			modify: func(c *config.Config) { c.DBAddr = filepath.Join(filepath.Dir(c.DBAddr), "missing", "db.sqlite") },
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			server := miniredis.RunT(t)
+			dir := t.TempDir()
+
+			conf := config.Config{
+				Redis: config.Redis{
+					Mode:  config.RedisModeSingle,
+					Addrs: []string{server.Addr()},
+				},
+				Logging: config.Logging{
+					Level:  config.LogLevelInfo,
+					Output: []string{filepath.Join(dir, "app.log")},
+				},
+				DBAddr:           filepath.Join(dir, "db.sqlite"),
+				DBAutoMigrate:    true,
+				OperationTimeout: time.Second,
+			}
+			cs.modify(&conf)
+
+			_, err := InitApp(conf)
+			assert.Error(t, err)
+
+			assert.Eventually(t, func() bool {
+				return server.CurrentConnectionCount() == 0
+			}, time.Second, time.Millisecond*10)
+		})
+	}
+}
+
+// Unit тест для фонового применения миграций после запуска сервера
+func TestUnitInitAppMigrate(t *testing.T) {
+	server := miniredis.RunT(t)
+	dir := t.TempDir()
+
+	conf := config.Config{
+		Redis: config.Redis{
+			Mode:  config.RedisModeSingle,
+			Addrs: []string{server.Addr()},
+		},
+		Logging: config.Logging{
+			Level:  config.LogLevelInfo,
+			Output: []string{filepath.Join(dir, "app.log")},
+		},
+		DBAddr:           filepath.Join(dir, "db.sqlite"),
+		DBAutoMigrate:    true,
+		OperationTimeout: time.Second,
+	}
+
+	existing, err := database.OpenGORM(conf.DBAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	existing.MigrateTo(context.Background(), 1)
+	existing.Close()
+
+	application, err := InitApp(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	version, _ := application.db.SchemaVersion(context.Background())
+	assert.Equal(t, 1, version)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go application.HTTP.Listener(listener)
+
+	assert.Eventually(t, func() bool {
+		version, _ := application.db.SchemaVersion(context.Background())
+		return version > 1
+	}, time.Second*5, time.Millisecond*10)
+
+	assert.NoError(t, application.Shutdown(time.Second))
+}
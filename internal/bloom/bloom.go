@@ -0,0 +1,195 @@
+package bloom
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/xoticdsign/returnauf/internal/database"
+)
+
+// Допустимая вероятность ложноположительного ответа
+const falsePositiveRate = 0.01
+
+// Минимальное количество элементов, на которое рассчитывается фильтр
+const minCapacity = 1024
+
+// Ключ Кэша с версией набора ID цитат. Версию меняет каждый, кто добавляет цитаты в
+// БД: API импорта, CLI и другие экземпляры
+const VersionKey = "bloom:version"
+
+// Интерфейс общего для всех экземпляров хранилища версии набора ID, например Кэша.
+// Отсутствующий ключ обозначается ошибкой redis.Nil
+type Versions interface {
+	Get(ctx context.Context, key string) (string, error)
+	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error
+}
+
+// Интерфейс, содержащий методы для работы с фильтром Блума
+type Filterer interface {
+	MayContain(ctx context.Context, id string) bool
+	Load(ctx context.Context) (int, error)
+}
+
+// Структура, реализующая Filterer. Фильтр хранится в памяти экземпляра, а изменения БД,
+// сделанные в обход него, он узнает по версии набора ID в Versions
+type Filter struct {
+	mu      sync.RWMutex
+	bits    []uint64
+	hashes  uint64
+	version string
+
+	db       database.Queuer
+	versions Versions
+	reload   sync.Mutex
+}
+
+// Создает пустой фильтр Блума, который загружает ID из db. Без versions отказ фильтра не
+// перепроверяется, и ID, добавленные в БД в обход фильтра, отклоняются до пересборки
+func New(db database.Queuer, versions Versions) *Filter {
+	f := &Filter{db: db, versions: versions}
+	f.Rebuild(nil)
+
+	return f
+}
+
+// Меняет версию набора ID, чтобы фильтры всех экземпляров пересобрались при следующем
+// отказе
+func Bump(ctx context.Context, versions Versions) error {
+	return versions.Set(ctx, VersionKey, uuid.NewString(), 0)
+}
+
+// Пересобирает фильтр из переданных ID
+func (f *Filter) Rebuild(ids []int) {
+	n := len(ids)
+	if n < minCapacity {
+		n = minCapacity
+	}
+
+	m := uint64(math.Ceil(-float64(n) * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)))
+	k := uint64(math.Max(1, math.Round(float64(m)/float64(n)*math.Ln2)))
+
+	bits := make([]uint64, (m+63)/64)
+	for _, id := range ids {
+		for _, pos := range positions(strconv.Itoa(id), k, uint64(len(bits))*64) {
+			bits[pos/64] |= 1 << (pos % 64)
+		}
+	}
+
+	f.mu.Lock()
+	f.bits = bits
+	f.hashes = k
+	f.mu.Unlock()
+}
+
+// Загружает ID всех цитат из БД, пересобирает фильтр и возвращает количество ID. Версия
+// читается до загрузки, поэтому изменение, сделанное во время загрузки, вызовет еще одну
+// пересборку
+func (f *Filter) Load(ctx context.Context) (int, error) {
+	version, _ := f.currentVersion(ctx)
+
+	quotes, err := f.db.ListAll(ctx, database.Filter{})
+	if err != nil {
+		return 0, err
+	}
+
+	ids := make([]int, len(quotes))
+	for i, quote := range quotes {
+		ids[i] = quote.ID
+	}
+
+	f.Rebuild(ids)
+
+	f.mu.Lock()
+	f.version = version
+	f.mu.Unlock()
+
+	return len(ids), nil
+}
+
+// Возвращает текущую версию набора ID. Отсутствующий ключ означает пустую версию, а
+// false - что версию узнать не удалось
+func (f *Filter) currentVersion(ctx context.Context) (string, bool) {
+	if f.versions == nil {
+		return "", false
+	}
+
+	version, err := f.versions.Get(ctx, VersionKey)
+	if errors.Is(err, redis.Nil) {
+		return "", true
+	}
+	if err != nil {
+		return "", false
+	}
+	return version, true
+}
+
+// Сообщает, может ли ID присутствовать в БД. false означает, что ID точно отсутствует.
+// Отказ перепроверяется по версии набора ID: если она изменилась после загрузки, фильтр
+// пересобирается, а если версию узнать не удалось, ID пропускается к БД
+func (f *Filter) MayContain(ctx context.Context, id string) bool {
+	if f.contains(id) {
+		return true
+	}
+	if f.versions == nil {
+		return false
+	}
+
+	version, ok := f.currentVersion(ctx)
+	if !ok {
+		return true
+	}
+
+	f.reload.Lock()
+	defer f.reload.Unlock()
+
+	f.mu.RLock()
+	stale := f.version != version
+	f.mu.RUnlock()
+
+	if !stale {
+		return f.contains(id)
+	}
+
+	_, err := f.Load(ctx)
+	if err != nil {
+		return true
+	}
+	return f.contains(id)
+}
+
+// Проверяет биты ID в фильтре
+func (f *Filter) contains(id string) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	for _, pos := range positions(id, f.hashes, uint64(len(f.bits))*64) {
+		if f.bits[pos/64]&(1<<(pos%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Вычисляет позиции битов методом двойного хэширования
+func positions(id string, k uint64, m uint64) []uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(id))
+	h1 := h.Sum64()
+
+	h.Write([]byte{0})
+	h2 := h.Sum64() | 1
+
+	pos := make([]uint64, k)
+	for i := uint64(0); i < k; i++ {
+		pos[i] = (h1 + i*h2) % m
+	}
+	return pos
+}
@@ -0,0 +1,219 @@
+package bloom
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/xoticdsign/returnauf/internal/database"
+	"github.com/xoticdsign/returnauf/models/responses"
+)
+
+// Имитация БД, реализующая методы Queuer
+type stubDB struct {
+	quotes []responses.Quote
+	err    error
+}
+
+// Имитация метода QuotesCount
+func (s *stubDB) QuotesCount(ctx context.Context) (int, error) {
+	return len(s.quotes), s.err
+}
+
+// Имитация метода QuoteIDAt
+func (s *stubDB) QuoteIDAt(ctx context.Context, offset int) (int, error) {
+	return offset + 1, s.err
+}
+
+// Имитация метода ListAll
+func (s *stubDB) ListAll(ctx context.Context, filter database.Filter) ([]responses.Quote, error) {
+	return s.quotes, s.err
+}
+
+// Имитация метода GetQuote
+func (s *stubDB) GetQuote(ctx context.Context, id string) (responses.Quote, error) {
+	return responses.Quote{}, s.err
+}
+
+// Unit тест для функции MayContain
+func TestUnitMayContain(t *testing.T) {
+	cases := []struct {
+		name string
+		ids  []int
+	}{
+		{
+			name: "general case",
+			ids:  []int{1, 2, 3},
+		},
+		{
+			name: "large set case",
+			ids: func() []int {
+				ids := make([]int, 5000)
+				for i := range ids {
+					ids[i] = i * 7
+				}
+				return ids
+			}(),
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			f := New(&stubDB{}, nil)
+			f.Rebuild(cs.ids)
+
+			for _, id := range cs.ids {
+				assert.True(t, f.MayContain(context.Background(), strconv.Itoa(id)))
+			}
+
+			var falsePositives int
+			for id := -1; id > -10000; id-- {
+				if f.MayContain(context.Background(), strconv.Itoa(id)) {
+					falsePositives++
+				}
+			}
+
+			assert.Less(t, falsePositives, 300)
+		})
+	}
+}
+
+// Unit тест для функции Load
+func TestUnitLoad(t *testing.T) {
+	cases := []struct {
+		name                 string
+		quotes               []responses.Quote
+		dbErr                error
+		wantLoadToReturnNum  int
+		wantLoadToReturnErr  error
+		wantMayContainToBeOK bool
+	}{
+		{
+			name:                 "general case",
+			quotes:               responses.TestQuotes,
+			dbErr:                nil,
+			wantLoadToReturnNum:  len(responses.TestQuotes),
+			wantLoadToReturnErr:  nil,
+			wantMayContainToBeOK: true,
+		},
+		{
+			name:                 "empty db case",
+			quotes:               []responses.Quote{},
+			dbErr:                nil,
+			wantLoadToReturnNum:  0,
+			wantLoadToReturnErr:  nil,
+			wantMayContainToBeOK: false,
+		},
+		{
+			name:                 "db error case",
+			dbErr:                errors.New("error"),
+			wantLoadToReturnNum:  0,
+			wantLoadToReturnErr:  errors.New("error"),
+			wantMayContainToBeOK: false,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			f := New(&stubDB{quotes: cs.quotes, err: cs.dbErr}, nil)
+
+			gotNum, gotErr := f.Load(context.Background())
+
+			assert.Equal(t, cs.wantLoadToReturnErr, gotErr)
+			assert.Equal(t, cs.wantLoadToReturnNum, gotNum)
+			assert.Equal(t, cs.wantMayContainToBeOK, f.MayContain(context.Background(), strconv.Itoa(responses.TestQuotes[0].ID)))
+		})
+	}
+}
+
+// Имитация общего хранилища версии, реализующая методы Versions
+type stubVersions struct {
+	values map[string]string
+	err    error
+}
+
+// Имитация метода Get
+func (s *stubVersions) Get(ctx context.Context, key string) (string, error) {
+	if s.err != nil {
+		return "", s.err
+	}
+
+	value, ok := s.values[key]
+	if !ok {
+		return "", redis.Nil
+	}
+	return value, nil
+}
+
+// Имитация метода Set
+func (s *stubVersions) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.values[key] = value.(string)
+
+	return nil
+}
+
+// Unit тест для функции MayContain при изменении БД в обход фильтра
+func TestUnitMayContainVersion(t *testing.T) {
+	cases := []struct {
+		name                 string
+		bump                 bool
+		versionsErr          error
+		wantMayContainToBeOK bool
+		wantReloaded         bool
+	}{
+		{
+			name:                 "same version case",
+			bump:                 false,
+			wantMayContainToBeOK: false,
+			wantReloaded:         false,
+		},
+		{
+			name:                 "changed version case",
+			bump:                 true,
+			wantMayContainToBeOK: true,
+			wantReloaded:         true,
+		},
+		{
+			name:                 "unknown version case",
+			versionsErr:          errors.New("error"),
+			wantMayContainToBeOK: true,
+			wantReloaded:         false,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			db := &stubDB{quotes: []responses.Quote{{ID: 1}}}
+			versions := &stubVersions{values: map[string]string{}}
+
+			f := New(db, versions)
+
+			_, err := f.Load(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Цитата добавлена другим экземпляром или CLI
+			db.quotes = append(db.quotes, responses.Quote{ID: 2})
+
+			if cs.bump {
+				assert.Nil(t, Bump(context.Background(), versions))
+			}
+			versions.err = cs.versionsErr
+
+			assert.Equal(t, cs.wantMayContainToBeOK, f.MayContain(context.Background(), "2"))
+
+			versions.err = nil
+
+			assert.Equal(t, cs.wantReloaded, f.contains("2"))
+		})
+	}
+}
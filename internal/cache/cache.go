@@ -2,59 +2,186 @@ package cache
 
 import (
 	"context"
-	"strconv"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"os"
+	"sync/atomic"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 
-	"github.com/xoticdsign/returnauf/models/responses"
+	"github.com/xoticdsign/returnauf/config"
 )
 
+// Пространство имен ключей returnauf в Redis
+const Namespace = "returnauf:"
+
+// Значение, которым в Кэше отмечаются отсутствующие в БД цитаты
+const Missing = "\x00missing"
+
+// Ключ рейтинга популярности цитат
+const PopularKey = "popular"
+
 // Интерфейс, содержащий методы для работы с Кэшом
 type Cacher interface {
-	Set(key string, value interface{}, expiration time.Duration) error
-	Get(key string) (string, error)
+	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error
+	Get(ctx context.Context, key string) (string, error)
+	TTL(ctx context.Context, key string) (time.Duration, error)
+	Delete(ctx context.Context, keys ...string) (int, error)
+	DeletePattern(ctx context.Context, pattern string) (int, error)
+	Flush(ctx context.Context) (int, error)
+}
+
+// Интерфейс рейтинга популярности цитат по числу обращений
+type Ranker interface {
+	Hit(ctx context.Context, member string) error
+	Top(ctx context.Context, n int) ([]string, error)
 }
 
-// Структура, реализующая Cacher
+// Структура, реализующая Cacher и Ranker
 type Cache struct {
-	cache *redis.Client
+	cache redis.UniversalClient
 }
 
-// Запускает Redis и возвращает структуру, реализующую Cacher
-func RunRedis(addr string, password string) (*Cache, error) {
-	config := redis.Options{
-		Addr:     addr,
-		Password: password,
-	}
+// Ошибка неизвестного режима подключения к Redis
+var ErrUnknownMode = errors.New("unknown redis mode")
+
+// Ошибка файла корневых сертификатов Redis без сертификатов PEM
+var ErrInvalidCA = errors.New("no PEM certificates in redis CA file")
 
-	client := redis.NewClient(&config)
+// Запускает Redis в заданном режиме и возвращает структуру, реализующую Cacher
+func RunRedis(conf config.Redis) (*Cache, error) {
+	client, err := newClient(conf)
+	if err != nil {
+		return nil, err
+	}
 
-	err := client.Ping(context.Background()).Err()
+	err = client.Ping(context.Background()).Err()
 	if err != nil {
+		client.Close()
+
 		return nil, redis.ErrClosed
 	}
 	return &Cache{cache: client}, nil
 }
 
-// Добавляет цитаты в тестовый Кэш
-func (c *Cache) PopulateCache() {
-	for _, quote := range responses.TestQuotes {
-		c.cache.Set(context.Background(), strconv.Itoa(quote.ID), quote.Quote, time.Duration(time.Minute*5))
+// Создает клиент Redis для одиночного узла, Sentinel или кластера
+func newClient(conf config.Redis) (redis.UniversalClient, error) {
+	tlsConfig, err := newTLSConfig(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	switch conf.Mode {
+	case config.RedisModeSingle, "":
+		var addr string
+		if len(conf.Addrs) > 0 {
+			addr = conf.Addrs[0]
+		}
+
+		return redis.NewClient(&redis.Options{
+			Addr:         addr,
+			Username:     conf.Username,
+			Password:     conf.Password,
+			DB:           conf.DB,
+			TLSConfig:    tlsConfig,
+			PoolSize:     conf.PoolSize,
+			DialTimeout:  conf.DialTimeout,
+			ReadTimeout:  conf.ReadTimeout,
+			WriteTimeout: conf.WriteTimeout,
+		}), nil
+
+	case config.RedisModeSentinel:
+		return redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:       conf.MasterName,
+			SentinelAddrs:    conf.Addrs,
+			SentinelPassword: conf.SentinelPassword,
+			Username:         conf.Username,
+			Password:         conf.Password,
+			DB:               conf.DB,
+			TLSConfig:        tlsConfig,
+			PoolSize:         conf.PoolSize,
+			DialTimeout:      conf.DialTimeout,
+			ReadTimeout:      conf.ReadTimeout,
+			WriteTimeout:     conf.WriteTimeout,
+		}), nil
+
+	case config.RedisModeCluster:
+		return redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:        conf.Addrs,
+			Username:     conf.Username,
+			Password:     conf.Password,
+			TLSConfig:    tlsConfig,
+			PoolSize:     conf.PoolSize,
+			DialTimeout:  conf.DialTimeout,
+			ReadTimeout:  conf.ReadTimeout,
+			WriteTimeout: conf.WriteTimeout,
+		}), nil
+	}
+	return nil, ErrUnknownMode
+}
+
+// Создает настройки TLS или возвращает nil, если TLS выключен. Имя сервера берется из
+// REDIS_TLS_SERVER_NAME, а без него - из адреса только в режиме одиночного узла. В
+// режимах Sentinel и кластера клиент подключается к разным узлам, поэтому имя остается
+// пустым и сертификат каждого узла проверяется по хосту его адреса
+func newTLSConfig(conf config.Redis) (*tls.Config, error) {
+	if !conf.TLS {
+		return nil, nil
 	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: conf.TLSServerName,
+	}
+
+	isSingle := conf.Mode == config.RedisModeSingle || conf.Mode == ""
+
+	if tlsConfig.ServerName == "" && isSingle && len(conf.Addrs) > 0 {
+		host, _, err := net.SplitHostPort(conf.Addrs[0])
+		if err == nil {
+			tlsConfig.ServerName = host
+		}
+	}
+
+	if conf.TLSCAFile != "" {
+		pem, err := os.ReadFile(conf.TLSCAFile)
+		if err != nil {
+			return nil, err
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, ErrInvalidCA
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return tlsConfig, nil
+}
+
+// Проверяет доступность Redis
+func (c *Cache) Ping(ctx context.Context) error {
+	return c.cache.Ping(ctx).Err()
 }
 
-// Уничтожает тестовый Кэш
-func (c *Cache) TeardownCache() {
-	client := c.cache
-	client.FlushAll(context.Background())
+// Закрывает соединение с Redis
+func (c *Cache) Close() error {
+	return c.cache.Close()
+}
 
-	defer client.Close()
+// Добавляет пространство имен к ключу
+func key(k string) string {
+	return Namespace + k
 }
 
 // Сохраняет данные в Кэш
-func (c *Cache) Set(key string, value interface{}, expiration time.Duration) error {
-	err := c.cache.Set(context.Background(), key, value, expiration).Err()
+func (c *Cache) Set(ctx context.Context, k string, value interface{}, expiration time.Duration) error {
+	err := c.cache.Set(ctx, key(k), value, expiration).Err()
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
 	if err != nil {
 		return redis.ErrClosed
 	}
@@ -62,13 +189,109 @@ func (c *Cache) Set(key string, value interface{}, expiration time.Duration) err
 }
 
 // Находит данные в Кэше
-func (c *Cache) Get(key string) (string, error) {
-	quote, err := c.cache.Get(context.Background(), key).Result()
+func (c *Cache) Get(ctx context.Context, k string) (string, error) {
+	quote, err := c.cache.Get(ctx, key(k)).Result()
 	if err == redis.ErrClosed {
 		return "", redis.ErrClosed
 	}
 	if err == redis.Nil {
 		return "", redis.Nil
 	}
+	if err != nil {
+		return "", err
+	}
 	return quote, nil
 }
+
+// Возвращает оставшееся время жизни ключа, -1 для ключа без срока действия
+func (c *Cache) TTL(ctx context.Context, k string) (time.Duration, error) {
+	ttl, err := c.cache.TTL(ctx, key(k)).Result()
+	if err != nil {
+		return 0, err
+	}
+	if ttl == -2 {
+		return 0, redis.Nil
+	}
+	return ttl, nil
+}
+
+// Удаляет ключи из Кэша и возвращает количество удаленных. Ключи удаляются
+// по одному в конвейере, чтобы в режиме кластера они могли лежать в разных слотах
+func (c *Cache) Delete(ctx context.Context, keys ...string) (int, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	pipe := c.cache.Pipeline()
+
+	cmds := make([]*redis.IntCmd, len(keys))
+	for i, k := range keys {
+		cmds[i] = pipe.Del(ctx, key(k))
+	}
+
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var deleted int
+	for _, cmd := range cmds {
+		deleted += int(cmd.Val())
+	}
+	return deleted, nil
+}
+
+// Удаляет ключи, соответствующие glob-шаблону, в пределах пространства имен.
+// В режиме кластера шаблон применяется к каждому мастер-узлу
+func (c *Cache) DeletePattern(ctx context.Context, pattern string) (int, error) {
+	cluster, ok := c.cache.(*redis.ClusterClient)
+	if !ok {
+		return deletePattern(ctx, c.cache, key(pattern))
+	}
+
+	var deleted int64
+
+	err := cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+		n, err := deletePattern(ctx, node, key(pattern))
+		atomic.AddInt64(&deleted, int64(n))
+
+		return err
+	})
+	return int(deleted), err
+}
+
+// Обходит ключи узла через SCAN и удаляет совпавшие с шаблоном
+func deletePattern(ctx context.Context, client redis.Cmdable, pattern string) (int, error) {
+	var deleted int
+
+	iter := client.Scan(ctx, 0, pattern, 100).Iterator()
+	for iter.Next(ctx) {
+		n, err := client.Del(ctx, iter.Val()).Result()
+		if err != nil {
+			return deleted, err
+		}
+		deleted += int(n)
+	}
+	if err := iter.Err(); err != nil {
+		return deleted, err
+	}
+	return deleted, nil
+}
+
+// Удаляет все ключи пространства имен returnauf
+func (c *Cache) Flush(ctx context.Context) (int, error) {
+	return c.DeletePattern(ctx, "*")
+}
+
+// Увеличивает на единицу число обращений к цитате в рейтинге популярности
+func (c *Cache) Hit(ctx context.Context, member string) error {
+	return c.cache.ZIncrBy(ctx, key(PopularKey), 1, member).Err()
+}
+
+// Возвращает до n самых популярных цитат по убыванию числа обращений
+func (c *Cache) Top(ctx context.Context, n int) ([]string, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	return c.cache.ZRevRange(ctx, key(PopularKey), 0, int64(n-1)).Result()
+}
@@ -1,62 +1,321 @@
 package cache
 
-/*
-
-ДЛЯ ИСПОЛЬЗОВАНИЯ ДАННЫХ ТЕСТОВ НЕОБХОДИМО ИМЕТЬ
-РАБОТАЮЩИЙ ЛОКАЛЬНЫЙ REDIS С АДРЕСОМ 127.0.0.1:6379
-И БЕЗ ПАРОЛЯ !!!
-
-*/
-
 import (
 	"context"
+	"encoding/pem"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
 	"testing"
 	"time"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
+
+	"github.com/xoticdsign/returnauf/config"
+	"github.com/xoticdsign/returnauf/models/responses"
 )
 
+// Адрес Redis, запущенного в процессе тестов
+var testRedisAddr string
+
+// Запускает встроенный Redis на время тестов пакета
+func TestMain(m *testing.M) {
+	server, err := miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+	testRedisAddr = server.Addr()
+
+	code := m.Run()
+
+	server.Close()
+	os.Exit(code)
+}
+
+// Настройки одиночного Redis для тестов
+func testRedisConfig() config.Redis {
+	return config.Redis{
+		Mode:  config.RedisModeSingle,
+		Addrs: []string{testRedisAddr},
+	}
+}
+
 // Настройка Redis для тестов
 func setupTestCache(emptyCache bool) *Cache {
-	Cache, _ := RunRedis("127.0.0.1:6379", "")
+	Cache, _ := RunRedis(testRedisConfig())
 
 	if !emptyCache {
-		Cache.PopulateCache()
+		for _, quote := range responses.TestQuotes {
+			Cache.Set(context.Background(), strconv.Itoa(quote.ID), quote.Quote, time.Minute*5)
+		}
 	}
 
 	return Cache
 }
 
+// Очистка Redis после тестов
+func teardownTestCache(c *Cache) {
+	c.Flush(context.Background())
+	c.Close()
+}
+
 // Unit тест для функции RunRedis
 func TestUnitRunRedis(t *testing.T) {
 	cases := []struct {
 		name                    string
-		addr                    string
-		password                string
+		conf                    config.Redis
 		wantRunRedisToReturnErr error
 	}{
 		{
 			name:                    "general case",
-			addr:                    "127.0.0.1:6379",
-			password:                "",
+			conf:                    testRedisConfig(),
+			wantRunRedisToReturnErr: nil,
+		},
+		{
+			name: "cluster case",
+			conf: config.Redis{
+				Mode:  config.RedisModeCluster,
+				Addrs: []string{testRedisAddr},
+			},
 			wantRunRedisToReturnErr: nil,
 		},
 		{
-			name:                    "wrong address case",
-			addr:                    "wrongaddr",
-			password:                "",
+			name: "wrong address case",
+			conf: config.Redis{
+				Mode:  config.RedisModeSingle,
+				Addrs: []string{"wrongaddr"},
+			},
+			wantRunRedisToReturnErr: redis.ErrClosed,
+		},
+		{
+			name: "no sentinel case",
+			conf: config.Redis{
+				Mode:        config.RedisModeSentinel,
+				Addrs:       []string{"wrongaddr"},
+				MasterName:  "master",
+				DialTimeout: time.Millisecond * 100,
+			},
 			wantRunRedisToReturnErr: redis.ErrClosed,
 		},
+		{
+			name: "unknown mode case",
+			conf: config.Redis{
+				Mode:  "unknown",
+				Addrs: []string{testRedisAddr},
+			},
+			wantRunRedisToReturnErr: ErrUnknownMode,
+		},
 	}
 
 	for _, cs := range cases {
 		t.Run(cs.name, func(t *testing.T) {
-			gotCache, gotErr := RunRedis(cs.addr, cs.password)
+			gotCache, gotErr := RunRedis(cs.conf)
 			if gotErr != nil {
 				assert.Equal(t, cs.wantRunRedisToReturnErr, gotErr)
 			} else {
-				defer gotCache.TeardownCache()
+				defer teardownTestCache(gotCache)
+
+				assert.Nil(t, cs.wantRunRedisToReturnErr)
+				assert.Nil(t, gotCache.Set(context.Background(), "key", "value", time.Minute))
+			}
+		})
+	}
+}
+
+// Unit тест для функции newClient
+func TestUnitNewClient(t *testing.T) {
+	cases := []struct {
+		name               string
+		conf               config.Redis
+		wantServerName     string
+		wantDB             int
+		wantPoolSize       int
+		wantUsername       string
+		wantTLSToBeEnabled bool
+	}{
+		{
+			name: "general case",
+			conf: config.Redis{
+				Mode:     config.RedisModeSingle,
+				Addrs:    []string{"redis.internal:6380"},
+				Username: "returnauf",
+				DB:       2,
+				PoolSize: 20,
+			},
+			wantServerName:     "",
+			wantDB:             2,
+			wantPoolSize:       20,
+			wantUsername:       "returnauf",
+			wantTLSToBeEnabled: false,
+		},
+		{
+			name: "tls case",
+			conf: config.Redis{
+				Mode:  config.RedisModeSingle,
+				Addrs: []string{"redis.internal:6380"},
+				TLS:   true,
+			},
+			wantServerName:     "redis.internal",
+			wantDB:             0,
+			wantPoolSize:       0,
+			wantUsername:       "",
+			wantTLSToBeEnabled: true,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			client, err := newClient(cs.conf)
+			if assert.Nil(t, err) {
+				defer client.Close()
+
+				options := client.(*redis.Client).Options()
+
+				assert.Equal(t, cs.wantDB, options.DB)
+				assert.Equal(t, cs.wantUsername, options.Username)
+				assert.Equal(t, cs.wantTLSToBeEnabled, options.TLSConfig != nil)
+
+				if cs.wantTLSToBeEnabled {
+					assert.Equal(t, cs.wantServerName, options.TLSConfig.ServerName)
+				}
+				if cs.wantPoolSize > 0 {
+					assert.Equal(t, cs.wantPoolSize, options.PoolSize)
+				}
+			}
+		})
+	}
+}
+
+// Unit тест для функции newTLSConfig
+func TestUnitNewTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	server := httptest.NewTLSServer(nil)
+	server.Close()
+
+	caFile := filepath.Join(dir, "ca.pem")
+	err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	badCAFile := filepath.Join(dir, "bad.pem")
+	err = os.WriteFile(badCAFile, []byte("not a certificate"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name                        string
+		conf                        config.Redis
+		wantTLSToBeEnabled          bool
+		wantServerName              string
+		wantRootCAsToBeSet          bool
+		wantNewTLSConfigToReturnErr error
+		wantNewTLSConfigToFail      bool
+	}{
+		{
+			name: "disabled case",
+			conf: config.Redis{
+				Mode:  config.RedisModeSingle,
+				Addrs: []string{"redis.internal:6380"},
+			},
+		},
+		{
+			name: "single case",
+			conf: config.Redis{
+				Mode:  config.RedisModeSingle,
+				Addrs: []string{"redis.internal:6380"},
+				TLS:   true,
+			},
+			wantTLSToBeEnabled: true,
+			wantServerName:     "redis.internal",
+		},
+		{
+			name: "explicit server name case",
+			conf: config.Redis{
+				Mode:          config.RedisModeSingle,
+				Addrs:         []string{"10.0.0.1:6380"},
+				TLS:           true,
+				TLSServerName: "redis.internal",
+			},
+			wantTLSToBeEnabled: true,
+			wantServerName:     "redis.internal",
+		},
+		{
+			name: "sentinel case",
+			conf: config.Redis{
+				Mode:  config.RedisModeSentinel,
+				Addrs: []string{"sentinel-1:26379", "sentinel-2:26379"},
+				TLS:   true,
+			},
+			wantTLSToBeEnabled: true,
+			wantServerName:     "",
+		},
+		{
+			name: "cluster case",
+			conf: config.Redis{
+				Mode:  config.RedisModeCluster,
+				Addrs: []string{"node-1:6379", "node-2:6379"},
+				TLS:   true,
+			},
+			wantTLSToBeEnabled: true,
+			wantServerName:     "",
+		},
+		{
+			name: "ca file case",
+			conf: config.Redis{
+				Mode:      config.RedisModeSingle,
+				Addrs:     []string{"redis.internal:6380"},
+				TLS:       true,
+				TLSCAFile: caFile,
+			},
+			wantTLSToBeEnabled: true,
+			wantServerName:     "redis.internal",
+			wantRootCAsToBeSet: true,
+		},
+		{
+			name: "bad ca file case",
+			conf: config.Redis{
+				Mode:      config.RedisModeSingle,
+				Addrs:     []string{"redis.internal:6380"},
+				TLS:       true,
+				TLSCAFile: badCAFile,
+			},
+			wantNewTLSConfigToReturnErr: ErrInvalidCA,
+			wantNewTLSConfigToFail:      true,
+		},
+		{
+			name: "missing ca file case",
+			conf: config.Redis{
+				Mode:      config.RedisModeSingle,
+				Addrs:     []string{"redis.internal:6380"},
+				TLS:       true,
+				TLSCAFile: filepath.Join(dir, "missing.pem"),
+			},
+			wantNewTLSConfigToReturnErr: os.ErrNotExist,
+			wantNewTLSConfigToFail:      true,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			got, gotErr := newTLSConfig(cs.conf)
+
+			if cs.wantNewTLSConfigToFail {
+				assert.ErrorIs(t, gotErr, cs.wantNewTLSConfigToReturnErr)
+				return
+			}
+
+			assert.Nil(t, gotErr)
+			assert.Equal(t, cs.wantTLSToBeEnabled, got != nil)
+
+			if cs.wantTLSToBeEnabled {
+				assert.Equal(t, cs.wantServerName, got.ServerName)
+				assert.Equal(t, cs.wantRootCAsToBeSet, got.RootCAs != nil)
 			}
 		})
 	}
@@ -88,17 +347,17 @@ func TestUnitSet(t *testing.T) {
 		t.Run(cs.name, func(t *testing.T) {
 			Cache := setupTestCache(false)
 			client := Cache.cache
-			defer Cache.TeardownCache()
+			defer teardownTestCache(Cache)
 
 			if cs.wantSetToReturnErr == redis.ErrClosed {
 				client.Close()
 			}
 
-			gotErr := Cache.Set(cs.key, cs.value, time.Duration(time.Minute*5))
+			gotErr := Cache.Set(context.Background(), cs.key, cs.value, time.Duration(time.Minute*5))
 			if gotErr != nil {
 				assert.Equal(t, cs.wantSetToReturnErr, gotErr)
 			} else {
-				gotValue := Cache.cache.Get(context.Background(), cs.key).Val()
+				gotValue := Cache.cache.Get(context.Background(), key(cs.key)).Val()
 
 				assert.Equal(t, cs.value, gotValue)
 			}
@@ -142,13 +401,13 @@ func TestUnitGet(t *testing.T) {
 		t.Run(cs.name, func(t *testing.T) {
 			Cache := setupTestCache(cs.emptyCache)
 			client := Cache.cache
-			defer Cache.TeardownCache()
+			defer teardownTestCache(Cache)
 
 			if cs.wantGetToReturnErr == redis.ErrClosed {
 				client.Close()
 			}
 
-			gotValue, gotErr := Cache.Get(cs.key)
+			gotValue, gotErr := Cache.Get(context.Background(), cs.key)
 			if gotErr != nil {
 				assert.Equal(t, cs.wantGetToReturnErr, gotErr)
 			} else {
@@ -157,3 +416,228 @@ func TestUnitGet(t *testing.T) {
 		})
 	}
 }
+
+// Unit тест для функции TTL
+func TestUnitTTL(t *testing.T) {
+	cases := []struct {
+		name               string
+		key                string
+		emptyCache         bool
+		wantTTLToReturnErr error
+	}{
+		{
+			name:               "general case",
+			key:                "1",
+			emptyCache:         false,
+			wantTTLToReturnErr: nil,
+		},
+		{
+			name:               "missing key case",
+			key:                "1",
+			emptyCache:         true,
+			wantTTLToReturnErr: redis.Nil,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			Cache := setupTestCache(cs.emptyCache)
+			defer teardownTestCache(Cache)
+
+			gotTTL, gotErr := Cache.TTL(context.Background(), cs.key)
+			if cs.wantTTLToReturnErr != nil {
+				assert.Equal(t, cs.wantTTLToReturnErr, gotErr)
+			} else {
+				assert.Nil(t, gotErr)
+				assert.Greater(t, gotTTL, time.Duration(0))
+			}
+		})
+	}
+}
+
+// Unit тест для функции Delete
+func TestUnitDelete(t *testing.T) {
+	cases := []struct {
+		name                  string
+		keys                  []string
+		emptyCache            bool
+		wantDeleteToReturnNum int
+	}{
+		{
+			name:                  "general case",
+			keys:                  []string{"1", "2"},
+			emptyCache:            false,
+			wantDeleteToReturnNum: 2,
+		},
+		{
+			name:                  "empty cache case",
+			keys:                  []string{"1"},
+			emptyCache:            true,
+			wantDeleteToReturnNum: 0,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			Cache := setupTestCache(cs.emptyCache)
+			defer teardownTestCache(Cache)
+
+			gotNum, gotErr := Cache.Delete(context.Background(), cs.keys...)
+
+			assert.Nil(t, gotErr)
+			assert.Equal(t, cs.wantDeleteToReturnNum, gotNum)
+		})
+	}
+}
+
+// Unit тест для функции DeletePattern
+func TestUnitDeletePattern(t *testing.T) {
+	cases := []struct {
+		name                         string
+		pattern                      string
+		wantDeletePatternToReturnNum int
+	}{
+		{
+			name:                         "general case",
+			pattern:                      "*",
+			wantDeletePatternToReturnNum: len(responses.TestQuotes),
+		},
+		{
+			name:                         "single key pattern case",
+			pattern:                      "1*",
+			wantDeletePatternToReturnNum: 1,
+		},
+		{
+			name:                         "no match case",
+			pattern:                      "nothing*",
+			wantDeletePatternToReturnNum: 0,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			Cache := setupTestCache(false)
+			defer teardownTestCache(Cache)
+
+			gotNum, gotErr := Cache.DeletePattern(context.Background(), cs.pattern)
+
+			assert.Nil(t, gotErr)
+			assert.Equal(t, cs.wantDeletePatternToReturnNum, gotNum)
+		})
+	}
+}
+
+// Unit тест для функции Flush
+func TestUnitFlush(t *testing.T) {
+	cases := []struct {
+		name                 string
+		foreignKey           string
+		wantFlushToReturnNum int
+	}{
+		{
+			name:                 "general case",
+			foreignKey:           "foreign",
+			wantFlushToReturnNum: len(responses.TestQuotes),
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			Cache := setupTestCache(false)
+			defer teardownTestCache(Cache)
+
+			Cache.cache.Set(context.Background(), cs.foreignKey, "value", time.Minute)
+			defer Cache.cache.Del(context.Background(), cs.foreignKey)
+
+			gotNum, gotErr := Cache.Flush(context.Background())
+
+			assert.Nil(t, gotErr)
+			assert.Equal(t, cs.wantFlushToReturnNum, gotNum)
+			assert.Equal(t, "value", Cache.cache.Get(context.Background(), cs.foreignKey).Val())
+		})
+	}
+}
+
+// Unit тест для функции Ping
+func TestUnitPing(t *testing.T) {
+	cases := []struct {
+		name           string
+		closeCache     bool
+		wantPingToFail bool
+	}{
+		{
+			name:           "general case",
+			wantPingToFail: false,
+		},
+		{
+			name:           "closed client case",
+			closeCache:     true,
+			wantPingToFail: true,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			Cache := setupTestCache(true)
+			defer Cache.Close()
+
+			if cs.closeCache {
+				Cache.Close()
+			}
+
+			gotErr := Cache.Ping(context.Background())
+
+			assert.Equal(t, cs.wantPingToFail, gotErr != nil)
+		})
+	}
+}
+
+// Unit тест для функций Hit и Top
+func TestUnitTop(t *testing.T) {
+	cases := []struct {
+		name               string
+		hits               []string
+		n                  int
+		wantTopToReturnIDs []string
+	}{
+		{
+			name:               "general case",
+			hits:               []string{"1", "2", "2", "3", "3", "3"},
+			n:                  2,
+			wantTopToReturnIDs: []string{"3", "2"},
+		},
+		{
+			name:               "fewer ranked than n case",
+			hits:               []string{"1"},
+			n:                  5,
+			wantTopToReturnIDs: []string{"1"},
+		},
+		{
+			name:               "empty ranking case",
+			n:                  5,
+			wantTopToReturnIDs: []string{},
+		},
+		{
+			name:               "zero n case",
+			hits:               []string{"1"},
+			n:                  0,
+			wantTopToReturnIDs: nil,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			Cache := setupTestCache(true)
+			defer teardownTestCache(Cache)
+
+			for _, id := range cs.hits {
+				assert.NoError(t, Cache.Hit(context.Background(), id))
+			}
+
+			gotIDs, gotErr := Cache.Top(context.Background(), cs.n)
+
+			assert.Nil(t, gotErr)
+			assert.Equal(t, cs.wantTopToReturnIDs, gotIDs)
+		})
+	}
+}
@@ -0,0 +1,410 @@
+package card
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+
+	"github.com/xoticdsign/returnauf/models/responses"
+)
+
+// Поддерживаемые форматы карточек
+const (
+	FormatPNG = "png"
+	FormatSVG = "svg"
+)
+
+// Значения по умолчанию
+const (
+	DefaultTheme  = "light"
+	DefaultSize   = "og"
+	DefaultFormat = FormatPNG
+)
+
+// Префикс ключей Кэша для карточек и шаблон, под который попадают все карточки
+const (
+	keyPrefix  = "image:"
+	KeyPattern = keyPrefix + "*"
+)
+
+// Минимальный кегль, до которого уменьшается текст длинной цитаты
+const minFontSize = 14
+
+// Ошибки разбора параметров карточки
+var (
+	ErrUnknownTheme  = errors.New("unknown card theme")
+	ErrUnknownSize   = errors.New("unknown card size")
+	ErrUnknownFormat = errors.New("unknown card format")
+)
+
+// Цветовая тема карточки
+type Theme struct {
+	Background color.RGBA
+	Foreground color.RGBA
+	Accent     color.RGBA
+}
+
+// Доступные темы карточек
+var Themes = map[string]Theme{
+	"light": {
+		Background: color.RGBA{0xfa, 0xfa, 0xf7, 0xff},
+		Foreground: color.RGBA{0x1f, 0x23, 0x28, 0xff},
+		Accent:     color.RGBA{0xd9, 0x48, 0x3b, 0xff},
+	},
+	"dark": {
+		Background: color.RGBA{0x16, 0x18, 0x1d, 0xff},
+		Foreground: color.RGBA{0xec, 0xed, 0xee, 0xff},
+		Accent:     color.RGBA{0xf2, 0xb1, 0x34, 0xff},
+	},
+	"sepia": {
+		Background: color.RGBA{0xf4, 0xec, 0xd8, 0xff},
+		Foreground: color.RGBA{0x43, 0x34, 0x22, 0xff},
+		Accent:     color.RGBA{0x8c, 0x5a, 0x2b, 0xff},
+	},
+}
+
+// Размер карточки в пикселях
+type Size struct {
+	Width  int
+	Height int
+}
+
+// Доступные размеры карточек: превью для соцсетей, квадрат и сторис
+var Sizes = map[string]Size{
+	"og":     {Width: 1200, Height: 630},
+	"square": {Width: 1080, Height: 1080},
+	"story":  {Width: 1080, Height: 1920},
+}
+
+// Content-Type для каждого формата карточки
+var ContentTypes = map[string]string{
+	FormatPNG: "image/png",
+	FormatSVG: "image/svg+xml",
+}
+
+// Параметры отрисовки карточки
+type Options struct {
+	Theme  string
+	Size   string
+	Format string
+}
+
+// Шрифты Go, встроенные в бинарник. Они покрывают кириллицу
+type fonts struct {
+	regular *opentype.Font
+	bold    *opentype.Font
+}
+
+// Разбирает встроенные шрифты один раз
+var loadFonts = sync.OnceValues(func() (fonts, error) {
+	regular, err := opentype.Parse(goregular.TTF)
+	if err != nil {
+		return fonts{}, err
+	}
+
+	bold, err := opentype.Parse(gobold.TTF)
+	if err != nil {
+		return fonts{}, err
+	}
+	return fonts{regular: regular, bold: bold}, nil
+})
+
+// Проверяет параметры карточки, подставляя значения по умолчанию для пустых
+func ParseOptions(theme string, size string, format string) (Options, error) {
+	opts := Options{
+		Theme:  strings.ToLower(theme),
+		Size:   strings.ToLower(size),
+		Format: strings.ToLower(format),
+	}
+
+	if opts.Theme == "" {
+		opts.Theme = DefaultTheme
+	}
+	if opts.Size == "" {
+		opts.Size = DefaultSize
+	}
+	if opts.Format == "" {
+		opts.Format = DefaultFormat
+	}
+
+	if _, ok := Themes[opts.Theme]; !ok {
+		return Options{}, ErrUnknownTheme
+	}
+	if _, ok := Sizes[opts.Size]; !ok {
+		return Options{}, ErrUnknownSize
+	}
+	if _, ok := ContentTypes[opts.Format]; !ok {
+		return Options{}, ErrUnknownFormat
+	}
+	return opts, nil
+}
+
+// Возвращает ключ Кэша для карточки цитаты с заданными параметрами
+func (o Options) Key(id int) string {
+	return keyPrefix + strconv.Itoa(id) + ":" + o.Theme + ":" + o.Size + ":" + o.Format
+}
+
+// Рассчитанное размещение текста на карточке
+type layout struct {
+	size        Size
+	theme       Theme
+	padding     int
+	fontSize    float64
+	lineHeight  int
+	lines       []string
+	widths      []int
+	textTop     int
+	footer      string
+	footerSize  float64
+	footerLine  int
+	footerWidth int
+}
+
+// Отрисовывает цитату на карточке в формате из параметров
+func Render(quote responses.Quote, opts Options) ([]byte, error) {
+	opts, err := ParseOptions(opts.Theme, opts.Size, opts.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := loadFonts()
+	if err != nil {
+		return nil, err
+	}
+
+	l, err := arrange(f, quote, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Format == FormatSVG {
+		return renderSVG(l), nil
+	}
+	return renderPNG(f, l)
+}
+
+// Подбирает кегль и переносит текст цитаты по словам так, чтобы он поместился на карточку
+func arrange(f fonts, quote responses.Quote, opts Options) (layout, error) {
+	size := Sizes[opts.Size]
+	short := min(size.Width, size.Height)
+
+	l := layout{
+		size:       size,
+		theme:      Themes[opts.Theme],
+		padding:    short / 12,
+		footer:     "— returnauf #" + strconv.Itoa(quote.ID),
+		footerSize: float64(short) / 28,
+	}
+	l.footerLine = size.Height - l.padding
+
+	width := size.Width - 2*l.padding
+	height := l.footerLine - l.padding - int(l.footerSize*3)
+
+	text := "«" + strings.TrimSpace(quote.Quote) + "»"
+
+	for fontSize := float64(short) / 11; ; fontSize *= 0.9 {
+		if fontSize < minFontSize {
+			fontSize = minFontSize
+		}
+
+		face, err := newFace(f.regular, fontSize)
+		if err != nil {
+			return layout{}, err
+		}
+		lines := wrap(face, text, width)
+
+		lineHeight := int(fontSize * 1.35)
+		if len(lines)*lineHeight <= height || fontSize == minFontSize {
+			l.fontSize = fontSize
+			l.lineHeight = lineHeight
+			l.lines = lines
+			l.widths = measure(face, lines...)
+			l.textTop = l.padding + (height-len(lines)*lineHeight)/2
+
+			face.Close()
+			break
+		}
+		face.Close()
+	}
+
+	footerFace, err := newFace(f.bold, l.footerSize)
+	if err != nil {
+		return layout{}, err
+	}
+	defer footerFace.Close()
+
+	l.footerWidth = measure(footerFace, l.footer)[0]
+
+	return l, nil
+}
+
+// Возвращает ширину каждой строки в пикселях
+func measure(face font.Face, lines ...string) []int {
+	widths := make([]int, len(lines))
+	for i, line := range lines {
+		widths[i] = font.MeasureString(face, line).Ceil()
+	}
+	return widths
+}
+
+// Создает начертание шрифта заданного кегля. DPI 72 делает пункт равным пикселю
+func newFace(f *opentype.Font, size float64) (font.Face, error) {
+	return opentype.NewFace(f, &opentype.FaceOptions{
+		Size:    size,
+		DPI:     72,
+		Hinting: font.HintingNone,
+	})
+}
+
+// Переносит текст по словам, не превышая ширину. Слово длиннее строки разбивается по символам
+func wrap(face font.Face, text string, width int) []string {
+	limit := fixed.I(width)
+
+	var lines []string
+	var line string
+
+	for _, word := range strings.Fields(text) {
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+		if font.MeasureString(face, candidate) <= limit {
+			line = candidate
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+
+		for font.MeasureString(face, word) > limit && utf8.RuneCountInString(word) > 1 {
+			cut := fitRunes(face, word, limit)
+			lines = append(lines, word[:cut])
+			word = word[cut:]
+		}
+		line = word
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// Возвращает длину в байтах самого длинного префикса слова, помещающегося в ширину
+func fitRunes(face font.Face, word string, limit fixed.Int26_6) int {
+	cut := 0
+	for i, r := range word {
+		next := i + utf8.RuneLen(r)
+		if cut > 0 && font.MeasureString(face, word[:next]) > limit {
+			break
+		}
+		cut = next
+	}
+	return cut
+}
+
+// Отрисовывает карточку в PNG
+func renderPNG(f fonts, l layout) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, l.size.Width, l.size.Height))
+
+	draw.Draw(img, img.Bounds(), image.NewUniform(l.theme.Background), image.Point{}, draw.Src)
+	draw.Draw(img, accentRect(l), image.NewUniform(l.theme.Accent), image.Point{}, draw.Src)
+
+	face, err := newFace(f.regular, l.fontSize)
+	if err != nil {
+		return nil, err
+	}
+	defer face.Close()
+
+	drawer := &font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(l.theme.Foreground),
+		Face: face,
+	}
+
+	for i, line := range l.lines {
+		drawer.Dot = fixed.P(l.padding, l.textTop+i*l.lineHeight+int(l.fontSize))
+		drawer.DrawString(line)
+	}
+
+	footerFace, err := newFace(f.bold, l.footerSize)
+	if err != nil {
+		return nil, err
+	}
+	defer footerFace.Close()
+
+	drawer.Face = footerFace
+	drawer.Src = image.NewUniform(l.theme.Accent)
+	drawer.Dot = fixed.P(l.padding, l.footerLine)
+	drawer.DrawString(l.footer)
+
+	var buf bytes.Buffer
+
+	err = png.Encode(&buf, img)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Отрисовывает карточку в SVG. Шрифты не встраиваются, чтобы карточка весила несколько
+// килобайт: текст ссылается на шрифт Go с запасным sans-serif, а ширина каждой строки
+// задается textLength, поэтому переносы совпадают с PNG при любом шрифте у получателя
+func renderSVG(l layout) []byte {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`, l.size.Width, l.size.Height, l.size.Width, l.size.Height)
+	b.WriteString(`<style>text{font-family:"Go","Go Regular",sans-serif}</style>`)
+
+	fmt.Fprintf(&b, `<rect width="100%%" height="100%%" fill="%s"/>`, hex(l.theme.Background))
+
+	r := accentRect(l)
+	fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`, r.Min.X, r.Min.Y, r.Dx(), r.Dy(), hex(l.theme.Accent))
+
+	fmt.Fprintf(&b, `<text font-size="%.2f" fill="%s" xml:space="preserve">`, l.fontSize, hex(l.theme.Foreground))
+	for i, line := range l.lines {
+		fmt.Fprintf(&b, `<tspan x="%d" y="%d" textLength="%d" lengthAdjust="spacingAndGlyphs">%s</tspan>`, l.padding, l.textTop+i*l.lineHeight+int(l.fontSize), l.widths[i], escape(line))
+	}
+	b.WriteString(`</text>`)
+
+	fmt.Fprintf(&b, `<text x="%d" y="%d" font-size="%.2f" font-weight="700" fill="%s" textLength="%d" lengthAdjust="spacingAndGlyphs">%s</text>`, l.padding, l.footerLine, l.footerSize, hex(l.theme.Accent), l.footerWidth, escape(l.footer))
+	b.WriteString(`</svg>`)
+
+	return []byte(b.String())
+}
+
+// Возвращает прямоугольник акцентной линии над подписью
+func accentRect(l layout) image.Rectangle {
+	y := l.footerLine - int(l.footerSize*2)
+	thickness := max(l.size.Height/200, 2)
+
+	return image.Rect(l.padding, y, l.padding+l.size.Width/8, y+thickness)
+}
+
+// Форматирует цвет для SVG
+func hex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// Экранирует текст для вставки в SVG
+func escape(s string) string {
+	var buf bytes.Buffer
+
+	xml.EscapeText(&buf, []byte(s))
+
+	return buf.String()
+}
@@ -0,0 +1,171 @@
+package card
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/xoticdsign/returnauf/models/responses"
+)
+
+// Unit тест для функции ParseOptions
+func TestUnitParseOptions(t *testing.T) {
+	cases := []struct {
+		name     string
+		theme    string
+		size     string
+		format   string
+		wantOpts Options
+		wantErr  error
+	}{
+		{
+			name:     "defaults case",
+			wantOpts: Options{Theme: DefaultTheme, Size: DefaultSize, Format: DefaultFormat},
+			wantErr:  nil,
+		},
+		{
+			name:     "explicit case",
+			theme:    "Dark",
+			size:     "story",
+			format:   "SVG",
+			wantOpts: Options{Theme: "dark", Size: "story", Format: FormatSVG},
+			wantErr:  nil,
+		},
+		{
+			name:    "unknown theme case",
+			theme:   "neon",
+			wantErr: ErrUnknownTheme,
+		},
+		{
+			name:    "unknown size case",
+			size:    "huge",
+			wantErr: ErrUnknownSize,
+		},
+		{
+			name:    "unknown format case",
+			format:  "gif",
+			wantErr: ErrUnknownFormat,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			gotOpts, gotErr := ParseOptions(cs.theme, cs.size, cs.format)
+
+			assert.Equal(t, cs.wantErr, gotErr)
+			assert.Equal(t, cs.wantOpts, gotOpts)
+		})
+	}
+}
+
+// Unit тест для функции Key
+func TestUnitKey(t *testing.T) {
+	opts := Options{Theme: "dark", Size: "square", Format: FormatPNG}
+
+	assert.Equal(t, "image:7:dark:square:png", opts.Key(7))
+}
+
+// Unit тест для функции Render
+func TestUnitRender(t *testing.T) {
+	quote := responses.Quote{ID: 7, Quote: "Съешь же ещё этих мягких французских булок, да выпей <чаю> & кофе"}
+
+	cases := []struct {
+		name    string
+		opts    Options
+		check   func(t *testing.T, body []byte)
+		wantErr error
+	}{
+		{
+			name: "png case",
+			opts: Options{Theme: "sepia", Size: "square", Format: FormatPNG},
+			check: func(t *testing.T, body []byte) {
+				img, err := png.Decode(bytes.NewReader(body))
+				if assert.Nil(t, err) {
+					assert.Equal(t, Sizes["square"].Width, img.Bounds().Dx())
+					assert.Equal(t, Sizes["square"].Height, img.Bounds().Dy())
+				}
+			},
+			wantErr: nil,
+		},
+		{
+			name: "svg case",
+			opts: Options{Format: FormatSVG},
+			check: func(t *testing.T, body []byte) {
+				svg := string(body)
+
+				assert.True(t, strings.HasPrefix(svg, `<svg xmlns="http://www.w3.org/2000/svg" width="1200" height="630"`))
+				assert.Contains(t, svg, "&lt;чаю&gt;")
+				assert.NotContains(t, svg, "<чаю>")
+				assert.Contains(t, svg, "returnauf #7")
+				assert.Contains(t, svg, `textLength="`)
+				assert.NotContains(t, svg, "base64")
+				assert.Less(t, len(body), 10*1024)
+			},
+			wantErr: nil,
+		},
+		{
+			name:    "unknown theme case",
+			opts:    Options{Theme: "neon"},
+			check:   func(t *testing.T, body []byte) { assert.Nil(t, body) },
+			wantErr: ErrUnknownTheme,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			gotBody, gotErr := Render(quote, cs.opts)
+
+			assert.Equal(t, cs.wantErr, gotErr)
+			cs.check(t, gotBody)
+		})
+	}
+}
+
+// Unit тест для функции wrap
+func TestUnitWrap(t *testing.T) {
+	f, err := loadFonts()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	face, err := newFace(f.regular, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer face.Close()
+
+	cases := []struct {
+		name      string
+		text      string
+		width     int
+		wantLines []string
+	}{
+		{
+			name:      "fits case",
+			text:      "короткая строка",
+			width:     1000,
+			wantLines: []string{"короткая строка"},
+		},
+		{
+			name:      "word wrap case",
+			text:      "раз два три",
+			width:     60,
+			wantLines: []string{"раз", "два", "три"},
+		},
+		{
+			name:      "long word case",
+			text:      strings.Repeat("ж", 12),
+			width:     60,
+			wantLines: []string{"жжжж", "жжжж", "жжжж"},
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			assert.Equal(t, cs.wantLines, wrap(face, cs.text, cs.width))
+		})
+	}
+}
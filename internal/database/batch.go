@@ -0,0 +1,28 @@
+package database
+
+import (
+	"context"
+
+	"github.com/xoticdsign/returnauf/models/responses"
+)
+
+// Интерфейс, содержащий методы для пакетного чтения цитат
+type BatchQueuer interface {
+	GetQuotes(ctx context.Context, ids []int) ([]responses.Quote, error)
+}
+
+// Возвращает цитаты с указанными ID одним запросом, упорядоченные по ID. Отсутствующие
+// ID пропускаются без ошибки
+func (d *DB) GetQuotes(ctx context.Context, ids []int) ([]responses.Quote, error) {
+	quotes := []responses.Quote{}
+
+	if len(ids) == 0 {
+		return quotes, nil
+	}
+
+	err := d.db.WithContext(ctx).Table("quotes").Where("id IN ?", ids).Order("id").Find(&quotes).Error
+	if err != nil {
+		return nil, classify(ctx, err)
+	}
+	return quotes, nil
+}
@@ -0,0 +1,51 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/xoticdsign/returnauf/models/responses"
+)
+
+// Unit тест для функции GetQuotes
+func TestUnitGetQuotes(t *testing.T) {
+	cases := []struct {
+		name                     string
+		ids                      []int
+		wantQuotes               []responses.Quote
+		wantGetQuotesToReturnErr error
+	}{
+		{
+			name:                     "general case",
+			ids:                      []int{3, 1},
+			wantQuotes:               []responses.Quote{responses.TestQuotes[0], responses.TestQuotes[2]},
+			wantGetQuotesToReturnErr: nil,
+		},
+		{
+			name:                     "missing ids case",
+			ids:                      []int{2, 999},
+			wantQuotes:               []responses.Quote{responses.TestQuotes[1]},
+			wantGetQuotesToReturnErr: nil,
+		},
+		{
+			name:                     "no ids case",
+			ids:                      nil,
+			wantQuotes:               []responses.Quote{},
+			wantGetQuotesToReturnErr: nil,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			DB := setupTestDB(false)
+			defer DB.TeardownDB()
+
+			gotQuotes, gotErr := DB.GetQuotes(context.Background(), cs.ids)
+
+			assert.Equal(t, cs.wantGetQuotesToReturnErr, gotErr)
+			assert.Equal(t, cs.wantQuotes, gotQuotes)
+		})
+	}
+}
@@ -1,7 +1,11 @@
 package database
 
 import (
+	"context"
+	"errors"
 	"os"
+	"strconv"
+	"sync/atomic"
 
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
@@ -12,18 +16,43 @@ import (
 
 // Интерфейс, содержащий методы для работы с БД
 type Queuer interface {
-	QuotesCount() (int, error)
-	ListAll() ([]responses.Quote, error)
-	GetQuote(id string) (responses.Quote, error)
+	QuotesCount(ctx context.Context) (int, error)
+	QuoteIDAt(ctx context.Context, offset int) (int, error)
+	ListAll(ctx context.Context, filter Filter) ([]responses.Quote, error)
+	GetQuote(ctx context.Context, id string) (responses.Quote, error)
 }
 
+// Ошибка проверки доступности БД во время применения миграций
+var ErrMigrating = errors.New("migrations in progress")
+
 // Структура, реализующая Queuer
 type DB struct {
 	db *gorm.DB
+
+	migrating atomic.Int32
 }
 
-// Запускает SQLite и возвращает структуру, реализующую Queuer
+// Запускает SQLite и возвращает структуру, реализующую Queuer. БД без схемы при первом
+// запуске создается и заполняется встроенным набором цитат. Миграции существующей схемы
+// применяет MigrateUp или MigrateUpAsync
 func RunGORM(dbAddr string) (*DB, error) {
+	d, err := OpenGORM(dbAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	err = d.prepare(context.Background())
+	if err != nil {
+		d.Close()
+
+		return nil, err
+	}
+	return d, nil
+}
+
+// Открывает SQLite, не меняя схему. Используется командами, которые управляют схемой
+// сами, и тестами
+func OpenGORM(dbAddr string) (*DB, error) {
 	gormDB, err := gorm.Open(sqlite.Open(dbAddr), &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Silent),
 	})
@@ -33,49 +62,111 @@ func RunGORM(dbAddr string) (*DB, error) {
 	return &DB{db: gormDB}, nil
 }
 
+// Создает схему и заполняет БД встроенным набором цитат, если миграции еще не
+// применялись. Существующая схема не меняется
+func (d *DB) prepare(ctx context.Context) error {
+	version, err := d.SchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if version > 0 {
+		return nil
+	}
+
+	err = d.MigrateUp(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = d.SeedIfEmpty(ctx)
+
+	return err
+}
+
+// Проверяет доступность БД простым запросом. Пока применяются миграции, возвращает
+// ErrMigrating
+func (d *DB) Ping(ctx context.Context) error {
+	if d.migrating.Load() > 0 {
+		return ErrMigrating
+	}
+
+	var one int
+
+	return d.db.WithContext(ctx).Raw("SELECT 1").Scan(&one).Error
+}
+
+// Закрывает соединение с БД
+func (d *DB) Close() error {
+	sqlDB, err := d.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
 // Мигрирует цитаты в БД
 func (d *DB) MigrateQuotes() {
-	d.db.AutoMigrate(&responses.Quote{})
+	d.MigrateUp(context.Background())
 	d.db.Table("quotes").Create(&responses.TestQuotes)
 }
 
 // Уничтожает тестовую БД
 func (d *DB) TeardownDB() {
-	sqlDB, _ := d.db.DB()
-	sqlDB.Close()
+	d.Close()
 
 	os.Remove("db_test.sqlite")
 }
 
-// Возвращает количество записей в БД
-func (d *DB) QuotesCount() (int, error) {
-	var quotes []responses.Quote
+// Возвращает количество записей в БД. Для пустой таблицы возвращает 0 без ошибки
+func (d *DB) QuotesCount(ctx context.Context) (int, error) {
+	var count int64
+
+	err := d.db.WithContext(ctx).Table("quotes").Count(&count).Error
+	if err != nil {
+		return 0, classify(ctx, err)
+	}
+	return int(count), nil
+}
+
+// Возвращает ID записи, стоящей на позиции offset в порядке ID. Позволяет выбрать
+// случайную цитату по смещению от 0 до QuotesCount, не полагаясь на то, что ID идут
+// подряд. Смещение за пределами таблицы возвращает ErrNotFound
+func (d *DB) QuoteIDAt(ctx context.Context, offset int) (int, error) {
+	var quote responses.Quote
 
-	tx := d.db.Table("quotes").Find(&quotes)
-	if tx.RowsAffected == 0 {
-		return 0, gorm.ErrRecordNotFound
+	err := d.db.WithContext(ctx).Table("quotes").Select("id").Order("id").Offset(offset).Take(&quote).Error
+	if err != nil {
+		return 0, classify(ctx, err)
 	}
-	return int(tx.RowsAffected), nil
+	return quote.ID, nil
 }
 
-// Возвращает все записи в БД
-func (d *DB) ListAll() ([]responses.Quote, error) {
-	var quotes []responses.Quote
+// Возвращает записи в БД, подходящие под фильтр, упорядоченные по ID. Фильтр
+// применяется в запросе так же, как при выгрузке. Для пустой выборки возвращает пустой
+// список без ошибки
+func (d *DB) ListAll(ctx context.Context, filter Filter) ([]responses.Quote, error) {
+	quotes := []responses.Quote{}
 
-	tx := d.db.Table("quotes").Find(&quotes)
-	if tx.RowsAffected == 0 {
-		return nil, gorm.ErrRecordNotFound
+	err := filter.apply(d.db.WithContext(ctx).Table("quotes")).Order("id").Find(&quotes).Error
+	if err != nil {
+		return nil, classify(ctx, err)
 	}
 	return quotes, nil
 }
 
-// Возвращает одну запись из БД по ID
-func (d *DB) GetQuote(id string) (responses.Quote, error) {
+// Возвращает одну запись из БД по ID. ID, не являющийся числом, возвращает ErrInvalid,
+// а отсутствующий - ErrNotFound
+func (d *DB) GetQuote(ctx context.Context, id string) (responses.Quote, error) {
 	var quote responses.Quote
 
-	tx := d.db.Table("quotes").Where("id=?", id).First(&quote)
-	if tx.RowsAffected == 0 {
-		return responses.Quote{}, gorm.ErrRecordNotFound
+	_, err := strconv.Atoi(id)
+	if err != nil {
+		return responses.Quote{}, &Error{Class: ErrInvalid, Err: err}
+	}
+
+	err = d.db.WithContext(ctx).Table("quotes").Where("id=?", id).First(&quote).Error
+	if err != nil {
+		return responses.Quote{}, classify(ctx, err)
 	}
 	return quote, nil
 }
@@ -1,12 +1,12 @@
 package database
 
 import (
+	"context"
 	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 
-	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 
 	"github.com/xoticdsign/returnauf/models/responses"
@@ -14,7 +14,7 @@ import (
 
 // Настройка GORM для тестов
 func setupTestDB(emptyDB bool) *DB {
-	DB, _ := RunGORM("db_test.sqlite")
+	DB, _ := OpenGORM("db_test.sqlite")
 
 	if !emptyDB {
 		DB.MigrateQuotes()
@@ -23,34 +23,84 @@ func setupTestDB(emptyDB bool) *DB {
 	return DB
 }
 
+// Настройка GORM для тестов с пустой таблицей цитат: схема создается, а цитаты нет
+func setupTestTable(emptyDB bool, emptyTable bool) *DB {
+	if !emptyTable {
+		return setupTestDB(emptyDB)
+	}
+
+	DB := setupTestDB(true)
+	DB.MigrateUp(context.Background())
+
+	return DB
+}
+
+// Проверяет, что ошибка относится к ожидаемому классу, а nil - что ошибки нет
+func assertErrorClass(t *testing.T, want error, got error) {
+	t.Helper()
+
+	if want == nil {
+		assert.NoError(t, got)
+		return
+	}
+	assert.ErrorIs(t, got, want)
+}
+
 // Unit тест для функции RunGORM
 func TestUnitRunGORM(t *testing.T) {
+	seed, _ := SeedQuotes()
+	migrations, _ := loadMigrations()
+	latest := migrations[len(migrations)-1].Version
+
 	cases := []struct {
 		name                   string
+		existingVersion        int
 		wantRunGORMToReturnErr error
 		wantLoggerToBe         logger.Interface
+		wantQuotesCount        int
+		wantSchemaVersion      int
 	}{
 		{
-			name:                   "general case",
+			name:                   "first start case",
+			wantRunGORMToReturnErr: nil,
+			wantLoggerToBe:         logger.Default.LogMode(logger.Silent),
+			wantQuotesCount:        len(seed),
+			wantSchemaVersion:      latest,
+		},
+		{
+			name:                   "existing schema case",
+			existingVersion:        1,
 			wantRunGORMToReturnErr: nil,
 			wantLoggerToBe:         logger.Default.LogMode(logger.Silent),
+			wantQuotesCount:        0,
+			wantSchemaVersion:      1,
 		},
 	}
 
 	for _, cs := range cases {
 		t.Run(cs.name, func(t *testing.T) {
+			defer os.Remove("db_test.sqlite")
+
+			if cs.existingVersion > 0 {
+				existing, _ := OpenGORM("db_test.sqlite")
+				existing.MigrateTo(context.Background(), cs.existingVersion)
+				existing.Close()
+			}
+
 			gotDB, gotErr := RunGORM("db_test.sqlite")
 			if gotErr != nil {
 				assert.Equal(t, cs.wantRunGORMToReturnErr, gotErr)
-			} else {
-				gotLogger := gotDB.db.Config.Logger
-				sqlDB, _ := gotDB.db.DB()
-				defer sqlDB.Close()
-
-				assert.Equal(t, cs.wantLoggerToBe, gotLogger)
+				return
 			}
+			defer gotDB.Close()
 
-			defer os.Remove("db_test.sqlite")
+			assert.Equal(t, cs.wantLoggerToBe, gotDB.db.Config.Logger)
+
+			gotCount, _ := gotDB.QuotesCount(context.Background())
+			assert.Equal(t, cs.wantQuotesCount, gotCount)
+
+			gotVersion, _ := gotDB.SchemaVersion(context.Background())
+			assert.Equal(t, cs.wantSchemaVersion, gotVersion)
 		})
 	}
 }
@@ -60,20 +110,82 @@ func TestUnitQuotesCount(t *testing.T) {
 	cases := []struct {
 		name                         string
 		emptyDB                      bool
+		emptyTable                   bool
 		wantQuotesCountToReturnCount int
 		wantQuotesCountToReturnErr   error
 	}{
 		{
 			name:                         "general case",
-			emptyDB:                      false,
 			wantQuotesCountToReturnCount: len(responses.TestQuotes),
 			wantQuotesCountToReturnErr:   nil,
 		},
 		{
-			name:                         "empty db case",
+			name:                         "empty table case",
+			emptyTable:                   true,
+			wantQuotesCountToReturnCount: 0,
+			wantQuotesCountToReturnErr:   nil,
+		},
+		{
+			name:                         "no schema case",
 			emptyDB:                      true,
 			wantQuotesCountToReturnCount: 0,
-			wantQuotesCountToReturnErr:   gorm.ErrRecordNotFound,
+			wantQuotesCountToReturnErr:   ErrUnavailable,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			DB := setupTestTable(cs.emptyDB, cs.emptyTable)
+			defer DB.TeardownDB()
+
+			gotCount, gotErr := DB.QuotesCount(context.Background())
+
+			assertErrorClass(t, cs.wantQuotesCountToReturnErr, gotErr)
+			assert.Equal(t, cs.wantQuotesCountToReturnCount, gotCount)
+		})
+	}
+}
+
+// Unit тест для функции QuoteIDAt
+func TestUnitQuoteIDAt(t *testing.T) {
+	cases := []struct {
+		name                     string
+		emptyDB                  bool
+		deleteID                 int
+		offset                   int
+		wantQuoteIDAtToReturnID  int
+		wantQuoteIDAtToReturnErr error
+	}{
+		{
+			name:                     "first quote case",
+			offset:                   0,
+			wantQuoteIDAtToReturnID:  1,
+			wantQuoteIDAtToReturnErr: nil,
+		},
+		{
+			name:                     "last quote case",
+			offset:                   len(responses.TestQuotes) - 1,
+			wantQuoteIDAtToReturnID:  3,
+			wantQuoteIDAtToReturnErr: nil,
+		},
+		{
+			name:                     "gap in ids case",
+			deleteID:                 2,
+			offset:                   1,
+			wantQuoteIDAtToReturnID:  3,
+			wantQuoteIDAtToReturnErr: nil,
+		},
+		{
+			name:                     "offset past end case",
+			offset:                   len(responses.TestQuotes),
+			wantQuoteIDAtToReturnID:  0,
+			wantQuoteIDAtToReturnErr: ErrNotFound,
+		},
+		{
+			name:                     "no schema case",
+			emptyDB:                  true,
+			wantQuoteIDAtToReturnID:  0,
+			wantQuoteIDAtToReturnErr: ErrUnavailable,
 		},
 	}
 
@@ -82,12 +194,14 @@ func TestUnitQuotesCount(t *testing.T) {
 			DB := setupTestDB(cs.emptyDB)
 			defer DB.TeardownDB()
 
-			gotCount, gotErr := DB.QuotesCount()
-			if gotErr != nil {
-				assert.Equal(t, cs.wantQuotesCountToReturnErr, gotErr)
-			} else {
-				assert.Equal(t, cs.wantQuotesCountToReturnCount, gotCount)
+			if cs.deleteID > 0 {
+				DB.db.Exec("DELETE FROM quotes WHERE id = ?", cs.deleteID)
 			}
+
+			gotID, gotErr := DB.QuoteIDAt(context.Background(), cs.offset)
+
+			assertErrorClass(t, cs.wantQuoteIDAtToReturnErr, gotErr)
+			assert.Equal(t, cs.wantQuoteIDAtToReturnID, gotID)
 		})
 	}
 }
@@ -96,35 +210,58 @@ func TestUnitQuotesCount(t *testing.T) {
 func TestUnitListAll(t *testing.T) {
 	cases := []struct {
 		name                      string
+		filter                    Filter
 		emptyDB                   bool
+		emptyTable                bool
 		wantListAllToReturnQuotes []responses.Quote
 		wantListAllToReturnErr    error
 	}{
 		{
 			name:                      "general case",
-			emptyDB:                   false,
 			wantListAllToReturnQuotes: responses.TestQuotes,
 			wantListAllToReturnErr:    nil,
 		},
 		{
-			name:                      "empty db case",
+			name:                      "id range case",
+			filter:                    Filter{FromID: 2, ToID: 2},
+			wantListAllToReturnQuotes: responses.TestQuotes[1:2],
+			wantListAllToReturnErr:    nil,
+		},
+		{
+			name:                      "contains case",
+			filter:                    Filter{Contains: "quote 3"},
+			wantListAllToReturnQuotes: responses.TestQuotes[2:],
+			wantListAllToReturnErr:    nil,
+		},
+		{
+			name:                      "nothing matched case",
+			filter:                    Filter{FromID: 100},
+			wantListAllToReturnQuotes: []responses.Quote{},
+			wantListAllToReturnErr:    nil,
+		},
+		{
+			name:                      "empty table case",
+			emptyTable:                true,
+			wantListAllToReturnQuotes: []responses.Quote{},
+			wantListAllToReturnErr:    nil,
+		},
+		{
+			name:                      "no schema case",
 			emptyDB:                   true,
 			wantListAllToReturnQuotes: nil,
-			wantListAllToReturnErr:    gorm.ErrRecordNotFound,
+			wantListAllToReturnErr:    ErrUnavailable,
 		},
 	}
 
 	for _, cs := range cases {
 		t.Run(cs.name, func(t *testing.T) {
-			DB := setupTestDB(cs.emptyDB)
+			DB := setupTestTable(cs.emptyDB, cs.emptyTable)
 			defer DB.TeardownDB()
 
-			gotQuotes, gotErr := DB.ListAll()
-			if gotErr != nil {
-				assert.Equal(t, cs.wantListAllToReturnErr, gotErr)
-			} else {
-				assert.Equal(t, cs.wantListAllToReturnQuotes, gotQuotes)
-			}
+			gotQuotes, gotErr := DB.ListAll(context.Background(), cs.filter)
+
+			assertErrorClass(t, cs.wantListAllToReturnErr, gotErr)
+			assert.Equal(t, cs.wantListAllToReturnQuotes, gotQuotes)
 		})
 	}
 }
@@ -134,35 +271,108 @@ func TestUnitGetQuote(t *testing.T) {
 	cases := []struct {
 		name                      string
 		input                     string
-		emptyDB                   bool
+		emptyTable                bool
+		cancelCtx                 bool
 		wantGetQuoteToReturnQuote responses.Quote
 		wantGetQuoteToReturnErr   error
 	}{
 		{
 			name:                      "general case",
 			input:                     "1",
-			emptyDB:                   false,
 			wantGetQuoteToReturnQuote: responses.TestQuotes[0],
 			wantGetQuoteToReturnErr:   nil,
 		},
 		{
-			name:                      "empty db case",
-			emptyDB:                   true,
+			name:                      "missing id case",
+			input:                     "999",
+			wantGetQuoteToReturnQuote: responses.Quote{},
+			wantGetQuoteToReturnErr:   ErrNotFound,
+		},
+		{
+			name:                      "empty table case",
+			input:                     "1",
+			emptyTable:                true,
+			wantGetQuoteToReturnQuote: responses.Quote{},
+			wantGetQuoteToReturnErr:   ErrNotFound,
+		},
+		{
+			name:                      "invalid id case",
+			input:                     "abc",
 			wantGetQuoteToReturnQuote: responses.Quote{},
-			wantGetQuoteToReturnErr:   gorm.ErrRecordNotFound,
+			wantGetQuoteToReturnErr:   ErrInvalid,
+		},
+		{
+			name:                      "cancelled context case",
+			input:                     "1",
+			cancelCtx:                 true,
+			wantGetQuoteToReturnQuote: responses.Quote{},
+			wantGetQuoteToReturnErr:   ErrUnavailable,
 		},
 	}
 
 	for _, cs := range cases {
 		t.Run(cs.name, func(t *testing.T) {
-			DB := setupTestDB(cs.emptyDB)
+			DB := setupTestTable(false, cs.emptyTable)
 			defer DB.TeardownDB()
 
-			gotQuote, gotErr := DB.GetQuote(cs.input)
-			if gotErr != nil {
-				assert.Equal(t, cs.wantGetQuoteToReturnErr, gotErr)
-			} else {
-				assert.Equal(t, cs.wantGetQuoteToReturnQuote, gotQuote)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			if cs.cancelCtx {
+				cancel()
+			}
+
+			gotQuote, gotErr := DB.GetQuote(ctx, cs.input)
+
+			assertErrorClass(t, cs.wantGetQuoteToReturnErr, gotErr)
+			assert.Equal(t, cs.wantGetQuoteToReturnQuote, gotQuote)
+		})
+	}
+}
+
+// Unit тест для функции Ping
+func TestUnitPing(t *testing.T) {
+	cases := []struct {
+		name             string
+		migrating        bool
+		closeDB          bool
+		wantPingToReturn error
+		wantPingToFail   bool
+	}{
+		{
+			name:             "general case",
+			wantPingToReturn: nil,
+		},
+		{
+			name:             "migrating case",
+			migrating:        true,
+			wantPingToReturn: ErrMigrating,
+			wantPingToFail:   true,
+		},
+		{
+			name:           "closed db case",
+			closeDB:        true,
+			wantPingToFail: true,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			DB := setupTestDB(true)
+			defer DB.TeardownDB()
+
+			if cs.migrating {
+				DB.migrating.Add(1)
+			}
+			if cs.closeDB {
+				DB.Close()
+			}
+
+			gotErr := DB.Ping(context.Background())
+
+			assert.Equal(t, cs.wantPingToFail, gotErr != nil)
+			if cs.wantPingToReturn != nil {
+				assert.Equal(t, cs.wantPingToReturn, gotErr)
 			}
 		})
 	}
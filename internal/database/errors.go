@@ -0,0 +1,85 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mattn/go-sqlite3"
+	"gorm.io/gorm"
+)
+
+// Классы ошибок БД. Методы DB возвращают ошибки, которые errors.Is относит ровно к
+// одному классу, поэтому вызывающему не нужно разбирать ошибки GORM и драйвера
+var (
+	// Запись не существует
+	ErrNotFound = errors.New("record not found")
+	// Запись противоречит существующей, например повторяет ее ключ
+	ErrConflict = errors.New("record conflicts with an existing one")
+	// БД недоступна: заблокирована, повреждена, закрыта или запрос прерван
+	ErrUnavailable = errors.New("database unavailable")
+	// Запрос или данные некорректны и не будут приняты при повторе
+	ErrInvalid = errors.New("invalid database request")
+)
+
+// Ошибка БД с классом и исходной ошибкой GORM или драйвера
+type Error struct {
+	Class error
+	Err   error
+}
+
+// Возвращает класс и исходную ошибку
+func (e *Error) Error() string {
+	return e.Class.Error() + ": " + e.Err.Error()
+}
+
+// Возвращает класс и исходную ошибку для errors.Is и errors.As
+func (e *Error) Unwrap() []error {
+	return []error{e.Class, e.Err}
+}
+
+// Относит ошибку запроса к классу. Отмена или истечение контекста считаются
+// недоступностью БД, а неизвестные ошибки драйвера - сбоем БД, а не ошибкой запроса
+func classify(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var e *Error
+	if errors.As(err, &e) {
+		return err
+	}
+
+	if ctx.Err() != nil {
+		return &Error{Class: ErrUnavailable, Err: ctx.Err()}
+	}
+
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return &Error{Class: ErrNotFound, Err: err}
+
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return &Error{Class: ErrConflict, Err: err}
+
+	case errors.Is(err, gorm.ErrInvalidData),
+		errors.Is(err, gorm.ErrInvalidField),
+		errors.Is(err, gorm.ErrInvalidValue),
+		errors.Is(err, gorm.ErrInvalidValueOfLength),
+		errors.Is(err, gorm.ErrPrimaryKeyRequired),
+		errors.Is(err, gorm.ErrMissingWhereClause):
+		return &Error{Class: ErrInvalid, Err: err}
+	}
+
+	var se sqlite3.Error
+	if errors.As(err, &se) {
+		switch se.ExtendedCode {
+		case sqlite3.ErrConstraintUnique, sqlite3.ErrConstraintPrimaryKey:
+			return &Error{Class: ErrConflict, Err: err}
+		}
+
+		switch se.Code {
+		case sqlite3.ErrConstraint, sqlite3.ErrMismatch, sqlite3.ErrRange, sqlite3.ErrTooBig:
+			return &Error{Class: ErrInvalid, Err: err}
+		}
+	}
+	return &Error{Class: ErrUnavailable, Err: err}
+}
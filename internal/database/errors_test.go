@@ -0,0 +1,104 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// Unit тест для функции classify
+func TestUnitClassify(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	cases := []struct {
+		name      string
+		ctx       context.Context
+		err       error
+		wantClass error
+	}{
+		{
+			name:      "nil case",
+			ctx:       context.Background(),
+			err:       nil,
+			wantClass: nil,
+		},
+		{
+			name:      "not found case",
+			ctx:       context.Background(),
+			err:       gorm.ErrRecordNotFound,
+			wantClass: ErrNotFound,
+		},
+		{
+			name:      "duplicated key case",
+			ctx:       context.Background(),
+			err:       gorm.ErrDuplicatedKey,
+			wantClass: ErrConflict,
+		},
+		{
+			name:      "unique constraint case",
+			ctx:       context.Background(),
+			err:       sqlite3.Error{Code: sqlite3.ErrConstraint, ExtendedCode: sqlite3.ErrConstraintPrimaryKey},
+			wantClass: ErrConflict,
+		},
+		{
+			name:      "not null constraint case",
+			ctx:       context.Background(),
+			err:       sqlite3.Error{Code: sqlite3.ErrConstraint, ExtendedCode: sqlite3.ErrConstraintNotNull},
+			wantClass: ErrInvalid,
+		},
+		{
+			name:      "invalid data case",
+			ctx:       context.Background(),
+			err:       gorm.ErrInvalidData,
+			wantClass: ErrInvalid,
+		},
+		{
+			name:      "locked case",
+			ctx:       context.Background(),
+			err:       sqlite3.Error{Code: sqlite3.ErrBusy},
+			wantClass: ErrUnavailable,
+		},
+		{
+			name:      "corrupt case",
+			ctx:       context.Background(),
+			err:       sqlite3.Error{Code: sqlite3.ErrCorrupt},
+			wantClass: ErrUnavailable,
+		},
+		{
+			name:      "cancelled context case",
+			ctx:       cancelled,
+			err:       context.Canceled,
+			wantClass: ErrUnavailable,
+		},
+		{
+			name:      "unknown error case",
+			ctx:       context.Background(),
+			err:       errors.New("error"),
+			wantClass: ErrUnavailable,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			got := classify(cs.ctx, cs.err)
+
+			if cs.wantClass == nil {
+				assert.NoError(t, got)
+				return
+			}
+			assert.ErrorIs(t, got, cs.wantClass)
+			assert.ErrorIs(t, got, cs.err)
+
+			for _, class := range []error{ErrNotFound, ErrConflict, ErrUnavailable, ErrInvalid} {
+				if class != cs.wantClass {
+					assert.NotErrorIs(t, got, class)
+				}
+			}
+		})
+	}
+}
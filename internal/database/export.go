@@ -0,0 +1,91 @@
+package database
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/xoticdsign/returnauf/models/responses"
+)
+
+// Интерфейс, содержащий методы для выборки цитат по фильтру: потоковой выгрузки,
+// подсчета и постраничного чтения
+type Exporter interface {
+	StreamQuotes(ctx context.Context, filter Filter, fn func(responses.Quote) error) error
+	CountQuotes(ctx context.Context, filter Filter) (int, error)
+	PageQuotes(ctx context.Context, filter Filter, offset int, limit int) ([]responses.Quote, error)
+}
+
+// Структура с фильтрами списка цитат. Нулевые значения означают отсутствие фильтра
+type Filter struct {
+	FromID   int
+	ToID     int
+	Contains string
+}
+
+// Сообщает, задан ли хотя бы один фильтр
+func (f Filter) IsZero() bool {
+	return f == Filter{}
+}
+
+// Добавляет условия фильтра к запросу
+func (f Filter) apply(tx *gorm.DB) *gorm.DB {
+	if f.FromID > 0 {
+		tx = tx.Where("id >= ?", f.FromID)
+	}
+	if f.ToID > 0 {
+		tx = tx.Where("id <= ?", f.ToID)
+	}
+	if f.Contains != "" {
+		tx = tx.Where("instr(quote, ?) > 0", f.Contains)
+	}
+	return tx
+}
+
+// Построчно читает цитаты через курсор и передает каждую в fn, не загружая всю
+// выборку в память. Ошибка fn прерывает чтение и возвращается вызывающему без изменений
+func (d *DB) StreamQuotes(ctx context.Context, filter Filter, fn func(responses.Quote) error) error {
+	rows, err := filter.apply(d.db.WithContext(ctx).Table("quotes")).Order("id").Rows()
+	if err != nil {
+		return classify(ctx, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var quote responses.Quote
+
+		err := d.db.ScanRows(rows, &quote)
+		if err != nil {
+			return classify(ctx, err)
+		}
+
+		err = fn(quote)
+		if err != nil {
+			return err
+		}
+	}
+	return classify(ctx, rows.Err())
+}
+
+// Возвращает количество цитат, подходящих под фильтр, одним запросом COUNT
+func (d *DB) CountQuotes(ctx context.Context, filter Filter) (int, error) {
+	var count int64
+
+	err := filter.apply(d.db.WithContext(ctx).Table("quotes")).Count(&count).Error
+	if err != nil {
+		return 0, classify(ctx, err)
+	}
+	return int(count), nil
+}
+
+// Возвращает не больше limit цитат, подходящих под фильтр, начиная с offset в порядке
+// ID. Для страницы за пределами выборки возвращает пустой список без ошибки
+func (d *DB) PageQuotes(ctx context.Context, filter Filter, offset int, limit int) ([]responses.Quote, error) {
+	quotes := []responses.Quote{}
+
+	err := filter.apply(d.db.WithContext(ctx).Table("quotes")).Order("id").Offset(offset).Limit(limit).Find(&quotes).Error
+	if err != nil {
+		return nil, classify(ctx, err)
+	}
+	return quotes, nil
+}
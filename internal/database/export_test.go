@@ -0,0 +1,160 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/xoticdsign/returnauf/models/responses"
+)
+
+// Unit тест для функции StreamQuotes
+func TestUnitStreamQuotes(t *testing.T) {
+	cases := []struct {
+		name       string
+		emptyDB    bool
+		filter     Filter
+		fnErr      error
+		wantQuotes []responses.Quote
+		wantErr    error
+	}{
+		{
+			name:       "general case",
+			emptyDB:    false,
+			filter:     Filter{},
+			wantQuotes: responses.TestQuotes,
+			wantErr:    nil,
+		},
+		{
+			name:       "filter case",
+			emptyDB:    false,
+			filter:     Filter{FromID: 2, Contains: "3"},
+			wantQuotes: responses.TestQuotes[2:3],
+			wantErr:    nil,
+		},
+		{
+			name:       "callback error case",
+			emptyDB:    false,
+			filter:     Filter{},
+			fnErr:      errors.New("error"),
+			wantQuotes: responses.TestQuotes[:1],
+			wantErr:    errors.New("error"),
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			DB := setupTestDB(cs.emptyDB)
+			defer DB.TeardownDB()
+
+			var gotQuotes []responses.Quote
+
+			gotErr := DB.StreamQuotes(context.Background(), cs.filter, func(quote responses.Quote) error {
+				gotQuotes = append(gotQuotes, quote)
+
+				return cs.fnErr
+			})
+
+			assert.Equal(t, cs.wantErr, gotErr)
+			assert.Equal(t, cs.wantQuotes, gotQuotes)
+		})
+	}
+}
+
+// Unit тест для функции CountQuotes
+func TestUnitCountQuotes(t *testing.T) {
+	cases := []struct {
+		name      string
+		filter    Filter
+		emptyDB   bool
+		wantCount int
+		wantErr   error
+	}{
+		{
+			name:      "general case",
+			wantCount: len(responses.TestQuotes),
+			wantErr:   nil,
+		},
+		{
+			name:      "filter case",
+			filter:    Filter{FromID: 2, Contains: "quote"},
+			wantCount: 2,
+			wantErr:   nil,
+		},
+		{
+			name:      "no schema case",
+			emptyDB:   true,
+			wantCount: 0,
+			wantErr:   ErrUnavailable,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			DB := setupTestDB(cs.emptyDB)
+			defer DB.TeardownDB()
+
+			gotCount, gotErr := DB.CountQuotes(context.Background(), cs.filter)
+
+			assertErrorClass(t, cs.wantErr, gotErr)
+			assert.Equal(t, cs.wantCount, gotCount)
+		})
+	}
+}
+
+// Unit тест для функции PageQuotes
+func TestUnitPageQuotes(t *testing.T) {
+	cases := []struct {
+		name       string
+		filter     Filter
+		offset     int
+		limit      int
+		emptyDB    bool
+		wantQuotes []responses.Quote
+		wantErr    error
+	}{
+		{
+			name:       "general case",
+			offset:     1,
+			limit:      1,
+			wantQuotes: responses.TestQuotes[1:2],
+			wantErr:    nil,
+		},
+		{
+			name:       "filter case",
+			filter:     Filter{FromID: 2},
+			offset:     1,
+			limit:      10,
+			wantQuotes: responses.TestQuotes[2:],
+			wantErr:    nil,
+		},
+		{
+			name:       "offset past end case",
+			offset:     10,
+			limit:      1,
+			wantQuotes: []responses.Quote{},
+			wantErr:    nil,
+		},
+		{
+			name:       "no schema case",
+			emptyDB:    true,
+			limit:      1,
+			wantQuotes: nil,
+			wantErr:    ErrUnavailable,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			DB := setupTestDB(cs.emptyDB)
+			defer DB.TeardownDB()
+
+			gotQuotes, gotErr := DB.PageQuotes(context.Background(), cs.filter, cs.offset, cs.limit)
+
+			assertErrorClass(t, cs.wantErr, gotErr)
+			assert.Equal(t, cs.wantQuotes, gotQuotes)
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Интерфейс, содержащий методы для построения лент цитат
+type Feeder interface {
+	FeedState(ctx context.Context) (FeedState, error)
+	LatestQuotes(ctx context.Context, limit int) ([]FeedEntry, error)
+}
+
+// Структура с состоянием таблицы цитат для условных запросов лент. Меняется при
+// добавлении, изменении и удалении цитат
+type FeedState struct {
+	Count   int
+	Updated time.Time
+}
+
+// Структура цитаты ленты с временем добавления и последнего изменения
+type FeedEntry struct {
+	ID        int
+	Quote     string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Возвращает количество цитат и время последнего изменения. Не читает сами цитаты,
+// поэтому дешево вызывается на каждый запрос ленты
+func (d *DB) FeedState(ctx context.Context) (FeedState, error) {
+	var state FeedState
+	var count int64
+
+	err := d.db.WithContext(ctx).Table("quotes").Count(&count).Error
+	if err != nil {
+		return FeedState{}, classify(ctx, err)
+	}
+	state.Count = int(count)
+
+	var updated sql.NullTime
+
+	err = d.db.WithContext(ctx).Table("quotes").Select("updated_at").Where("updated_at IS NOT NULL").Order("updated_at DESC").Limit(1).Row().Scan(&updated)
+	if err != nil && err != sql.ErrNoRows {
+		return FeedState{}, classify(ctx, err)
+	}
+	state.Updated = updated.Time
+
+	return state, nil
+}
+
+// Возвращает последние добавленные цитаты, от новых к старым
+func (d *DB) LatestQuotes(ctx context.Context, limit int) ([]FeedEntry, error) {
+	var entries []FeedEntry
+
+	err := d.db.WithContext(ctx).Table("quotes").Order("created_at DESC").Order("id DESC").Limit(limit).Find(&entries).Error
+	if err != nil {
+		return nil, classify(ctx, err)
+	}
+	return entries, nil
+}
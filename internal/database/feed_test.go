@@ -0,0 +1,95 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/xoticdsign/returnauf/models/responses"
+)
+
+// Unit тест для функции FeedState
+func TestUnitFeedState(t *testing.T) {
+	cases := []struct {
+		name                     string
+		emptyDB                  bool
+		wantCount                int
+		wantFeedStateToReturnErr bool
+	}{
+		{
+			name:                     "general case",
+			emptyDB:                  false,
+			wantCount:                len(responses.TestQuotes),
+			wantFeedStateToReturnErr: false,
+		},
+		{
+			name:                     "no table case",
+			emptyDB:                  true,
+			wantCount:                0,
+			wantFeedStateToReturnErr: true,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			DB := setupTestDB(cs.emptyDB)
+			defer DB.TeardownDB()
+
+			gotState, gotErr := DB.FeedState(context.Background())
+
+			if cs.wantFeedStateToReturnErr {
+				assert.NotNil(t, gotErr)
+				return
+			}
+			assert.Nil(t, gotErr)
+			assert.Equal(t, cs.wantCount, gotState.Count)
+			assert.WithinDuration(t, time.Now(), gotState.Updated, time.Minute)
+		})
+	}
+}
+
+// Unit тест для триггеров времени изменения цитат
+func TestUnitFeedTimestamps(t *testing.T) {
+	DB := setupTestDB(false)
+	defer DB.TeardownDB()
+
+	old := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	DB.db.Exec("UPDATE quotes SET created_at = ?, updated_at = ?", old, old)
+
+	DB.db.Exec("UPDATE quotes SET quote = quote WHERE id = 1")
+	DB.db.Exec("UPDATE quotes SET quote = 'Changed quote' WHERE id = 2")
+
+	entries, err := DB.LatestQuotes(context.Background(), 5)
+	if assert.Nil(t, err) {
+		for _, entry := range entries {
+			assert.True(t, entry.CreatedAt.Equal(old))
+
+			if entry.ID == 2 {
+				assert.True(t, entry.UpdatedAt.After(old))
+			} else {
+				assert.True(t, entry.UpdatedAt.Equal(old))
+			}
+		}
+	}
+}
+
+// Unit тест для функции LatestQuotes
+func TestUnitLatestQuotes(t *testing.T) {
+	DB := setupTestDB(false)
+	defer DB.TeardownDB()
+
+	DB.db.Exec("UPDATE quotes SET created_at = ?", time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	DB.db.Exec("UPDATE quotes SET created_at = ? WHERE id = 2", time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	entries, err := DB.LatestQuotes(context.Background(), 3)
+	if assert.Nil(t, err) {
+		gotIDs := []int{}
+		for _, entry := range entries {
+			gotIDs = append(gotIDs, entry.ID)
+		}
+		assert.Equal(t, []int{2, 3, 1}, gotIDs)
+	}
+}
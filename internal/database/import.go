@@ -0,0 +1,94 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+
+	"github.com/xoticdsign/returnauf/models/responses"
+)
+
+// Политики обработки цитат с уже существующими ID
+const (
+	PolicyUpsert = "upsert"
+	PolicySkip   = "skip"
+)
+
+// Ошибка неизвестной политики импорта
+var ErrUnknownPolicy = errors.New("unknown import policy")
+
+// Ошибка, которой откатывается транзакция пробного импорта
+var errDryRun = errors.New("dry run")
+
+// Интерфейс, содержащий методы для записи цитат в БД
+type Importer interface {
+	ImportQuotes(ctx context.Context, quotes []responses.Quote, policy string, dryRun bool) (ImportResult, error)
+}
+
+// Структура для возврата результата импорта. Вместе со счетчиками возвращаются
+// добавленные и измененные цитаты
+type ImportResult struct {
+	Inserted       int
+	Updated        int
+	Skipped        int
+	InsertedQuotes []responses.Quote
+	UpdatedQuotes  []responses.Quote
+}
+
+// Возвращает добавленные и измененные цитаты
+func (r ImportResult) Changed() []responses.Quote {
+	return append(append([]responses.Quote{}, r.InsertedQuotes...), r.UpdatedQuotes...)
+}
+
+// Записывает цитаты в одной транзакции. Существующие ID обновляются или пропускаются
+// в зависимости от политики. При dryRun транзакция откатывается, но результат
+// подсчитывается так же, как при настоящем импорте
+func (d *DB) ImportQuotes(ctx context.Context, quotes []responses.Quote, policy string, dryRun bool) (ImportResult, error) {
+	var result ImportResult
+
+	if policy != PolicyUpsert && policy != PolicySkip {
+		return result, ErrUnknownPolicy
+	}
+
+	err := d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, quote := range quotes {
+			var existing responses.Quote
+
+			res := tx.Table("quotes").Where("id=?", quote.ID).Limit(1).Find(&existing)
+			if res.Error != nil {
+				return res.Error
+			}
+
+			switch {
+			case res.RowsAffected == 0:
+				err := tx.Table("quotes").Create(&quote).Error
+				if err != nil {
+					return err
+				}
+				result.Inserted++
+				result.InsertedQuotes = append(result.InsertedQuotes, quote)
+
+			case policy == PolicySkip || existing.Quote == quote.Quote:
+				result.Skipped++
+
+			default:
+				err := tx.Table("quotes").Where("id=?", quote.ID).Update("quote", quote.Quote).Error
+				if err != nil {
+					return err
+				}
+				result.Updated++
+				result.UpdatedQuotes = append(result.UpdatedQuotes, quote)
+			}
+		}
+
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		return ImportResult{}, classify(ctx, err)
+	}
+	return result, nil
+}
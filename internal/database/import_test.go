@@ -0,0 +1,84 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/xoticdsign/returnauf/models/responses"
+)
+
+// Unit тест для функции ImportQuotes
+func TestUnitImportQuotes(t *testing.T) {
+	input := []responses.Quote{
+		{ID: 1, Quote: "Changed quote 1"},
+		{ID: 2, Quote: "Mock quote 2"},
+		{ID: 10, Quote: "New quote 10"},
+	}
+
+	cases := []struct {
+		name                           string
+		policy                         string
+		dryRun                         bool
+		wantImportQuotesToReturnResult ImportResult
+		wantImportQuotesToReturnErr    error
+		wantQuote1ToBe                 string
+		wantQuotesCountAfterImport     int
+	}{
+		{
+			name:                           "upsert case",
+			policy:                         PolicyUpsert,
+			dryRun:                         false,
+			wantImportQuotesToReturnResult: ImportResult{Inserted: 1, Updated: 1, Skipped: 1, InsertedQuotes: input[2:3], UpdatedQuotes: input[0:1]},
+			wantImportQuotesToReturnErr:    nil,
+			wantQuote1ToBe:                 "Changed quote 1",
+			wantQuotesCountAfterImport:     len(responses.TestQuotes) + 1,
+		},
+		{
+			name:                           "skip case",
+			policy:                         PolicySkip,
+			dryRun:                         false,
+			wantImportQuotesToReturnResult: ImportResult{Inserted: 1, Skipped: 2, InsertedQuotes: input[2:3]},
+			wantImportQuotesToReturnErr:    nil,
+			wantQuote1ToBe:                 "Mock quote 1",
+			wantQuotesCountAfterImport:     len(responses.TestQuotes) + 1,
+		},
+		{
+			name:                           "dry run case",
+			policy:                         PolicyUpsert,
+			dryRun:                         true,
+			wantImportQuotesToReturnResult: ImportResult{Inserted: 1, Updated: 1, Skipped: 1, InsertedQuotes: input[2:3], UpdatedQuotes: input[0:1]},
+			wantImportQuotesToReturnErr:    nil,
+			wantQuote1ToBe:                 "Mock quote 1",
+			wantQuotesCountAfterImport:     len(responses.TestQuotes),
+		},
+		{
+			name:                           "unknown policy case",
+			policy:                         "replace",
+			dryRun:                         false,
+			wantImportQuotesToReturnResult: ImportResult{},
+			wantImportQuotesToReturnErr:    ErrUnknownPolicy,
+			wantQuote1ToBe:                 "Mock quote 1",
+			wantQuotesCountAfterImport:     len(responses.TestQuotes),
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			DB := setupTestDB(false)
+			defer DB.TeardownDB()
+
+			gotResult, gotErr := DB.ImportQuotes(context.Background(), input, cs.policy, cs.dryRun)
+
+			assert.Equal(t, cs.wantImportQuotesToReturnErr, gotErr)
+			assert.Equal(t, cs.wantImportQuotesToReturnResult, gotResult)
+
+			gotQuote, _ := DB.GetQuote(context.Background(), "1")
+			gotCount, _ := DB.QuotesCount(context.Background())
+
+			assert.Equal(t, cs.wantQuote1ToBe, gotQuote.Quote)
+			assert.Equal(t, cs.wantQuotesCountAfterImport, gotCount)
+		})
+	}
+}
@@ -0,0 +1,306 @@
+package database
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Файлы миграций вида NNNN_название.up.sql и NNNN_название.down.sql
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Ошибка несуществующей версии миграции
+var ErrUnknownMigration = errors.New("unknown migration version")
+
+// Структура, описывающая одну миграцию
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Структура для возврата состояния миграции
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Структура записи в таблице schema_migrations
+type schemaMigration struct {
+	Version   int       `gorm:"type:BIGINT NOT NULL PRIMARY KEY"`
+	AppliedAt time.Time `gorm:"type:DATETIME NOT NULL"`
+}
+
+// Возвращает имя таблицы для schemaMigration
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Читает встроенные миграции, отсортированные по версии
+func loadMigrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+
+	for _, entry := range entries {
+		name := entry.Name()
+
+		var direction string
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(name, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+
+		base := strings.TrimSuffix(name, "."+direction+".sql")
+
+		prefix, title, _ := strings.Cut(base, "_")
+
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: bad version: %w", name, err)
+		}
+
+		body, err := migrationFiles.ReadFile(path.Join("migrations", name))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: title}
+			byVersion[version] = m
+		}
+
+		if direction == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d: both up and down files are required", m.Version)
+		}
+		migrations = append(migrations, *m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Создает таблицу schema_migrations и возвращает примененные версии
+func (d *DB) appliedMigrations(ctx context.Context) (map[int]time.Time, error) {
+	err := d.db.WithContext(ctx).AutoMigrate(&schemaMigration{})
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []schemaMigration
+
+	err = d.db.WithContext(ctx).Order("version").Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int]time.Time, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row.AppliedAt
+	}
+	return applied, nil
+}
+
+// Возвращает текущую версию схемы, 0 если миграции не применялись
+func (d *DB) SchemaVersion(ctx context.Context) (int, error) {
+	applied, err := d.appliedMigrations(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var version int
+	for v := range applied {
+		if v > version {
+			version = v
+		}
+	}
+	return version, nil
+}
+
+// Возвращает состояние всех известных миграций
+func (d *DB) MigrationStatus(ctx context.Context) ([]MigrationStatus, error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := d.appliedMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	status := make([]MigrationStatus, len(migrations))
+	for i, m := range migrations {
+		at, ok := applied[m.Version]
+
+		status[i] = MigrationStatus{
+			Version:   m.Version,
+			Name:      m.Name,
+			Applied:   ok,
+			AppliedAt: at,
+		}
+	}
+	return status, nil
+}
+
+// Применяет все непримененные миграции
+func (d *DB) MigrateUp(ctx context.Context) error {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+	if len(migrations) == 0 {
+		return nil
+	}
+
+	return d.MigrateTo(ctx, migrations[len(migrations)-1].Version)
+}
+
+// Применяет все непримененные миграции в фоне и возвращает канал, в который придет
+// результат. БД считается мигрирующей уже к возврату, поэтому Ping сразу возвращает
+// ErrMigrating
+func (d *DB) MigrateUpAsync(ctx context.Context) <-chan error {
+	d.migrating.Add(1)
+
+	done := make(chan error, 1)
+
+	go func() {
+		defer d.migrating.Add(-1)
+
+		done <- d.MigrateUp(ctx)
+	}()
+	return done
+}
+
+// Откатывает последнюю примененную миграцию
+func (d *DB) MigrateDown(ctx context.Context) error {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+
+	applied, err := d.appliedMigrations(ctx)
+	if err != nil {
+		return err
+	}
+
+	target := -1
+	for i := len(migrations) - 1; i >= 0; i-- {
+		if _, ok := applied[migrations[i].Version]; !ok {
+			continue
+		}
+
+		target = 0
+		if i > 0 {
+			target = migrations[i-1].Version
+		}
+		break
+	}
+	if target < 0 {
+		return nil
+	}
+
+	return d.MigrateTo(ctx, target)
+}
+
+// Приводит схему к заданной версии, применяя или откатывая миграции. Версия 0 откатывает все
+func (d *DB) MigrateTo(ctx context.Context, version int) error {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+
+	known := version == 0
+	for _, m := range migrations {
+		if m.Version == version {
+			known = true
+		}
+	}
+	if !known {
+		return ErrUnknownMigration
+	}
+
+	d.migrating.Add(1)
+	defer d.migrating.Add(-1)
+
+	applied, err := d.appliedMigrations(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		_, ok := applied[m.Version]
+		if ok || m.Version > version {
+			continue
+		}
+
+		err := d.applyMigration(ctx, m.Version, m.Up, true)
+		if err != nil {
+			return fmt.Errorf("migration %d up: %w", m.Version, err)
+		}
+	}
+
+	for i := len(migrations) - 1; i >= 0; i-- {
+		m := migrations[i]
+
+		_, ok := applied[m.Version]
+		if !ok || m.Version <= version {
+			continue
+		}
+
+		err := d.applyMigration(ctx, m.Version, m.Down, false)
+		if err != nil {
+			return fmt.Errorf("migration %d down: %w", m.Version, err)
+		}
+	}
+	return nil
+}
+
+// Выполняет SQL миграции и обновляет schema_migrations в одной транзакции
+func (d *DB) applyMigration(ctx context.Context, version int, sql string, up bool) error {
+	return d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(sql).Error
+		if err != nil {
+			return err
+		}
+
+		if up {
+			return tx.Create(&schemaMigration{Version: version, AppliedAt: time.Now().UTC()}).Error
+		}
+		return tx.Where("version=?", version).Delete(&schemaMigration{}).Error
+	})
+}
@@ -0,0 +1,161 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Unit тест для функции loadMigrations
+func TestUnitLoadMigrations(t *testing.T) {
+	migrations, err := loadMigrations()
+	if assert.Nil(t, err) && assert.NotEmpty(t, migrations) {
+		for i, m := range migrations {
+			assert.NotEmpty(t, m.Up)
+			assert.NotEmpty(t, m.Down)
+
+			if i > 0 {
+				assert.Greater(t, m.Version, migrations[i-1].Version)
+			}
+		}
+	}
+}
+
+// Unit тест для функций MigrateUp, MigrateUpAsync, MigrateDown и MigrateTo
+func TestUnitMigrate(t *testing.T) {
+	latest := func() int {
+		migrations, _ := loadMigrations()
+
+		return migrations[len(migrations)-1].Version
+	}()
+
+	cases := []struct {
+		name              string
+		migrate           func(d *DB) error
+		wantErr           error
+		wantVersion       int
+		wantQuotesToExist bool
+	}{
+		{
+			name: "up case",
+			migrate: func(d *DB) error {
+				return d.MigrateUp(context.Background())
+			},
+			wantErr:           nil,
+			wantVersion:       latest,
+			wantQuotesToExist: true,
+		},
+		{
+			name: "up twice case",
+			migrate: func(d *DB) error {
+				d.MigrateUp(context.Background())
+
+				return d.MigrateUp(context.Background())
+			},
+			wantErr:           nil,
+			wantVersion:       latest,
+			wantQuotesToExist: true,
+		},
+		{
+			name: "async up case",
+			migrate: func(d *DB) error {
+				done := d.MigrateUpAsync(context.Background())
+				if d.Ping(context.Background()) != ErrMigrating {
+					return ErrUnavailable
+				}
+				return <-done
+			},
+			wantErr:           nil,
+			wantVersion:       latest,
+			wantQuotesToExist: true,
+		},
+		{
+			name: "down to zero case",
+			migrate: func(d *DB) error {
+				d.MigrateUp(context.Background())
+
+				return d.MigrateTo(context.Background(), 0)
+			},
+			wantErr:           nil,
+			wantVersion:       0,
+			wantQuotesToExist: false,
+		},
+		{
+			name: "down on empty schema case",
+			migrate: func(d *DB) error {
+				return d.MigrateDown(context.Background())
+			},
+			wantErr:           nil,
+			wantVersion:       0,
+			wantQuotesToExist: false,
+		},
+		{
+			name: "unknown version case",
+			migrate: func(d *DB) error {
+				return d.MigrateTo(context.Background(), 999999)
+			},
+			wantErr:           ErrUnknownMigration,
+			wantVersion:       0,
+			wantQuotesToExist: false,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			DB := setupTestDB(true)
+			defer DB.TeardownDB()
+
+			gotErr := cs.migrate(DB)
+
+			assert.Equal(t, cs.wantErr, gotErr)
+			assert.NoError(t, DB.Ping(context.Background()))
+
+			gotVersion, err := DB.SchemaVersion(context.Background())
+			if assert.Nil(t, err) {
+				assert.Equal(t, cs.wantVersion, gotVersion)
+			}
+
+			assert.Equal(t, cs.wantQuotesToExist, DB.db.Migrator().HasTable("quotes"))
+		})
+	}
+}
+
+// Unit тест для функции MigrationStatus
+func TestUnitMigrationStatus(t *testing.T) {
+	cases := []struct {
+		name        string
+		migrate     bool
+		wantApplied bool
+	}{
+		{
+			name:        "general case",
+			migrate:     true,
+			wantApplied: true,
+		},
+		{
+			name:        "empty schema case",
+			migrate:     false,
+			wantApplied: false,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			DB := setupTestDB(true)
+			defer DB.TeardownDB()
+
+			if cs.migrate {
+				DB.MigrateUp(context.Background())
+			}
+
+			gotStatus, gotErr := DB.MigrationStatus(context.Background())
+			if assert.Nil(t, gotErr) && assert.NotEmpty(t, gotStatus) {
+				for _, s := range gotStatus {
+					assert.Equal(t, cs.wantApplied, s.Applied)
+					assert.Equal(t, cs.wantApplied, !s.AppliedAt.IsZero())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,4 @@
+CREATE TABLE IF NOT EXISTS `quotes` (
+    `id` BIGINT NOT NULL PRIMARY KEY,
+    `quote` VARCHAR NOT NULL
+);
@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS `quotes_set_updated_at`;
+DROP TRIGGER IF EXISTS `quotes_set_created_at`;
+
+ALTER TABLE `quotes` DROP COLUMN `updated_at`;
+ALTER TABLE `quotes` DROP COLUMN `created_at`;
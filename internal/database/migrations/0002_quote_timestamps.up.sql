@@ -0,0 +1,16 @@
+ALTER TABLE `quotes` ADD COLUMN `created_at` DATETIME;
+ALTER TABLE `quotes` ADD COLUMN `updated_at` DATETIME;
+
+UPDATE `quotes` SET `created_at` = CURRENT_TIMESTAMP, `updated_at` = CURRENT_TIMESTAMP;
+
+CREATE TRIGGER IF NOT EXISTS `quotes_set_created_at` AFTER INSERT ON `quotes`
+WHEN NEW.`created_at` IS NULL
+BEGIN
+    UPDATE `quotes` SET `created_at` = CURRENT_TIMESTAMP, `updated_at` = CURRENT_TIMESTAMP WHERE `id` = NEW.`id`;
+END;
+
+CREATE TRIGGER IF NOT EXISTS `quotes_set_updated_at` AFTER UPDATE OF `quote` ON `quotes`
+WHEN NEW.`quote` IS NOT OLD.`quote`
+BEGIN
+    UPDATE `quotes` SET `updated_at` = CURRENT_TIMESTAMP WHERE `id` = NEW.`id`;
+END;
@@ -0,0 +1,142 @@
+package database
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"github.com/xoticdsign/returnauf/models/responses"
+)
+
+// Канонический набор цитат, по одной JSON-записи на строку
+//
+//go:embed seed/quotes.ndjson
+var seedFile []byte
+
+// Структура для возврата результата сверки БД с набором цитат
+type SeedResult struct {
+	Inserted  int
+	Updated   int
+	Unchanged int
+	Deleted   int
+}
+
+// Разбирает встроенный набор цитат
+func SeedQuotes() ([]responses.Quote, error) {
+	var quotes []responses.Quote
+
+	seen := map[int]bool{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(seedFile))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for line := 1; scanner.Scan(); line++ {
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+
+		var quote responses.Quote
+
+		err := json.Unmarshal(raw, &quote)
+		if err != nil {
+			return nil, fmt.Errorf("seed line %d: %w", line, err)
+		}
+		if quote.ID <= 0 || quote.Quote == "" {
+			return nil, fmt.Errorf("seed line %d: id and quote are required", line)
+		}
+		if seen[quote.ID] {
+			return nil, fmt.Errorf("seed line %d: duplicate id %d", line, quote.ID)
+		}
+		seen[quote.ID] = true
+
+		quotes = append(quotes, quote)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return quotes, nil
+}
+
+// Сверяет таблицу цитат со встроенным набором: добавляет отсутствующие цитаты и
+// обновляет измененные. Если prune установлен, удаляет цитаты, которых нет в наборе
+func (d *DB) Seed(ctx context.Context, prune bool) (SeedResult, error) {
+	var result SeedResult
+
+	quotes, err := SeedQuotes()
+	if err != nil {
+		return result, err
+	}
+
+	err = d.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing []responses.Quote
+
+		err := tx.Table("quotes").Find(&existing).Error
+		if err != nil {
+			return err
+		}
+
+		current := make(map[int]string, len(existing))
+		for _, quote := range existing {
+			current[quote.ID] = quote.Quote
+		}
+
+		ids := make([]int, 0, len(quotes))
+
+		for _, quote := range quotes {
+			ids = append(ids, quote.ID)
+
+			text, ok := current[quote.ID]
+			switch {
+			case !ok:
+				err = tx.Table("quotes").Create(&quote).Error
+				result.Inserted++
+			case text != quote.Quote:
+				err = tx.Table("quotes").Where("id=?", quote.ID).Update("quote", quote.Quote).Error
+				result.Updated++
+			default:
+				result.Unchanged++
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		if prune {
+			res := tx.Table("quotes").Where("id NOT IN ?", ids).Delete(&responses.Quote{})
+			if res.Error != nil {
+				return res.Error
+			}
+			result.Deleted = int(res.RowsAffected)
+		}
+		return nil
+	})
+	if err != nil {
+		return SeedResult{}, err
+	}
+	return result, nil
+}
+
+// Заполняет таблицу цитат встроенным набором, если она пуста
+func (d *DB) SeedIfEmpty(ctx context.Context) (bool, error) {
+	var count int64
+
+	err := d.db.WithContext(ctx).Table("quotes").Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return false, nil
+	}
+
+	_, err = d.Seed(ctx, false)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,144 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/xoticdsign/returnauf/models/responses"
+)
+
+// Unit тест для функции SeedQuotes
+func TestUnitSeedQuotes(t *testing.T) {
+	quotes, err := SeedQuotes()
+	if assert.Nil(t, err) && assert.NotEmpty(t, quotes) {
+		for _, quote := range quotes {
+			assert.Greater(t, quote.ID, 0)
+			assert.NotEmpty(t, quote.Quote)
+		}
+	}
+}
+
+// Unit тест для функции Seed
+func TestUnitSeed(t *testing.T) {
+	seed, _ := SeedQuotes()
+
+	cases := []struct {
+		name       string
+		prepare    func(d *DB)
+		prune      bool
+		wantResult SeedResult
+		wantCount  int
+	}{
+		{
+			name:    "empty db case",
+			prepare: func(d *DB) {},
+			prune:   false,
+			wantResult: SeedResult{
+				Inserted: len(seed),
+			},
+			wantCount: len(seed),
+		},
+		{
+			name: "reseed case",
+			prepare: func(d *DB) {
+				d.Seed(context.Background(), false)
+			},
+			prune: false,
+			wantResult: SeedResult{
+				Unchanged: len(seed),
+			},
+			wantCount: len(seed),
+		},
+		{
+			name: "changed quote case",
+			prepare: func(d *DB) {
+				d.Seed(context.Background(), false)
+				d.db.Table("quotes").Where("id=?", seed[0].ID).Update("quote", "changed")
+			},
+			prune: false,
+			wantResult: SeedResult{
+				Updated:   1,
+				Unchanged: len(seed) - 1,
+			},
+			wantCount: len(seed),
+		},
+		{
+			name: "extra quote kept case",
+			prepare: func(d *DB) {
+				d.db.Table("quotes").Create(&responses.Quote{ID: 1000000, Quote: "extra"})
+			},
+			prune: false,
+			wantResult: SeedResult{
+				Inserted: len(seed),
+			},
+			wantCount: len(seed) + 1,
+		},
+		{
+			name: "extra quote pruned case",
+			prepare: func(d *DB) {
+				d.db.Table("quotes").Create(&responses.Quote{ID: 1000000, Quote: "extra"})
+			},
+			prune: true,
+			wantResult: SeedResult{
+				Inserted: len(seed),
+				Deleted:  1,
+			},
+			wantCount: len(seed),
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			DB := setupTestDB(true)
+			defer DB.TeardownDB()
+
+			DB.MigrateUp(context.Background())
+			cs.prepare(DB)
+
+			gotResult, gotErr := DB.Seed(context.Background(), cs.prune)
+
+			assert.Nil(t, gotErr)
+			assert.Equal(t, cs.wantResult, gotResult)
+
+			gotCount, _ := DB.QuotesCount(context.Background())
+
+			assert.Equal(t, cs.wantCount, gotCount)
+		})
+	}
+}
+
+// Unit тест для функции SeedIfEmpty
+func TestUnitSeedIfEmpty(t *testing.T) {
+	cases := []struct {
+		name       string
+		emptyDB    bool
+		wantSeeded bool
+	}{
+		{
+			name:       "empty db case",
+			emptyDB:    true,
+			wantSeeded: true,
+		},
+		{
+			name:       "populated db case",
+			emptyDB:    false,
+			wantSeeded: false,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			DB := setupTestDB(cs.emptyDB)
+			defer DB.TeardownDB()
+
+			DB.MigrateUp(context.Background())
+
+			gotSeeded, gotErr := DB.SeedIfEmpty(context.Background())
+
+			assert.Nil(t, gotErr)
+			assert.Equal(t, cs.wantSeeded, gotSeeded)
+		})
+	}
+}
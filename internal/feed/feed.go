@@ -0,0 +1,252 @@
+package feed
+
+import (
+	"encoding/xml"
+	"hash/fnv"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/xoticdsign/returnauf/models/responses"
+)
+
+// Поддерживаемые форматы лент
+const (
+	FormatRSS  = "rss"
+	FormatAtom = "atom"
+)
+
+// Content-Type для каждого формата ленты
+var ContentTypes = map[string]string{
+	FormatRSS:  "application/rss+xml; charset=utf-8",
+	FormatAtom: "application/atom+xml; charset=utf-8",
+}
+
+// Максимальная длина заголовка записи в символах
+const titleLength = 60
+
+// Структура ленты, общая для RSS и Atom
+type Feed struct {
+	ID          string
+	Title       string
+	Description string
+	Link        string
+	Self        string
+	Updated     time.Time
+	Items       []Item
+}
+
+// Структура записи ленты
+type Item struct {
+	GUID      string
+	Title     string
+	Link      string
+	Content   string
+	Published time.Time
+	Updated   time.Time
+}
+
+// Структура цитаты дня
+type Daily struct {
+	Day   time.Time
+	Quote responses.Quote
+}
+
+// Возвращает стабильный идентификатор записи цитаты. Не зависит от адреса сервера,
+// поэтому читатели не дублируют записи при переезде
+func QuoteGUID(id int) string {
+	return "urn:returnauf:quote:" + strconv.Itoa(id)
+}
+
+// Возвращает стабильный идентификатор записи цитаты дня
+func DailyGUID(day time.Time) string {
+	return "urn:returnauf:daily:" + day.Format(time.DateOnly)
+}
+
+// Сокращает текст цитаты до заголовка записи
+func Title(quote string) string {
+	if utf8.RuneCountInString(quote) <= titleLength {
+		return quote
+	}
+
+	runes := []rune(quote)
+
+	return string(runes[:titleLength-1]) + "…"
+}
+
+// Выбирает цитату на каждый из последних days дней, начиная с сегодняшнего. Выбор
+// зависит только от даты и набора ID, поэтому одинаков на всех экземплярах сервиса
+func DailyQuotes(quotes []responses.Quote, now time.Time, days int) []Daily {
+	if len(quotes) == 0 {
+		return nil
+	}
+
+	sorted := make([]responses.Quote, len(quotes))
+	copy(sorted, quotes)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	today := now.UTC().Truncate(24 * time.Hour)
+
+	daily := make([]Daily, days)
+	for i := range daily {
+		day := today.AddDate(0, 0, -i)
+
+		h := fnv.New32a()
+		h.Write([]byte(day.Format(time.DateOnly)))
+
+		daily[i] = Daily{
+			Day:   day,
+			Quote: sorted[h.Sum32()%uint32(len(sorted))],
+		}
+	}
+	return daily
+}
+
+// Формирует ленту в формате RSS 2.0
+func RSS(f Feed) ([]byte, error) {
+	channel := rssChannel{
+		Title:         f.Title,
+		Link:          f.Link,
+		Description:   f.Description,
+		LastBuildDate: f.Updated.UTC().Format(time.RFC1123Z),
+		AtomLink:      rssAtomLink{Href: f.Self, Rel: "self", Type: ContentTypes[FormatRSS]},
+	}
+
+	for _, item := range f.Items {
+		channel.Items = append(channel.Items, rssItem{
+			Title:       item.Title,
+			Link:        item.Link,
+			Description: item.Content,
+			GUID:        rssGUID{Value: item.GUID, IsPermaLink: "false"},
+			PubDate:     item.Published.UTC().Format(time.RFC1123Z),
+		})
+	}
+
+	return marshal(rss{Version: "2.0", AtomNS: "http://www.w3.org/2005/Atom", Channel: channel})
+}
+
+// Формирует ленту в формате Atom 1.0
+func Atom(f Feed) ([]byte, error) {
+	feed := atomFeed{
+		NS:       "http://www.w3.org/2005/Atom",
+		ID:       f.ID,
+		Title:    f.Title,
+		Subtitle: f.Description,
+		Updated:  f.Updated.UTC().Format(time.RFC3339),
+		Author:   atomAuthor{Name: "returnauf"},
+		Links: []atomLink{
+			{Href: f.Link, Rel: "alternate"},
+			{Href: f.Self, Rel: "self", Type: ContentTypes[FormatAtom]},
+		},
+	}
+
+	for _, item := range f.Items {
+		feed.Entries = append(feed.Entries, atomEntry{
+			ID:        item.GUID,
+			Title:     item.Title,
+			Link:      atomLink{Href: item.Link, Rel: "alternate"},
+			Content:   atomContent{Type: "text", Value: item.Content},
+			Published: item.Published.UTC().Format(time.RFC3339),
+			Updated:   item.Updated.UTC().Format(time.RFC3339),
+		})
+	}
+
+	return marshal(feed)
+}
+
+// Форматирует время для заголовка Last-Modified
+func LastModified(t time.Time) string {
+	return t.UTC().Format(http.TimeFormat)
+}
+
+// Сериализует ленту в XML с объявлением
+func marshal(v interface{}) ([]byte, error) {
+	b, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), b...), nil
+}
+
+// Корневой элемент RSS 2.0
+type rss struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	AtomNS  string     `xml:"xmlns:atom,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+// Канал RSS
+type rssChannel struct {
+	Title         string      `xml:"title"`
+	Link          string      `xml:"link"`
+	Description   string      `xml:"description"`
+	LastBuildDate string      `xml:"lastBuildDate"`
+	AtomLink      rssAtomLink `xml:"atom:link"`
+	Items         []rssItem   `xml:"item"`
+}
+
+// Ссылка RSS на саму ленту из пространства имен Atom
+type rssAtomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+// Запись RSS
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	Description string  `xml:"description"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+}
+
+// Идентификатор записи RSS
+type rssGUID struct {
+	Value       string `xml:",chardata"`
+	IsPermaLink string `xml:"isPermaLink,attr"`
+}
+
+// Корневой элемент Atom 1.0
+type atomFeed struct {
+	XMLName  xml.Name    `xml:"feed"`
+	NS       string      `xml:"xmlns,attr"`
+	ID       string      `xml:"id"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	Updated  string      `xml:"updated"`
+	Author   atomAuthor  `xml:"author"`
+	Links    []atomLink  `xml:"link"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+// Автор ленты Atom
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+// Ссылка Atom
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+// Содержимое записи Atom
+type atomContent struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+// Запись Atom
+type atomEntry struct {
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Link      atomLink    `xml:"link"`
+	Content   atomContent `xml:"content"`
+	Published string      `xml:"published"`
+	Updated   string      `xml:"updated"`
+}
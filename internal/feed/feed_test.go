@@ -0,0 +1,113 @@
+package feed
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/xoticdsign/returnauf/models/responses"
+)
+
+// Лента для тестов
+var testFeed = Feed{
+	ID:          "urn:returnauf:feed:test",
+	Title:       "Test feed",
+	Description: "Test description",
+	Link:        "http://example.com/",
+	Self:        "http://example.com/feed.rss",
+	Updated:     time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	Items: []Item{
+		{
+			GUID:      QuoteGUID(1),
+			Title:     "Mock quote 1",
+			Link:      "http://example.com/1",
+			Content:   "Mock quote 1 & <more>",
+			Published: time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC),
+			Updated:   time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		},
+	},
+}
+
+// Unit тест для функции Title
+func TestUnitTitle(t *testing.T) {
+	cases := []struct {
+		name  string
+		quote string
+		want  string
+	}{
+		{
+			name:  "short case",
+			quote: "Короткая цитата",
+			want:  "Короткая цитата",
+		},
+		{
+			name:  "long case",
+			quote: strings.Repeat("ж", titleLength+10),
+			want:  strings.Repeat("ж", titleLength-1) + "…",
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			assert.Equal(t, cs.want, Title(cs.quote))
+		})
+	}
+}
+
+// Unit тест для функции DailyQuotes
+func TestUnitDailyQuotes(t *testing.T) {
+	now := time.Date(2024, 5, 1, 15, 30, 0, 0, time.UTC)
+
+	reversed := []responses.Quote{responses.TestQuotes[2], responses.TestQuotes[1], responses.TestQuotes[0]}
+
+	got := DailyQuotes(responses.TestQuotes, now, 3)
+
+	assert.Len(t, got, 3)
+	assert.Equal(t, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), got[0].Day)
+	assert.Equal(t, time.Date(2024, 4, 29, 0, 0, 0, 0, time.UTC), got[2].Day)
+	assert.Equal(t, got, DailyQuotes(reversed, now.Add(time.Hour), 3))
+	assert.Nil(t, DailyQuotes(nil, now, 3))
+}
+
+// Unit тест для функции RSS
+func TestUnitRSS(t *testing.T) {
+	body, err := RSS(testFeed)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	var got rss
+
+	err = xml.Unmarshal(body, &got)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "2.0", got.Version)
+		assert.Equal(t, "Wed, 01 May 2024 12:00:00 +0000", got.Channel.LastBuildDate)
+		assert.Len(t, got.Channel.Items, 1)
+		assert.Equal(t, "urn:returnauf:quote:1", got.Channel.Items[0].GUID.Value)
+		assert.Equal(t, "false", got.Channel.Items[0].GUID.IsPermaLink)
+		assert.Equal(t, "Mock quote 1 & <more>", got.Channel.Items[0].Description)
+	}
+}
+
+// Unit тест для функции Atom
+func TestUnitAtom(t *testing.T) {
+	body, err := Atom(testFeed)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	var got atomFeed
+
+	err = xml.Unmarshal(body, &got)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "urn:returnauf:feed:test", got.ID)
+		assert.Equal(t, "2024-05-01T12:00:00Z", got.Updated)
+		assert.Len(t, got.Entries, 1)
+		assert.Equal(t, "urn:returnauf:quote:1", got.Entries[0].ID)
+		assert.Equal(t, "2024-04-30T00:00:00Z", got.Entries[0].Published)
+		assert.Equal(t, "2024-05-01T12:00:00Z", got.Entries[0].Updated)
+	}
+}
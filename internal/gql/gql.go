@@ -0,0 +1,123 @@
+package gql
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+
+	"github.com/xoticdsign/returnauf/config"
+	"github.com/xoticdsign/returnauf/internal/database"
+	"github.com/xoticdsign/returnauf/models/responses"
+)
+
+// Ошибка пустого запроса
+var ErrEmptyQuery = errors.New("query is required")
+
+// Интерфейс источника данных для резолверов. Реализуется поверх Кэша и БД в хендлерах
+type Source interface {
+	QuotesByID(ctx context.Context, ids []int) (map[int]responses.Quote, error)
+	CountQuotes(ctx context.Context, filter database.Filter) (int, error)
+	PageQuotes(ctx context.Context, filter database.Filter, offset int, limit int) ([]responses.Quote, error)
+	RandInt(count int) int
+}
+
+// Структура запроса GraphQL по спецификации GraphQL over HTTP
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// Структура, содержащая схему и ограничения запросов
+type Server struct {
+	schema graphql.Schema
+	conf   atomic.Pointer[config.GraphQL]
+}
+
+// Ключ контекста, под которым резолверам передается состояние запроса
+type requestKey struct{}
+
+// Состояние одного запроса: источник данных и загрузчик цитат по ID
+type request struct {
+	src    Source
+	loader *loader
+}
+
+// Создает сервер GraphQL. Нулевые ограничения заменяются значениями по умолчанию
+func New(conf config.GraphQL) (*Server, error) {
+	schema, err := newSchema()
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{schema: schema}
+	s.SetConfig(conf)
+
+	return s, nil
+}
+
+// Заменяет ограничения запросов и настройку GraphiQL. Безопасно вызывать во время
+// выполнения запросов
+func (s *Server) SetConfig(conf config.GraphQL) {
+	if conf.MaxDepth <= 0 {
+		conf.MaxDepth = config.DefaultGraphQLMaxDepth
+	}
+	if conf.MaxComplexity <= 0 {
+		conf.MaxComplexity = config.DefaultGraphQLMaxComplexity
+	}
+	s.conf.Store(&conf)
+}
+
+// Сообщает, нужно ли отдавать GraphiQL
+func (s *Server) GraphiQL() bool {
+	return s.conf.Load().GraphiQL
+}
+
+// Выполняет запрос. Второе значение ложно, если запрос отклонен до выполнения: не
+// разобран, не прошел валидацию или превысил ограничения глубины и сложности
+func (s *Server) Execute(ctx context.Context, src Source, req Request) (*graphql.Result, bool) {
+	if strings.TrimSpace(req.Query) == "" {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(ErrEmptyQuery)}, false
+	}
+
+	doc, err := parser.Parse(parser.ParseParams{
+		Source: source.NewSource(&source.Source{Body: []byte(req.Query), Name: "GraphQL request"}),
+	})
+	if err != nil {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}, false
+	}
+
+	validation := graphql.ValidateDocument(&s.schema, doc, nil)
+	if !validation.IsValid {
+		return &graphql.Result{Errors: validation.Errors}, false
+	}
+
+	err = checkLimits(doc, req, *s.conf.Load())
+	if err != nil {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}, false
+	}
+
+	ctx = context.WithValue(ctx, requestKey{}, &request{
+		src:    src,
+		loader: newLoader(src),
+	})
+
+	return graphql.Execute(graphql.ExecuteParams{
+		Schema:        s.schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       ctx,
+	}), true
+}
+
+// Возвращает состояние запроса из контекста резолвера
+func fromContext(ctx context.Context) *request {
+	return ctx.Value(requestKey{}).(*request)
+}
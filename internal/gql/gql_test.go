@@ -0,0 +1,249 @@
+package gql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/xoticdsign/returnauf/config"
+	"github.com/xoticdsign/returnauf/internal/database"
+	"github.com/xoticdsign/returnauf/models/responses"
+)
+
+// Проверяет, подходит ли цитата под фильтр, так же, как условия запроса к БД
+func matchFilter(filter database.Filter, quote responses.Quote) bool {
+	if filter.FromID > 0 && quote.ID < filter.FromID {
+		return false
+	}
+	if filter.ToID > 0 && quote.ID > filter.ToID {
+		return false
+	}
+	if filter.Contains != "" && !strings.Contains(quote.Quote, filter.Contains) {
+		return false
+	}
+	return true
+}
+
+// Источник данных для тестов, запоминающий пакетные обращения
+type stubSource struct {
+	quotes  []responses.Quote
+	batches [][]int
+	err     error
+}
+
+// Имитация метода QuotesByID
+func (s *stubSource) QuotesByID(ctx context.Context, ids []int) (map[int]responses.Quote, error) {
+	s.batches = append(s.batches, ids)
+
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	found := map[int]responses.Quote{}
+	for _, quote := range s.quotes {
+		for _, id := range ids {
+			if quote.ID == id {
+				found[id] = quote
+			}
+		}
+	}
+	return found, nil
+}
+
+// Возвращает цитаты, подходящие под фильтр
+func (s *stubSource) matched(filter database.Filter) []responses.Quote {
+	quotes := []responses.Quote{}
+	for _, quote := range s.quotes {
+		if matchFilter(filter, quote) {
+			quotes = append(quotes, quote)
+		}
+	}
+	return quotes
+}
+
+// Имитация метода CountQuotes
+func (s *stubSource) CountQuotes(ctx context.Context, filter database.Filter) (int, error) {
+	if s.err != nil {
+		return 0, s.err
+	}
+	return len(s.matched(filter)), nil
+}
+
+// Имитация метода PageQuotes
+func (s *stubSource) PageQuotes(ctx context.Context, filter database.Filter, offset int, limit int) ([]responses.Quote, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	quotes := s.matched(filter)
+	if offset > len(quotes) {
+		offset = len(quotes)
+	}
+	return quotes[offset:min(offset+limit, len(quotes))], nil
+}
+
+// Имитация метода RandInt
+func (s *stubSource) RandInt(count int) int {
+	return count - 1
+}
+
+// Unit тест для функции Execute
+func TestUnitExecute(t *testing.T) {
+	cases := []struct {
+		name         string
+		req          Request
+		sourceErr    error
+		wantExecuted bool
+		wantData     string
+		wantErr      bool
+		wantBatches  [][]int
+	}{
+		{
+			name:         "quote case",
+			req:          Request{Query: `{ quote(id: 2) { id quote } }`},
+			wantExecuted: true,
+			wantData:     `{"quote":{"id":2,"quote":"Mock quote 2"}}`,
+			wantBatches:  [][]int{{2}},
+		},
+		{
+			name:         "batched quotes case",
+			req:          Request{Query: `{ a: quote(id: 1) { quote } b: quote(id: 3) { quote } c: quote(id: 999) { quote } }`},
+			wantExecuted: true,
+			wantData:     `{"a":{"quote":"Mock quote 1"},"b":{"quote":"Mock quote 3"},"c":null}`,
+			wantBatches:  [][]int{{1, 3, 999}},
+		},
+		{
+			name:         "quotes page case",
+			req:          Request{Query: `query($page: Page) { quotes(filter: {fromId: 2}, page: $page) { total offset limit items { id } } }`, Variables: map[string]interface{}{"page": map[string]interface{}{"offset": 1, "limit": 1}}},
+			wantExecuted: true,
+			wantData:     `{"quotes":{"total":2,"offset":1,"limit":1,"items":[{"id":3}]}}`,
+		},
+		{
+			name:         "random quote case",
+			req:          Request{Query: `{ randomQuote(filter: {toId: 2}) { id } }`},
+			wantExecuted: true,
+			wantData:     `{"randomQuote":{"id":2}}`,
+		},
+		{
+			name:         "search case",
+			req:          Request{Query: `{ search(q: "quote", limit: 2) { id } }`},
+			wantExecuted: true,
+			wantData:     `{"search":[{"id":1},{"id":2}]}`,
+		},
+		{
+			name:         "source error case",
+			req:          Request{Query: `{ quote(id: 1) { id } }`},
+			sourceErr:    errors.New("error"),
+			wantExecuted: true,
+			wantData:     `{"quote":null}`,
+			wantErr:      true,
+			wantBatches:  [][]int{{1}},
+		},
+		{
+			name:         "empty query case",
+			req:          Request{},
+			wantExecuted: false,
+			wantErr:      true,
+		},
+		{
+			name:         "syntax error case",
+			req:          Request{Query: `{ quote(id: 1) { id }`},
+			wantExecuted: false,
+			wantErr:      true,
+		},
+		{
+			name:         "unknown field case",
+			req:          Request{Query: `{ quote(id: 1) { author } }`},
+			wantExecuted: false,
+			wantErr:      true,
+		},
+		{
+			name:         "complexity limit case",
+			req:          Request{Query: `{ quotes(page: {limit: 100}) { items { id quote } } search(q: "a", limit: 100) { id quote } }`},
+			wantExecuted: false,
+			wantErr:      true,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			server, err := New(config.GraphQL{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			src := &stubSource{quotes: responses.TestQuotes, err: cs.sourceErr}
+
+			result, executed := server.Execute(context.Background(), src, cs.req)
+
+			assert.Equal(t, cs.wantExecuted, executed)
+			assert.Equal(t, cs.wantErr, result.HasErrors())
+			assert.Equal(t, cs.wantBatches, src.batches)
+
+			if cs.wantData != "" {
+				gotData, _ := json.Marshal(result.Data)
+
+				assert.JSONEq(t, cs.wantData, string(gotData))
+			}
+		})
+	}
+}
+
+// Unit тест для функции checkLimits
+func TestUnitCheckLimits(t *testing.T) {
+	cases := []struct {
+		name    string
+		conf    config.GraphQL
+		req     Request
+		wantErr bool
+	}{
+		{
+			name:    "within limits case",
+			conf:    config.GraphQL{MaxDepth: 3, MaxComplexity: 50},
+			req:     Request{Query: `{ quotes(page: {limit: 10}) { items { id quote } } }`},
+			wantErr: false,
+		},
+		{
+			name:    "depth exceeded case",
+			conf:    config.GraphQL{MaxDepth: 2, MaxComplexity: 1000},
+			req:     Request{Query: `{ quotes { items { id } } }`},
+			wantErr: true,
+		},
+		{
+			name:    "variable limit case",
+			conf:    config.GraphQL{MaxDepth: 5, MaxComplexity: 50},
+			req:     Request{Query: `query($n: Int) { search(q: "a", limit: $n) { id quote } }`, Variables: map[string]interface{}{"n": float64(30)}},
+			wantErr: true,
+		},
+		{
+			name:    "fragment case",
+			conf:    config.GraphQL{MaxDepth: 2, MaxComplexity: 1000},
+			req:     Request{Query: `{ ...F } fragment F on Query { quotes { items { id } } }`},
+			wantErr: true,
+		},
+		{
+			name:    "introspection case",
+			conf:    config.GraphQL{MaxDepth: 1, MaxComplexity: 1},
+			req:     Request{Query: `{ __schema { types { name fields { name type { ofType { ofType { name } } } } } } }`},
+			wantErr: false,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			server, err := New(cs.conf)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, executed := server.Execute(context.Background(), &stubSource{quotes: responses.TestQuotes}, cs.req)
+
+			assert.Equal(t, !cs.wantErr, executed)
+			assert.Equal(t, cs.wantErr, result.HasErrors())
+		})
+	}
+}
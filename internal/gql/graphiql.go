@@ -0,0 +1,26 @@
+package gql
+
+// Страница GraphiQL для разработки. Ключ API берется из параметра returnauf-key адреса
+// страницы и передается в каждый запрос
+const GraphiQLPage = `<!DOCTYPE html>
+<html lang="ru">
+<head>
+  <meta charset="utf-8">
+  <title>returnauf GraphiQL</title>
+  <style>body { margin: 0; height: 100vh; } #graphiql { height: 100vh; }</style>
+  <link rel="stylesheet" href="https://unpkg.com/graphiql@3/graphiql.min.css">
+  <script crossorigin src="https://unpkg.com/react@18/umd/react.production.min.js"></script>
+  <script crossorigin src="https://unpkg.com/react-dom@18/umd/react-dom.production.min.js"></script>
+  <script crossorigin src="https://unpkg.com/graphiql@3/graphiql.min.js"></script>
+</head>
+<body>
+  <div id="graphiql"></div>
+  <script>
+    const key = new URLSearchParams(window.location.search).get('returnauf-key');
+    const url = window.location.pathname + (key ? '?returnauf-key=' + encodeURIComponent(key) : '');
+    const fetcher = GraphiQL.createFetcher({ url: url });
+    ReactDOM.createRoot(document.getElementById('graphiql')).render(React.createElement(GraphiQL, { fetcher: fetcher }));
+  </script>
+</body>
+</html>
+`
@@ -0,0 +1,159 @@
+package gql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql/language/ast"
+
+	"github.com/xoticdsign/returnauf/config"
+)
+
+// Поля, возвращающие списки, и путь к аргументу, задающему размер списка. Сложность
+// вложенных полей таких полей умножается на размер списка
+var listFields = map[string][]string{
+	"quotes": {"page", "limit"},
+	"search": {"limit"},
+}
+
+// Измеритель глубины и сложности запроса
+type measurer struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+	visiting  map[string]bool
+}
+
+// Проверяет, что запрос не превышает ограничения глубины и сложности. Сложность
+// считается как число запрошенных полей с учетом размеров списков. Поля интроспекции
+// не учитываются, чтобы работал GraphiQL
+func checkLimits(doc *ast.Document, req Request, conf config.GraphQL) error {
+	m := &measurer{
+		fragments: map[string]*ast.FragmentDefinition{},
+		variables: req.Variables,
+		visiting:  map[string]bool{},
+	}
+
+	var operations []*ast.OperationDefinition
+
+	for _, definition := range doc.Definitions {
+		switch definition := definition.(type) {
+		case *ast.FragmentDefinition:
+			m.fragments[definition.Name.Value] = definition
+		case *ast.OperationDefinition:
+			if req.OperationName == "" || (definition.Name != nil && definition.Name.Value == req.OperationName) {
+				operations = append(operations, definition)
+			}
+		}
+	}
+
+	for _, operation := range operations {
+		depth, complexity := m.selectionSet(operation.SelectionSet, 1)
+
+		if depth > conf.MaxDepth {
+			return fmt.Errorf("query depth %d exceeds the limit of %d", depth, conf.MaxDepth)
+		}
+		if complexity > conf.MaxComplexity {
+			return fmt.Errorf("query complexity %d exceeds the limit of %d", complexity, conf.MaxComplexity)
+		}
+	}
+	return nil
+}
+
+// Возвращает максимальную глубину и суммарную сложность набора полей
+func (m *measurer) selectionSet(set *ast.SelectionSet, depth int) (int, int) {
+	if set == nil {
+		return 0, 0
+	}
+
+	maxDepth, complexity := 0, 0
+
+	for _, selection := range set.Selections {
+		var d, c int
+
+		switch selection := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(selection.Name.Value, "__") {
+				continue
+			}
+
+			d, c = depth, 1
+			if selection.SelectionSet != nil {
+				subDepth, subComplexity := m.selectionSet(selection.SelectionSet, depth+1)
+
+				d = max(d, subDepth)
+				c += subComplexity * m.multiplier(selection)
+			}
+
+		case *ast.FragmentSpread:
+			name := selection.Name.Value
+
+			fragment, ok := m.fragments[name]
+			if !ok || m.visiting[name] {
+				continue
+			}
+
+			m.visiting[name] = true
+			d, c = m.selectionSet(fragment.SelectionSet, depth)
+			m.visiting[name] = false
+
+		case *ast.InlineFragment:
+			d, c = m.selectionSet(selection.SelectionSet, depth)
+		}
+
+		maxDepth = max(maxDepth, d)
+		complexity += c
+	}
+	return maxDepth, complexity
+}
+
+// Возвращает размер списка, который вернет поле, или 1 для остальных полей
+func (m *measurer) multiplier(field *ast.Field) int {
+	path, ok := listFields[field.Name.Value]
+	if !ok {
+		return 1
+	}
+
+	var value interface{}
+
+	for _, argument := range field.Arguments {
+		if argument.Name.Value == path[0] {
+			value = m.value(argument.Value)
+		}
+	}
+	for _, key := range path[1:] {
+		object, _ := value.(map[string]interface{})
+		value = object[key]
+	}
+
+	switch v := value.(type) {
+	case int:
+		return max(v, 1)
+	case float64:
+		return max(int(v), 1)
+	}
+	return defaultPageLimit
+}
+
+// Преобразует значение аргумента из AST, подставляя переменные
+func (m *measurer) value(v ast.Value) interface{} {
+	switch v := v.(type) {
+	case *ast.IntValue:
+		i, err := strconv.Atoi(v.Value)
+		if err != nil {
+			return nil
+		}
+		return i
+
+	case *ast.Variable:
+		return m.variables[v.Name.Value]
+
+	case *ast.ObjectValue:
+		object := map[string]interface{}{}
+		for _, field := range v.Fields {
+			object[field.Name.Value] = m.value(field.Value)
+		}
+		return object
+	}
+	return nil
+}
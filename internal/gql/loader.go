@@ -0,0 +1,86 @@
+package gql
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/xoticdsign/returnauf/models/responses"
+)
+
+// Загрузчик цитат по ID в духе dataloader. Резолверы регистрируют ID и возвращают
+// отложенные функции; первая вызванная функция загружает все накопленные ID одним
+// обращением к источнику. Живет в пределах одного запроса
+type loader struct {
+	src Source
+
+	mu      sync.Mutex
+	pending []int
+	queued  map[int]bool
+	results map[int]responses.Quote
+	errs    map[int]error
+}
+
+// Создает загрузчик поверх источника
+func newLoader(src Source) *loader {
+	return &loader{
+		src:     src,
+		queued:  map[int]bool{},
+		results: map[int]responses.Quote{},
+		errs:    map[int]error{},
+	}
+}
+
+// Регистрирует ID и возвращает отложенную функцию, возвращающую цитату или nil,
+// если цитаты нет
+func (l *loader) load(ctx context.Context, id int) func() (interface{}, error) {
+	l.mu.Lock()
+	if !l.queued[id] {
+		l.queued[id] = true
+		l.pending = append(l.pending, id)
+	}
+	l.mu.Unlock()
+
+	return func() (interface{}, error) {
+		l.flush(ctx)
+
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		if err := l.errs[id]; err != nil {
+			return nil, err
+		}
+		quote, ok := l.results[id]
+		if !ok {
+			return nil, nil
+		}
+		return quote, nil
+	}
+}
+
+// Загружает все накопленные ID одним обращением к источнику. ID сортируются, чтобы
+// обращение не зависело от порядка вычисления полей
+func (l *loader) flush(ctx context.Context) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.pending) == 0 {
+		return
+	}
+
+	ids := l.pending
+	l.pending = nil
+
+	sort.Ints(ids)
+
+	quotes, err := l.src.QuotesByID(ctx, ids)
+	for _, id := range ids {
+		if err != nil {
+			l.errs[id] = err
+			continue
+		}
+		if quote, ok := quotes[id]; ok {
+			l.results[id] = quote
+		}
+	}
+}
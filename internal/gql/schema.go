@@ -0,0 +1,217 @@
+package gql
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+
+	"github.com/xoticdsign/returnauf/internal/database"
+	"github.com/xoticdsign/returnauf/models/responses"
+)
+
+// Ограничения размера страницы
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// Собирает схему GraphQL над цитатами
+func newSchema() (graphql.Schema, error) {
+	quoteType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Quote",
+		Description: "Цитата",
+		Fields: graphql.Fields{
+			"id": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Int),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(responses.Quote).ID, nil
+				},
+			},
+			"quote": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(responses.Quote).Quote, nil
+				},
+			},
+		},
+	})
+
+	filterInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name:        "QuoteFilter",
+		Description: "Фильтры цитат, те же, что у списка цитат в REST API",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"fromId":   &graphql.InputObjectFieldConfig{Type: graphql.Int},
+			"toId":     &graphql.InputObjectFieldConfig{Type: graphql.Int},
+			"contains": &graphql.InputObjectFieldConfig{Type: graphql.String},
+		},
+	})
+
+	pageInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name:        "Page",
+		Description: "Параметры страницы",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"offset": &graphql.InputObjectFieldConfig{Type: graphql.Int, DefaultValue: 0},
+			"limit":  &graphql.InputObjectFieldConfig{Type: graphql.Int, DefaultValue: defaultPageLimit},
+		},
+	})
+
+	pageType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "QuotePage",
+		Description: "Страница цитат",
+		Fields: graphql.Fields{
+			"items":  &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(quoteType)))},
+			"total":  &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"offset": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"limit":  &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		},
+	})
+
+	queryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"quote": &graphql.Field{
+				Type:        quoteType,
+				Description: "Цитата по ID",
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+				},
+				Resolve: resolveQuote,
+			},
+			"quotes": &graphql.Field{
+				Type:        graphql.NewNonNull(pageType),
+				Description: "Страница цитат, подходящих под фильтр",
+				Args: graphql.FieldConfigArgument{
+					"filter": &graphql.ArgumentConfig{Type: filterInput},
+					"page":   &graphql.ArgumentConfig{Type: pageInput},
+				},
+				Resolve: resolveQuotes,
+			},
+			"randomQuote": &graphql.Field{
+				Type:        quoteType,
+				Description: "Случайная цитата, подходящая под фильтр",
+				Args: graphql.FieldConfigArgument{
+					"filter": &graphql.ArgumentConfig{Type: filterInput},
+				},
+				Resolve: resolveRandomQuote,
+			},
+			"search": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(quoteType))),
+				Description: "Цитаты, содержащие подстроку",
+				Args: graphql.FieldConfigArgument{
+					"q":     &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"limit": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: defaultPageLimit},
+				},
+				Resolve: resolveSearch,
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: queryType})
+}
+
+// Резолвер quote(id). Загружает цитату через загрузчик, поэтому несколько quote в
+// одном запросе превращаются в одно обращение к источнику
+func resolveQuote(p graphql.ResolveParams) (interface{}, error) {
+	return fromContext(p.Context).loader.load(p.Context, p.Args["id"].(int)), nil
+}
+
+// Резолвер quotes(filter, page)
+func resolveQuotes(p graphql.ResolveParams) (interface{}, error) {
+	filter := parseFilter(p.Args["filter"])
+
+	offset, limit := 0, defaultPageLimit
+	if page, ok := p.Args["page"].(map[string]interface{}); ok {
+		if v, ok := page["offset"].(int); ok {
+			offset = v
+		}
+		if v, ok := page["limit"].(int); ok {
+			limit = v
+		}
+	}
+	if offset < 0 {
+		return nil, errors.New("page.offset must not be negative")
+	}
+	if limit < 1 || limit > maxPageLimit {
+		return nil, fmt.Errorf("page.limit must be between 1 and %d", maxPageLimit)
+	}
+
+	src := fromContext(p.Context).src
+
+	total, err := src.CountQuotes(p.Context, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := src.PageQuotes(p.Context, filter, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"items":  items,
+		"total":  total,
+		"offset": offset,
+		"limit":  limit,
+	}, nil
+}
+
+// Резолвер randomQuote(filter). Считает подходящие цитаты и читает одну по случайному
+// смещению, поэтому пропуски в ID не влияют на выбор
+func resolveRandomQuote(p graphql.ResolveParams) (interface{}, error) {
+	src := fromContext(p.Context).src
+	filter := parseFilter(p.Args["filter"])
+
+	count, err := src.CountQuotes(p.Context, filter)
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, nil
+	}
+
+	quotes, err := src.PageQuotes(p.Context, filter, src.RandInt(count), 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(quotes) == 0 {
+		return nil, nil
+	}
+	return quotes[0], nil
+}
+
+// Резолвер search(q, limit)
+func resolveSearch(p graphql.ResolveParams) (interface{}, error) {
+	q := strings.TrimSpace(p.Args["q"].(string))
+	if q == "" {
+		return nil, errors.New("q must not be empty")
+	}
+
+	limit, _ := p.Args["limit"].(int)
+	if limit < 1 || limit > maxPageLimit {
+		return nil, fmt.Errorf("limit must be between 1 and %d", maxPageLimit)
+	}
+
+	return fromContext(p.Context).src.PageQuotes(p.Context, database.Filter{Contains: q}, 0, limit)
+}
+
+// Преобразует аргумент QuoteFilter в фильтр БД
+func parseFilter(arg interface{}) database.Filter {
+	var filter database.Filter
+
+	m, ok := arg.(map[string]interface{})
+	if !ok {
+		return filter
+	}
+	if v, ok := m["fromId"].(int); ok {
+		filter.FromID = v
+	}
+	if v, ok := m["toId"].(int); ok {
+		filter.ToID = v
+	}
+	if v, ok := m["contains"].(string); ok {
+		filter.Contains = v
+	}
+	return filter
+}
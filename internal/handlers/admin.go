@@ -0,0 +1,385 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/xoticdsign/returnauf/internal/bloom"
+	"github.com/xoticdsign/returnauf/internal/card"
+	"github.com/xoticdsign/returnauf/internal/database"
+	"github.com/xoticdsign/returnauf/internal/importer"
+	"github.com/xoticdsign/returnauf/internal/logging"
+	"github.com/xoticdsign/returnauf/internal/stream"
+	"github.com/xoticdsign/returnauf/models/responses"
+)
+
+// @description Возвращает значение ключа в Кэше и оставшееся время его жизни. Полезно для проверки того, что цитата действительно закэширована.
+//
+// @id          cache-inspect
+// @tags        Администрирование Кэша
+//
+// @summary     Предоставляет запись Кэша по ключу
+// @produce     json
+// @param       key path string true "Ключ в Кэше без пространства имен" example(105)
+// @security    AdminKeyAuth
+// @success     200 {object} responses.CacheEntry
+// @failure     401 {object} responses.Problem
+// @failure     404 {object} responses.Problem
+// @failure     500 {object} responses.Problem
+// @router      /admin/cache/{key} [get]
+func (d *Dependencies) CacheInspect(c *fiber.Ctx) error {
+	key := c.Params("key")
+
+	ctx, cancel := d.context(c)
+	defer cancel()
+
+	value, err := d.Cache.Get(ctx, key)
+	if err == redis.Nil {
+		return fiber.ErrNotFound
+	}
+	if err != nil {
+		return fiber.ErrInternalServerError
+	}
+
+	ttl, err := d.Cache.TTL(ctx, key)
+	if err == redis.Nil {
+		return fiber.ErrNotFound
+	}
+	if err != nil {
+		return fiber.ErrInternalServerError
+	}
+	requestLog(c).Info("Обработан запрос")
+
+	seconds := int(ttl.Seconds())
+	if ttl < 0 {
+		seconds = -1
+	}
+
+	return c.JSON(responses.CacheEntry{
+		Key:   key,
+		Value: value,
+		TTL:   seconds,
+	})
+}
+
+// @description Удаляет ключ из Кэша. Следующий запрос за этой цитатой будет обслужен из базы данных.
+//
+// @id          cache-evict
+// @tags        Администрирование Кэша
+//
+// @summary     Удаляет запись Кэша по ключу
+// @produce     json
+// @param       key path string true "Ключ в Кэше без пространства имен" example(105)
+// @security    AdminKeyAuth
+// @success     200 {object} responses.CacheResult
+// @failure     401 {object} responses.Problem
+// @failure     500 {object} responses.Problem
+// @router      /admin/cache/{key} [delete]
+func (d *Dependencies) CacheEvict(c *fiber.Ctx) error {
+	ctx, cancel := d.context(c)
+	defer cancel()
+
+	n, err := d.Cache.Delete(ctx, c.Params("key"))
+	if err != nil {
+		return fiber.ErrInternalServerError
+	}
+	requestLog(c).Info("Обработан запрос")
+
+	return c.JSON(responses.CacheResult{Affected: n})
+}
+
+// @description Удаляет из Кэша все ключи пространства имен returnauf, соответствующие glob-шаблону Redis.
+//
+// @id          cache-evict-pattern
+// @tags        Администрирование Кэша
+//
+// @summary     Удаляет записи Кэша по шаблону
+// @produce     json
+// @param       pattern query string true "Glob-шаблон ключей" example(1*)
+// @security    AdminKeyAuth
+// @success     200 {object} responses.CacheResult
+// @failure     400 {object} responses.Problem
+// @failure     401 {object} responses.Problem
+// @failure     500 {object} responses.Problem
+// @router      /admin/cache [delete]
+func (d *Dependencies) CacheEvictPattern(c *fiber.Ctx) error {
+	pattern := c.Query("pattern")
+	if pattern == "" {
+		return fiber.ErrBadRequest
+	}
+
+	ctx, cancel := d.context(c)
+	defer cancel()
+
+	n, err := d.Cache.DeletePattern(ctx, pattern)
+	if err != nil {
+		return fiber.ErrInternalServerError
+	}
+	requestLog(c).Info("Обработан запрос")
+
+	return c.JSON(responses.CacheResult{Affected: n})
+}
+
+// @description Удаляет из Кэша все ключи пространства имен returnauf, включая рейтинг популярности цитат для прогрева. Ключи других приложений в том же Redis не затрагиваются.
+//
+// @id          cache-flush
+// @tags        Администрирование Кэша
+//
+// @summary     Очищает Кэш
+// @produce     json
+// @security    AdminKeyAuth
+// @success     200 {object} responses.CacheResult
+// @failure     401 {object} responses.Problem
+// @failure     500 {object} responses.Problem
+// @router      /admin/cache/flush [post]
+func (d *Dependencies) CacheFlush(c *fiber.Ctx) error {
+	ctx, cancel := d.context(c)
+	defer cancel()
+
+	n, err := d.Cache.Flush(ctx)
+	if err != nil {
+		return fiber.ErrInternalServerError
+	}
+	requestLog(c).Info("Обработан запрос")
+
+	return c.JSON(responses.CacheResult{Affected: n})
+}
+
+// Загружает цитаты из БД в Кэш. Если WarmUpTop больше 0, загружается только столько
+// самых популярных цитат. Возвращает количество загруженных цитат
+func (d *Dependencies) WarmUp(ctx context.Context) (int, error) {
+	quotes, err := d.warmUpQuotes(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, quote := range quotes {
+		err := d.Cache.Set(ctx, strconv.Itoa(quote.ID), quote.Quote, d.cacheTTL())
+		if err != nil {
+			return i, err
+		}
+	}
+	return len(quotes), nil
+}
+
+// Возвращает цитаты для прогрева: все или WarmUpTop самых популярных. Пока рейтинг
+// популярности пуст, например при первом запуске, берутся цитаты с наименьшими ID
+func (d *Dependencies) warmUpQuotes(ctx context.Context) ([]responses.Quote, error) {
+	if d.WarmUpTop > 0 && d.Popularity != nil {
+		ids, err := d.Popularity.Top(ctx, d.WarmUpTop)
+		if err != nil {
+			return nil, err
+		}
+		if len(ids) > 0 {
+			return d.quotesByID(ctx, ids)
+		}
+	}
+
+	quotes, err := d.DB.ListAll(ctx, database.Filter{})
+	if err != nil {
+		return nil, err
+	}
+
+	if d.WarmUpTop > 0 && d.WarmUpTop < len(quotes) {
+		quotes = quotes[:d.WarmUpTop]
+	}
+	return quotes, nil
+}
+
+// Находит цитаты по ID в БД, пропуская удаленные
+func (d *Dependencies) quotesByID(ctx context.Context, ids []string) ([]responses.Quote, error) {
+	quotes := make([]responses.Quote, 0, len(ids))
+
+	for _, id := range ids {
+		quote, err := d.DB.GetQuote(ctx, id)
+		if errors.Is(err, database.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		quotes = append(quotes, quote)
+	}
+	return quotes, nil
+}
+
+// @description Повторно загружает цитаты из базы данных в Кэш. Настройка CACHE_WARMUP_TOP ограничивает прогрев самыми популярными цитатами по числу обращений, пока обращений нет - цитатами с наименьшими ID.
+//
+// @id          cache-warmup
+// @tags        Администрирование Кэша
+//
+// @summary     Прогревает Кэш
+// @produce     json
+// @security    AdminKeyAuth
+// @success     200 {object} responses.CacheResult
+// @failure     401 {object} responses.Problem
+// @failure     500 {object} responses.Problem
+// @router      /admin/cache/warmup [post]
+func (d *Dependencies) CacheWarmUp(c *fiber.Ctx) error {
+	ctx, cancel := d.context(c)
+	defer cancel()
+
+	n, err := d.WarmUp(ctx)
+	if err != nil {
+		return fiber.ErrInternalServerError
+	}
+	requestLog(c).Info("Обработан запрос")
+
+	return c.JSON(responses.CacheResult{Affected: n})
+}
+
+// @description Пересобирает фильтр Блума по ID цитат в базе данных и меняет версию набора ID в кэше, чтобы фильтры остальных экземпляров пересобрались при следующем отказе. Импорт через API и CLI меняет версию сам, вызывать эндпоинт нужно после добавления цитат в базу данных напрямую.
+//
+// @id          filter-rebuild
+// @tags        Администрирование Кэша
+//
+// @summary     Пересобирает фильтр Блума
+// @produce     json
+// @security    AdminKeyAuth
+// @success     200 {object} responses.CacheResult
+// @failure     401 {object} responses.Problem
+// @failure     404 {object} responses.Problem
+// @failure     500 {object} responses.Problem
+// @failure     503 {object} responses.Problem
+// @router      /admin/filter/rebuild [post]
+func (d *Dependencies) FilterRebuild(c *fiber.Ctx) error {
+	if d.Filter == nil {
+		return fiber.ErrNotFound
+	}
+
+	ctx, cancel := d.context(c)
+	defer cancel()
+
+	// Новая версия заставит пересобрать фильтр и остальные экземпляры
+	err := bloom.Bump(ctx, d.Cache)
+	if err != nil {
+		requestLog(c).Warn("Не удалось изменить версию фильтра Блума", logging.Err(err))
+	}
+
+	n, err := d.Filter.Load(ctx)
+	if err != nil {
+		return dbError(err)
+	}
+	requestLog(c).Info("Обработан запрос")
+
+	return c.JSON(responses.CacheResult{Affected: n})
+}
+
+// @description Импортирует цитаты из тела запроса в формате CSV, JSON-массива или NDJSON. Все строки проверяются заранее, и при наличии ошибок база данных не изменяется, а в ответе возвращается отчет по строкам. Импорт выполняется одной транзакцией. Добавленные и измененные цитаты отправляются событиями created и updated в потоки /stream/random и /ws этого экземпляра.
+//
+// @id          import
+// @tags        Администрирование цитат
+//
+// @summary     Импортирует цитаты
+// @accept      plain
+// @produce     json
+// @param       format  query string false "Формат файла: csv, json или ndjson. По умолчанию определяется по Content-Type"
+// @param       policy  query string false "Политика для существующих ID: upsert или skip" default(skip)
+// @param       dry_run query bool   false "Проверить импорт без записи в базу данных"
+// @param       map     query string false "Отображение колонок на поля цитаты" example(text:quote,num:id)
+// @security    AdminKeyAuth
+// @success     200 {object} responses.ImportReport
+// @failure     400 {object} responses.Problem
+// @failure     401 {object} responses.Problem
+// @failure     409 {object} responses.Problem
+// @failure     422 {object} responses.ImportReport
+// @failure     500 {object} responses.Problem
+// @failure     503 {object} responses.Problem
+// @router      /admin/import [post]
+func (d *Dependencies) Import(c *fiber.Ctx) error {
+	format, err := importer.DetectFormat(c.Query("format"), "", c.Get(fiber.HeaderContentType))
+	if err != nil {
+		return ErrInvalidImport.Wrap(err, err.Error())
+	}
+
+	mapping, err := importer.ParseMapping(c.Query("map"))
+	if err != nil {
+		return ErrInvalidImport.Wrap(err, err.Error())
+	}
+
+	opts := importer.Options{
+		Format:  format,
+		Policy:  c.Query("policy", database.PolicySkip),
+		DryRun:  c.QueryBool("dry_run"),
+		Mapping: mapping,
+	}
+
+	ctx, cancel := d.context(c)
+	defer cancel()
+
+	report, result, err := importer.Import(ctx, bytes.NewReader(c.Body()), opts, d.Importer)
+	if err == database.ErrUnknownPolicy {
+		return ErrInvalidImport.Wrap(err, "policy must be upsert or skip")
+	}
+	if err != nil {
+		return dbError(err)
+	}
+
+	if len(report.Errors) > 0 {
+		requestLog(c).Warn("Импорт отклонен: ошибки в строках", logging.Int("Errors", len(report.Errors)))
+
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(report)
+	}
+
+	if quotes := result.Changed(); !opts.DryRun && len(quotes) > 0 {
+		d.invalidateQuotes(ctx, quotes)
+		d.publishChanges(result)
+	}
+	requestLog(c).Info("Обработан запрос")
+
+	return c.JSON(report)
+}
+
+// Рассылает открытым потокам события о добавленных и измененных цитатах
+func (d *Dependencies) publishChanges(result database.ImportResult) {
+	if d.Streams == nil {
+		return
+	}
+
+	events := make([]stream.Event, 0, len(result.InsertedQuotes)+len(result.UpdatedQuotes))
+	for _, quote := range result.InsertedQuotes {
+		events = append(events, stream.Event{Type: stream.EventCreated, Quote: quote})
+	}
+	for _, quote := range result.UpdatedQuotes {
+		events = append(events, stream.Event{Type: stream.EventUpdated, Quote: quote})
+	}
+	d.Streams.Publish(events...)
+}
+
+// Удаляет измененные цитаты из Кэша и пересобирает фильтр Блума. Ошибки пишутся в
+// Логгер из контекста
+func (d *Dependencies) invalidateQuotes(ctx context.Context, quotes []responses.Quote) {
+	keys := make([]string, len(quotes))
+	for i, quote := range quotes {
+		keys[i] = strconv.Itoa(quote.ID)
+	}
+
+	_, err := d.Cache.Delete(ctx, keys...)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Не удалось удалить цитаты из Кэша", logging.Err(err))
+	}
+
+	_, err = d.Cache.DeletePattern(ctx, card.KeyPattern)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Не удалось удалить карточки цитат из Кэша", logging.Err(err))
+	}
+
+	// Версия меняется и без фильтра на этом экземпляре, так как он может быть включен
+	// на других
+	err = bloom.Bump(ctx, d.Cache)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Не удалось изменить версию фильтра Блума", logging.Err(err))
+	}
+
+	if d.Filter != nil {
+		_, err = d.Filter.Load(ctx)
+		if err != nil {
+			logging.FromContext(ctx).Warn("Не удалось пересобрать фильтр Блума", logging.Err(err))
+		}
+	}
+}
@@ -0,0 +1,538 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/xoticdsign/returnauf/config"
+	"github.com/xoticdsign/returnauf/internal/bloom"
+	"github.com/xoticdsign/returnauf/internal/card"
+	"github.com/xoticdsign/returnauf/internal/database"
+	"github.com/xoticdsign/returnauf/internal/logging"
+	"github.com/xoticdsign/returnauf/internal/problem"
+	"github.com/xoticdsign/returnauf/internal/stream"
+	"github.com/xoticdsign/returnauf/models/responses"
+)
+
+// Unit тест для хендлера CacheInspect
+func TestUnitCacheInspect(t *testing.T) {
+	cases := []struct {
+		name                    string
+		path                    string
+		wantCacheGetToReturnErr error
+		wantCacheTTLToReturnErr error
+		wantStatus              int
+		wantBodyToBe            interface{}
+	}{
+		{
+			name:                    "general case",
+			path:                    "/admin/cache/1",
+			wantCacheGetToReturnErr: nil,
+			wantCacheTTLToReturnErr: nil,
+			wantStatus:              200,
+			wantBodyToBe: responses.CacheEntry{
+				Key:   "1",
+				Value: responses.TestQuotesForHandlers[1].Quote,
+				TTL:   60,
+			},
+		},
+		{
+			name:                    "missing key case",
+			path:                    "/admin/cache/1",
+			wantCacheGetToReturnErr: redis.Nil,
+			wantCacheTTLToReturnErr: nil,
+			wantStatus:              404,
+			wantBodyToBe:            wantProblem(problem.NotFound, ""),
+		},
+		{
+			name:                    "cache error case",
+			path:                    "/admin/cache/1",
+			wantCacheGetToReturnErr: nil,
+			wantCacheTTLToReturnErr: errors.New("error"),
+			wantStatus:              500,
+			wantBodyToBe:            wantProblem(problem.Internal, ""),
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			mockCache := new(MockCache)
+			mockLogger := new(MockLog)
+
+			dependencies := &Dependencies{
+				Cache:  mockCache,
+				Logger: mockLogger,
+			}
+
+			mockCache.On("Get", "1").Return(responses.TestQuotesForHandlers[1].Quote, cs.wantCacheGetToReturnErr)
+			mockCache.On("TTL", "1").Return(time.Minute, cs.wantCacheTTLToReturnErr)
+
+			mockLogger.On("Info", mock.Anything, mock.Anything)
+			mockLogger.On("Warn", mock.Anything, mock.Anything)
+			mockLogger.On("Error", mock.Anything, mock.Anything)
+
+			mockApp := setupTestApp(dependencies)
+
+			mockApp.Get("/admin/cache/:key", dependencies.CacheInspect)
+
+			req := httptest.NewRequest("GET", cs.path, nil)
+			resp, _ := mockApp.Test(req, -1)
+
+			assert.Equal(t, cs.wantStatus, resp.StatusCode)
+
+			gotBody, _ := io.ReadAll(resp.Body)
+
+			wantBodyJSON, _ := json.Marshal(&cs.wantBodyToBe)
+
+			assert.JSONEq(t, string(wantBodyJSON), string(gotBody))
+		})
+	}
+}
+
+// Unit тест для хендлеров CacheEvict, CacheEvictPattern, CacheFlush и CacheWarmUp
+func TestUnitCacheOperations(t *testing.T) {
+	cases := []struct {
+		name         string
+		method       string
+		path         string
+		wantCacheErr error
+		wantStatus   int
+		wantBodyToBe interface{}
+	}{
+		{
+			name:         "evict case",
+			method:       "DELETE",
+			path:         "/admin/cache/1",
+			wantCacheErr: nil,
+			wantStatus:   200,
+			wantBodyToBe: responses.CacheResult{Affected: 1},
+		},
+		{
+			name:         "evict pattern case",
+			method:       "DELETE",
+			path:         "/admin/cache?pattern=1*",
+			wantCacheErr: nil,
+			wantStatus:   200,
+			wantBodyToBe: responses.CacheResult{Affected: 2},
+		},
+		{
+			name:         "evict empty pattern case",
+			method:       "DELETE",
+			path:         "/admin/cache",
+			wantCacheErr: nil,
+			wantStatus:   400,
+			wantBodyToBe: wantProblem(problem.BadRequest, ""),
+		},
+		{
+			name:         "flush case",
+			method:       "POST",
+			path:         "/admin/cache/flush",
+			wantCacheErr: nil,
+			wantStatus:   200,
+			wantBodyToBe: responses.CacheResult{Affected: 3},
+		},
+		{
+			name:         "flush error case",
+			method:       "POST",
+			path:         "/admin/cache/flush",
+			wantCacheErr: errors.New("error"),
+			wantStatus:   500,
+			wantBodyToBe: wantProblem(problem.Internal, ""),
+		},
+		{
+			name:         "warmup case",
+			method:       "POST",
+			path:         "/admin/cache/warmup",
+			wantCacheErr: nil,
+			wantStatus:   200,
+			wantBodyToBe: responses.CacheResult{Affected: len(responses.TestQuotesForHandlers)},
+		},
+		{
+			name:         "warmup error case",
+			method:       "POST",
+			path:         "/admin/cache/warmup",
+			wantCacheErr: errors.New("error"),
+			wantStatus:   500,
+			wantBodyToBe: wantProblem(problem.Internal, ""),
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			mockDB := new(MockDB)
+			mockCache := new(MockCache)
+			mockLogger := new(MockLog)
+
+			dependencies := &Dependencies{
+				DB:     mockDB,
+				Cache:  mockCache,
+				Logger: mockLogger,
+			}
+
+			mockDB.On("ListAll", mock.Anything).Return(responses.TestQuotesForHandlers, nil)
+
+			mockCache.On("Delete", []string{"1"}).Return(1, cs.wantCacheErr)
+			mockCache.On("DeletePattern", "1*").Return(2, cs.wantCacheErr)
+			mockCache.On("Flush").Return(3, cs.wantCacheErr)
+			mockCache.On("Set", mock.Anything, mock.Anything, mock.Anything).Return(cs.wantCacheErr)
+
+			mockLogger.On("Info", mock.Anything, mock.Anything)
+			mockLogger.On("Warn", mock.Anything, mock.Anything)
+			mockLogger.On("Error", mock.Anything, mock.Anything)
+
+			mockApp := setupTestApp(dependencies)
+
+			mockApp.Delete("/admin/cache/:key", dependencies.CacheEvict)
+			mockApp.Delete("/admin/cache", dependencies.CacheEvictPattern)
+			mockApp.Post("/admin/cache/flush", dependencies.CacheFlush)
+			mockApp.Post("/admin/cache/warmup", dependencies.CacheWarmUp)
+
+			req := httptest.NewRequest(cs.method, cs.path, nil)
+			resp, _ := mockApp.Test(req, -1)
+
+			assert.Equal(t, cs.wantStatus, resp.StatusCode)
+
+			gotBody, _ := io.ReadAll(resp.Body)
+
+			wantBodyJSON, _ := json.Marshal(&cs.wantBodyToBe)
+
+			assert.JSONEq(t, string(wantBodyJSON), string(gotBody))
+		})
+	}
+}
+
+// Unit тест для хендлера Import
+func TestUnitImport(t *testing.T) {
+	cases := []struct {
+		name                 string
+		path                 string
+		contentType          string
+		body                 string
+		wantImportToBeCalled bool
+		wantImportErr        error
+		wantEvict            bool
+		wantStatus           int
+	}{
+		{
+			name:                 "general case",
+			path:                 "/admin/import?policy=upsert",
+			contentType:          "text/csv",
+			body:                 "id,quote\n1,Mock quote 1\n",
+			wantImportToBeCalled: true,
+			wantImportErr:        nil,
+			wantEvict:            true,
+			wantStatus:           200,
+		},
+		{
+			name:                 "dry run case",
+			path:                 "/admin/import?format=ndjson&dry_run=true",
+			contentType:          "",
+			body:                 "{\"id\": 1, \"quote\": \"Mock quote 1\"}\n",
+			wantImportToBeCalled: true,
+			wantImportErr:        nil,
+			wantEvict:            false,
+			wantStatus:           200,
+		},
+		{
+			name:                 "invalid rows case",
+			path:                 "/admin/import",
+			contentType:          "text/csv",
+			body:                 "id,quote\nx,Mock quote 1\n",
+			wantImportToBeCalled: false,
+			wantImportErr:        nil,
+			wantEvict:            false,
+			wantStatus:           422,
+		},
+		{
+			name:                 "unknown format case",
+			path:                 "/admin/import",
+			contentType:          "application/xml",
+			body:                 "<quotes/>",
+			wantImportToBeCalled: false,
+			wantImportErr:        nil,
+			wantEvict:            false,
+			wantStatus:           400,
+		},
+		{
+			name:                 "unknown policy case",
+			path:                 "/admin/import?policy=replace",
+			contentType:          "text/csv",
+			body:                 "id,quote\n1,Mock quote 1\n",
+			wantImportToBeCalled: false,
+			wantImportErr:        nil,
+			wantEvict:            false,
+			wantStatus:           400,
+		},
+		{
+			name:                 "db error case",
+			path:                 "/admin/import",
+			contentType:          "text/csv",
+			body:                 "id,quote\n1,Mock quote 1\n",
+			wantImportToBeCalled: true,
+			wantImportErr:        errors.New("error"),
+			wantEvict:            false,
+			wantStatus:           500,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			mockImporter := new(MockImporter)
+			mockCache := new(MockCache)
+			mockLogger := new(MockLog)
+
+			dependencies := &Dependencies{
+				Importer: mockImporter,
+				Cache:    mockCache,
+				Logger:   mockLogger,
+				Streams:  stream.New(0),
+			}
+
+			sub, _ := dependencies.Streams.Subscribe("key")
+			defer sub.Close()
+
+			mockImporter.On("ImportQuotes", mock.Anything, mock.Anything, mock.Anything).Return(database.ImportResult{
+				Inserted:       1,
+				InsertedQuotes: []responses.Quote{{ID: 1, Quote: "Mock quote 1"}},
+			}, cs.wantImportErr)
+
+			mockCache.On("Delete", []string{"1"}).Return(1, nil)
+			mockCache.On("DeletePattern", card.KeyPattern).Return(0, nil)
+			mockCache.On("Set", bloom.VersionKey, mock.Anything, time.Duration(0)).Return(nil)
+
+			mockLogger.On("Info", mock.Anything, mock.Anything)
+			mockLogger.On("Warn", mock.Anything, mock.Anything)
+			mockLogger.On("Error", mock.Anything, mock.Anything)
+
+			mockApp := setupTestApp(dependencies)
+
+			mockApp.Post("/admin/import", dependencies.Import)
+
+			req := httptest.NewRequest("POST", cs.path, strings.NewReader(cs.body))
+			if cs.contentType != "" {
+				req.Header.Set("Content-Type", cs.contentType)
+			}
+			resp, _ := mockApp.Test(req, -1)
+
+			assert.Equal(t, cs.wantStatus, resp.StatusCode)
+
+			if cs.wantImportToBeCalled {
+				mockImporter.AssertCalled(t, "ImportQuotes", mock.Anything, mock.Anything, mock.Anything)
+			} else {
+				mockImporter.AssertNotCalled(t, "ImportQuotes", mock.Anything, mock.Anything, mock.Anything)
+			}
+
+			if cs.wantEvict {
+				mockCache.AssertCalled(t, "Delete", []string{"1"})
+				mockCache.AssertCalled(t, "Set", bloom.VersionKey, mock.Anything, time.Duration(0))
+
+				assert.Equal(t, stream.Event{Type: stream.EventCreated, Quote: responses.Quote{ID: 1, Quote: "Mock quote 1"}}, <-sub.Events())
+			} else {
+				mockCache.AssertNotCalled(t, "Delete", []string{"1"})
+
+				assert.Len(t, sub.Events(), 0)
+			}
+		})
+	}
+}
+
+// Unit тест для хендлера FilterRebuild
+func TestUnitFilterRebuild(t *testing.T) {
+	cases := []struct {
+		name          string
+		noFilter      bool
+		loadErr       error
+		bumpErr       error
+		wantStatus    int
+		wantAffected  int
+		wantBumpLevel string
+	}{
+		{
+			name:         "general case",
+			wantStatus:   200,
+			wantAffected: 3,
+		},
+		{
+			name:          "version not changed case",
+			bumpErr:       errors.New("error"),
+			wantStatus:    200,
+			wantAffected:  3,
+			wantBumpLevel: config.LogLevelWarn,
+		},
+		{
+			name:       "db error case",
+			loadErr:    database.ErrUnavailable,
+			wantStatus: 503,
+		},
+		{
+			name:       "no filter case",
+			noFilter:   true,
+			wantStatus: 404,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			mockCache := new(MockCache)
+			mockFilter := new(MockFilter)
+			recorder := logging.NewRecorder()
+
+			dependencies := &Dependencies{
+				Cache:  mockCache,
+				Logger: recorder,
+			}
+			if !cs.noFilter {
+				dependencies.Filter = mockFilter
+			}
+
+			mockFilter.On("Load").Return(cs.wantAffected, cs.loadErr)
+
+			mockCache.On("Set", bloom.VersionKey, mock.Anything, time.Duration(0)).Return(cs.bumpErr)
+
+			mockApp := setupTestApp(dependencies)
+
+			mockApp.Post("/admin/filter/rebuild", dependencies.FilterRebuild)
+
+			req := httptest.NewRequest("POST", "/admin/filter/rebuild", nil)
+			resp, _ := mockApp.Test(req, -1)
+
+			assert.Equal(t, cs.wantStatus, resp.StatusCode)
+
+			if cs.noFilter {
+				mockCache.AssertNotCalled(t, "Set", bloom.VersionKey, mock.Anything, time.Duration(0))
+				return
+			}
+			mockCache.AssertCalled(t, "Set", bloom.VersionKey, mock.Anything, time.Duration(0))
+
+			if cs.wantStatus == 200 {
+				var got responses.CacheResult
+
+				json.NewDecoder(resp.Body).Decode(&got)
+
+				assert.Equal(t, cs.wantAffected, got.Affected)
+			}
+
+			if cs.wantBumpLevel != "" {
+				assert.Equal(t, cs.wantBumpLevel, recorder.Entries()[0].Level)
+			}
+		})
+	}
+}
+
+// Unit тест для функции WarmUp
+func TestUnitWarmUp(t *testing.T) {
+	cases := []struct {
+		name                  string
+		top                   int
+		rankedIDs             []string
+		rankErr               error
+		dbErr                 error
+		cacheErr              error
+		wantWarmUpToReturnNum int
+		wantWarmUpToReturnErr error
+		wantCachedIDs         []string
+		wantSetCalls          int
+	}{
+		{
+			name:                  "general case",
+			top:                   0,
+			wantWarmUpToReturnNum: len(responses.TestQuotesForHandlers),
+			wantCachedIDs:         []string{"0", "1", "2"},
+			wantSetCalls:          3,
+		},
+		{
+			name:                  "popular ids case",
+			top:                   2,
+			rankedIDs:             []string{"2", "0"},
+			wantWarmUpToReturnNum: 2,
+			wantCachedIDs:         []string{"2", "0"},
+			wantSetCalls:          2,
+		},
+		{
+			name:                  "deleted popular id case",
+			top:                   2,
+			rankedIDs:             []string{"2", "9"},
+			wantWarmUpToReturnNum: 1,
+			wantCachedIDs:         []string{"2"},
+			wantSetCalls:          1,
+		},
+		{
+			name:                  "empty ranking case",
+			top:                   2,
+			wantWarmUpToReturnNum: 2,
+			wantCachedIDs:         []string{"0", "1"},
+			wantSetCalls:          2,
+		},
+		{
+			name:                  "ranking error case",
+			top:                   2,
+			rankErr:               errors.New("error"),
+			wantWarmUpToReturnNum: 0,
+			wantWarmUpToReturnErr: errors.New("error"),
+		},
+		{
+			name:                  "db error case",
+			dbErr:                 database.ErrUnavailable,
+			wantWarmUpToReturnNum: 0,
+			wantWarmUpToReturnErr: database.ErrUnavailable,
+		},
+		{
+			name:                  "cache error case",
+			cacheErr:              errors.New("error"),
+			wantWarmUpToReturnNum: 0,
+			wantWarmUpToReturnErr: errors.New("error"),
+			wantSetCalls:          1,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			mockDB := new(MockDB)
+			mockCache := new(MockCache)
+			mockRanker := new(MockRanker)
+
+			dependencies := &Dependencies{
+				DB:         mockDB,
+				Cache:      mockCache,
+				Popularity: mockRanker,
+				WarmUpTop:  cs.top,
+			}
+			dependencies.SetCacheTTL(time.Minute, time.Second)
+
+			mockRanker.On("Top", cs.top).Return(cs.rankedIDs, cs.rankErr)
+
+			mockDB.On("ListAll", database.Filter{}).Return(responses.TestQuotesForHandlers, cs.dbErr)
+			for _, quote := range responses.TestQuotesForHandlers {
+				mockDB.On("GetQuote", strconv.Itoa(quote.ID)).Return(quote, nil)
+			}
+			mockDB.On("GetQuote", "9").Return(responses.Quote{}, database.ErrNotFound)
+
+			mockCache.On("Set", mock.Anything, mock.Anything, time.Minute).Return(cs.cacheErr)
+
+			gotNum, gotErr := dependencies.WarmUp(context.Background())
+
+			if cs.wantWarmUpToReturnErr != nil {
+				assert.ErrorContains(t, gotErr, cs.wantWarmUpToReturnErr.Error())
+			} else {
+				assert.Nil(t, gotErr)
+			}
+			assert.Equal(t, cs.wantWarmUpToReturnNum, gotNum)
+
+			for _, id := range cs.wantCachedIDs {
+				mockCache.AssertCalled(t, "Set", id, mock.Anything, time.Minute)
+			}
+			mockCache.AssertNumberOfCalls(t, "Set", cs.wantSetCalls)
+		})
+	}
+}
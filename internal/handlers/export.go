@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/xoticdsign/returnauf/internal/database"
+	"github.com/xoticdsign/returnauf/internal/logging"
+	"github.com/xoticdsign/returnauf/models/responses"
+)
+
+// Форматы выгрузки и соответствующие им Content-Type
+var exportContentTypes = map[string]string{
+	"ndjson": "application/x-ndjson; charset=utf-8",
+	"csv":    "text/csv; charset=utf-8",
+	"json":   fiber.MIMEApplicationJSONCharsetUTF8,
+}
+
+// Разбирает фильтры списка цитат из параметров запроса
+func parseFilter(c *fiber.Ctx) (database.Filter, error) {
+	var filter database.Filter
+	var err error
+
+	if v := c.Query("from_id"); v != "" {
+		filter.FromID, err = strconv.Atoi(v)
+		if err != nil {
+			return filter, ErrInvalidFilter.Wrap(err, "from_id must be an integer")
+		}
+	}
+	if v := c.Query("to_id"); v != "" {
+		filter.ToID, err = strconv.Atoi(v)
+		if err != nil {
+			return filter, ErrInvalidFilter.Wrap(err, "to_id must be an integer")
+		}
+	}
+	filter.Contains = c.Query("contains")
+
+	return filter, nil
+}
+
+// @description Выгружает все цитаты, подходящие под фильтры, потоково: строки читаются из базы данных курсором и сразу пишутся в ответ, поэтому сервер не держит всю выборку в памяти. Ответ отдается как файл для скачивания.
+//
+// @id          export
+// @tags        Операции с цитатами
+//
+// @summary     Выгружает цитаты в файл
+// @produce     json
+// @produce     plain
+// @param       format   query string false "Формат выгрузки: ndjson, csv или json" default(ndjson)
+// @param       from_id  query int    false "Минимальный ID цитаты"
+// @param       to_id    query int    false "Максимальный ID цитаты"
+// @param       contains query string false "Подстрока, которую должна содержать цитата"
+// @security    KeyAuth
+// @success     200 {array}  responses.Quote
+// @failure     400 {object} responses.Problem
+// @failure     401 {object} responses.Problem
+// @failure     405 {object} responses.Problem
+// @failure     500 {object} responses.Problem
+// @router      /export [get]
+func (d *Dependencies) Export(c *fiber.Ctx) error {
+	format := c.Query("format", "ndjson")
+
+	contentType, ok := exportContentTypes[format]
+	if !ok {
+		return ErrInvalidFormat.New("export format must be ndjson, csv or json")
+	}
+
+	filter, err := parseFilter(c)
+	if err != nil {
+		return err
+	}
+
+	filename := "quotes-" + time.Now().UTC().Format("20060102") + "." + format
+
+	c.Attachment(filename)
+	c.Set(fiber.HeaderContentType, contentType)
+
+	exporter := d.Exporter
+	start := time.Now()
+
+	// Заголовки уже отправлены, поэтому об ошибке во время выгрузки клиент узнает только
+	// по оборванному файлу, а причина и число записанных строк пишутся в журнал
+	d.streamBody(c, func(ctx context.Context, w *bufio.Writer) {
+		rows, err := writeExport(ctx, exporter, filter, format, w)
+		if err != nil {
+			logging.FromContext(ctx).Error("Выгрузка прервана", logging.Int("Rows", rows), logging.Err(err))
+			return
+		}
+		logging.FromContext(ctx).Info("Обработан запрос", logging.Int("Rows", rows), logging.Duration("Duration", time.Since(start)))
+	})
+
+	return nil
+}
+
+// Пишет цитаты в выбранном формате и возвращает число записанных строк. Ошибка записи,
+// например разрыв соединения, прерывает чтение курсора
+func writeExport(ctx context.Context, exporter database.Exporter, filter database.Filter, format string, w *bufio.Writer) (int, error) {
+	var write func(responses.Quote) error
+	var finish func() error
+
+	switch format {
+	case "csv":
+		cw := csv.NewWriter(w)
+
+		err := cw.Write([]string{"id", "quote"})
+		if err != nil {
+			return 0, err
+		}
+
+		write = func(quote responses.Quote) error {
+			err := cw.Write([]string{strconv.Itoa(quote.ID), quote.Quote})
+			if err != nil {
+				return err
+			}
+			cw.Flush()
+
+			return cw.Error()
+		}
+		finish = func() error {
+			cw.Flush()
+
+			return cw.Error()
+		}
+
+	case "json":
+		first := true
+
+		_, err := w.WriteString("[")
+		if err != nil {
+			return 0, err
+		}
+
+		write = func(quote responses.Quote) error {
+			if !first {
+				err := w.WriteByte(',')
+				if err != nil {
+					return err
+				}
+			}
+			first = false
+
+			return writeJSON(w, quote)
+		}
+		finish = func() error {
+			_, err := w.WriteString("]")
+
+			return err
+		}
+
+	default:
+		write = func(quote responses.Quote) error {
+			err := writeJSON(w, quote)
+			if err != nil {
+				return err
+			}
+			return w.WriteByte('\n')
+		}
+		finish = func() error {
+			return nil
+		}
+	}
+
+	rows := 0
+
+	err := exporter.StreamQuotes(ctx, filter, func(quote responses.Quote) error {
+		err := write(quote)
+		if err != nil {
+			return err
+		}
+		rows++
+
+		if w.Buffered() > 32*1024 {
+			return w.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return rows, err
+	}
+
+	err = finish()
+	if err != nil {
+		return rows, err
+	}
+	return rows, w.Flush()
+}
+
+// Кодирует цитату в JSON без завершающего перевода строки
+func writeJSON(w *bufio.Writer, quote responses.Quote) error {
+	b, err := json.Marshal(quote)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(b)
+
+	return err
+}
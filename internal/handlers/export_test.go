@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/xoticdsign/returnauf/config"
+	"github.com/xoticdsign/returnauf/internal/database"
+	"github.com/xoticdsign/returnauf/internal/logging"
+	"github.com/xoticdsign/returnauf/models/responses"
+)
+
+// Unit тест для хендлера Export
+func TestUnitExport(t *testing.T) {
+	cases := []struct {
+		name            string
+		path            string
+		wantStatus      int
+		wantContentType string
+		wantBodyToBe    string
+	}{
+		{
+			name:            "ndjson case",
+			path:            "/export",
+			wantStatus:      200,
+			wantContentType: "application/x-ndjson; charset=utf-8",
+			wantBodyToBe:    "{\"ID\":0,\"Quote\":\"Mock quote 0\"}\n{\"ID\":1,\"Quote\":\"Mock quote 1\"}\n{\"ID\":2,\"Quote\":\"Mock quote 2\"}\n",
+		},
+		{
+			name:            "csv case",
+			path:            "/export?format=csv&from_id=1",
+			wantStatus:      200,
+			wantContentType: "text/csv; charset=utf-8",
+			wantBodyToBe:    "id,quote\n1,Mock quote 1\n2,Mock quote 2\n",
+		},
+		{
+			name:            "json case",
+			path:            "/export?format=json&contains=2",
+			wantStatus:      200,
+			wantContentType: "application/json; charset=utf-8",
+			wantBodyToBe:    "[{\"ID\":2,\"Quote\":\"Mock quote 2\"}]",
+		},
+		{
+			name:         "unknown format case",
+			path:         "/export?format=xml",
+			wantStatus:   400,
+			wantBodyToBe: "",
+		},
+		{
+			name:         "bad filter case",
+			path:         "/export?from_id=x",
+			wantStatus:   400,
+			wantBodyToBe: "",
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			mockLogger := new(MockLog)
+
+			dependencies := &Dependencies{
+				Exporter: &MockExporter{quotes: responses.TestQuotesForHandlers},
+				Logger:   mockLogger,
+			}
+
+			mockLogger.On("Info", mock.Anything, mock.Anything)
+			mockLogger.On("Warn", mock.Anything, mock.Anything)
+			mockLogger.On("Error", mock.Anything, mock.Anything)
+
+			mockApp := setupTestApp(dependencies)
+
+			mockApp.Get("/export", dependencies.Export)
+
+			req := httptest.NewRequest("GET", cs.path, nil)
+			resp, _ := mockApp.Test(req, -1)
+
+			assert.Equal(t, cs.wantStatus, resp.StatusCode)
+
+			if cs.wantStatus == 200 {
+				gotBody, _ := io.ReadAll(resp.Body)
+
+				assert.Equal(t, cs.wantContentType, resp.Header.Get("Content-Type"))
+				assert.Contains(t, resp.Header.Get("Content-Disposition"), "attachment")
+				assert.Equal(t, cs.wantBodyToBe, string(gotBody))
+			}
+		})
+	}
+}
+
+// Unit тест для записей журнала хендлера Export: запись делается после выгрузки и
+// содержит число строк
+func TestUnitExportLogging(t *testing.T) {
+	cases := []struct {
+		name        string
+		exportErr   error
+		wantLevel   string
+		wantMessage string
+	}{
+		{
+			name:        "general case",
+			exportErr:   nil,
+			wantLevel:   config.LogLevelInfo,
+			wantMessage: "Обработан запрос",
+		},
+		{
+			name:        "interrupted case",
+			exportErr:   database.ErrUnavailable,
+			wantLevel:   config.LogLevelError,
+			wantMessage: "Выгрузка прервана",
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			recorder := logging.NewRecorder()
+
+			dependencies := &Dependencies{
+				Exporter: &MockExporter{quotes: responses.TestQuotesForHandlers, err: cs.exportErr},
+				Logger:   recorder,
+			}
+
+			mockApp := setupTestApp(dependencies)
+
+			mockApp.Get("/export", dependencies.Export)
+
+			req := httptest.NewRequest("GET", "/export", nil)
+			resp, _ := mockApp.Test(req, -1)
+
+			io.ReadAll(resp.Body)
+
+			assert.Eventually(t, func() bool {
+				return len(recorder.Entries()) == 1
+			}, time.Second, time.Millisecond*10)
+
+			got := recorder.Entries()[0]
+
+			assert.Equal(t, cs.wantLevel, got.Level)
+			assert.Equal(t, cs.wantMessage, got.Message)
+			assert.Equal(t, int64(len(responses.TestQuotesForHandlers)), got.Fields["Rows"])
+		})
+	}
+}
+
+// Unit тест для хендлера Export на настоящем соединении: выгрузка идет дольше
+// WriteTimeout и не обрывается
+func TestUnitExportWriteTimeout(t *testing.T) {
+	const writeTimeout = time.Millisecond * 200
+
+	dependencies := &Dependencies{
+		Exporter: &MockExporter{quotes: responses.TestQuotesForHandlers, delay: writeTimeout},
+		Logger:   logging.NewRecorder(),
+	}
+
+	mockApp := fiber.New(fiber.Config{
+		WriteTimeout: writeTimeout,
+		ErrorHandler: dependencies.Error,
+	})
+	mockApp.Use(logging.Middleware(dependencies.Logger))
+	mockApp.Get("/export", dependencies.Export)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go mockApp.Listener(listener)
+	defer mockApp.Shutdown()
+
+	resp, err := http.Get("http://" + listener.Addr().String() + "/export?format=csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	gotBody, err := io.ReadAll(resp.Body)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "id,quote\n0,Mock quote 0\n1,Mock quote 1\n2,Mock quote 2\n", string(gotBody))
+}
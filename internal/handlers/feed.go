@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/xoticdsign/returnauf/internal/database"
+	"github.com/xoticdsign/returnauf/internal/feed"
+)
+
+// Ограничения количества записей в лентах
+const (
+	defaultFeedLimit = 20
+	maxFeedLimit     = 100
+	defaultFeedDays  = 7
+	maxFeedDays      = 30
+)
+
+// @description Возвращает ленту последних добавленных цитат в формате RSS 2.0 или Atom 1.0. Идентификаторы записей стабильны и строятся из ID цитат. Лента поддерживает условные запросы через заголовки If-None-Match и If-Modified-Since: если цитаты не менялись, возвращается 304 без тела.
+//
+// @id          feed
+// @tags        Ленты
+//
+// @summary     Предоставляет ленту новых цитат
+// @produce     xml
+// @param       format path  string true  "Формат ленты: rss или atom" Enums(rss, atom)
+// @param       limit  query int    false "Количество записей, не больше 100" default(20)
+// @security    KeyAuth
+// @success     200 {string} string
+// @success     304 {string} string
+// @failure     400 {object} responses.Problem
+// @failure     401 {object} responses.Problem
+// @failure     404 {object} responses.Problem
+// @failure     405 {object} responses.Problem
+// @failure     500 {object} responses.Problem
+// @failure     503 {object} responses.Problem
+// @router      /feed.{format} [get]
+func (d *Dependencies) Feed(c *fiber.Ctx) error {
+	format := c.Params("format")
+
+	contentType, ok := feed.ContentTypes[format]
+	if !ok {
+		return fiber.ErrNotFound
+	}
+
+	limit, err := queryBounded(c, "limit", defaultFeedLimit, maxFeedLimit)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := d.context(c)
+	defer cancel()
+
+	state, err := d.Feeder.FeedState(ctx)
+	if err != nil {
+		return dbError(err)
+	}
+
+	etag := `W/"` + strconv.Itoa(state.Count) + "-" + strconv.FormatInt(state.Updated.Unix(), 10) + "-" + strconv.Itoa(limit) + `"`
+
+	c.Set(fiber.HeaderETag, etag)
+	c.Set(fiber.HeaderLastModified, feed.LastModified(state.Updated))
+
+	if notModified(c, etag, state.Updated) {
+		requestLog(c).Info("Обработан запрос")
+
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	entries, err := d.Feeder.LatestQuotes(ctx, limit)
+	if err != nil {
+		return dbError(err)
+	}
+
+	f := feed.Feed{
+		ID:          "urn:returnauf:feed:latest",
+		Title:       "returnauf: новые цитаты",
+		Description: "Последние добавленные цитаты",
+		Link:        c.BaseURL() + "/",
+		Self:        c.BaseURL() + c.Path(),
+		Updated:     state.Updated,
+	}
+
+	for _, entry := range entries {
+		f.Items = append(f.Items, feed.Item{
+			GUID:      feed.QuoteGUID(entry.ID),
+			Title:     feed.Title(entry.Quote),
+			Link:      c.BaseURL() + "/" + strconv.Itoa(entry.ID),
+			Content:   entry.Quote,
+			Published: entry.CreatedAt,
+			Updated:   entry.UpdatedAt,
+		})
+	}
+
+	return d.sendFeed(c, format, contentType, f)
+}
+
+// @description Возвращает ленту цитат дня за последние дни в формате RSS 2.0 или Atom 1.0. Цитата дня выбирается детерминированно по дате, поэтому одинакова для всех читателей. Лента поддерживает условные запросы: до смены дня или изменения цитат возвращается 304 без тела.
+//
+// @id          feed-daily
+// @tags        Ленты
+//
+// @summary     Предоставляет ленту цитат дня
+// @produce     xml
+// @param       format path  string true  "Формат ленты: rss или atom" Enums(rss, atom)
+// @param       days   query int    false "Количество дней, не больше 30" default(7)
+// @security    KeyAuth
+// @success     200 {string} string
+// @success     304 {string} string
+// @failure     400 {object} responses.Problem
+// @failure     401 {object} responses.Problem
+// @failure     404 {object} responses.Problem
+// @failure     405 {object} responses.Problem
+// @failure     500 {object} responses.Problem
+// @failure     503 {object} responses.Problem
+// @router      /feed/daily.{format} [get]
+func (d *Dependencies) DailyFeed(c *fiber.Ctx) error {
+	format := c.Params("format")
+
+	contentType, ok := feed.ContentTypes[format]
+	if !ok {
+		return fiber.ErrNotFound
+	}
+
+	days, err := queryBounded(c, "days", defaultFeedDays, maxFeedDays)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := d.context(c)
+	defer cancel()
+
+	state, err := d.Feeder.FeedState(ctx)
+	if err != nil {
+		return dbError(err)
+	}
+
+	now := time.Now().UTC()
+	today := now.Truncate(24 * time.Hour)
+
+	updated := today
+	if state.Updated.After(updated) {
+		updated = state.Updated
+	}
+
+	etag := `W/"daily-` + today.Format(time.DateOnly) + "-" + strconv.Itoa(state.Count) + "-" + strconv.FormatInt(state.Updated.Unix(), 10) + "-" + strconv.Itoa(days) + `"`
+
+	c.Set(fiber.HeaderETag, etag)
+	c.Set(fiber.HeaderLastModified, feed.LastModified(updated))
+
+	if notModified(c, etag, updated) {
+		requestLog(c).Info("Обработан запрос")
+
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	quotes, err := d.DB.ListAll(ctx, database.Filter{})
+	if err != nil {
+		return dbError(err)
+	}
+
+	f := feed.Feed{
+		ID:          "urn:returnauf:feed:daily",
+		Title:       "returnauf: цитата дня",
+		Description: "Одна цитата на каждый день",
+		Link:        c.BaseURL() + "/",
+		Self:        c.BaseURL() + c.Path(),
+		Updated:     updated,
+	}
+
+	for _, daily := range feed.DailyQuotes(quotes, now, days) {
+		f.Items = append(f.Items, feed.Item{
+			GUID:      feed.DailyGUID(daily.Day),
+			Title:     daily.Day.Format(time.DateOnly) + ": " + feed.Title(daily.Quote.Quote),
+			Link:      c.BaseURL() + "/" + strconv.Itoa(daily.Quote.ID),
+			Content:   daily.Quote.Quote,
+			Published: daily.Day,
+			Updated:   daily.Day,
+		})
+	}
+
+	return d.sendFeed(c, format, contentType, f)
+}
+
+// Читает целочисленный параметр запроса в пределах от 1 до limit
+func queryBounded(c *fiber.Ctx, key string, def int, limit int) (int, error) {
+	v := c.Query(key)
+	if v == "" {
+		return def, nil
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 || n > limit {
+		return 0, ErrInvalidPagination.New(key + " must be an integer from 1 to " + strconv.Itoa(limit))
+	}
+	return n, nil
+}
+
+// Проверяет условный запрос по RFC 9110: If-None-Match имеет приоритет над
+// If-Modified-Since. Fiber Ctx.Fresh для этого не подходит - при одном
+// If-Modified-Since он всегда считает ответ свежим
+func notModified(c *fiber.Ctx, etag string, lastModified time.Time) bool {
+	if noneMatch := c.Get(fiber.HeaderIfNoneMatch); noneMatch != "" {
+		for _, candidate := range strings.Split(noneMatch, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	modifiedSince := c.Get(fiber.HeaderIfModifiedSince)
+	if modifiedSince == "" {
+		return false
+	}
+
+	since, err := http.ParseTime(modifiedSince)
+	if err != nil {
+		return false
+	}
+	return !lastModified.Truncate(time.Second).After(since)
+}
+
+// Сериализует ленту в нужный формат и отправляет ее
+func (d *Dependencies) sendFeed(c *fiber.Ctx, format string, contentType string, f feed.Feed) error {
+	var body []byte
+	var err error
+
+	switch format {
+	case feed.FormatAtom:
+		body, err = feed.Atom(f)
+	default:
+		body, err = feed.RSS(f)
+	}
+	if err != nil {
+		return fiber.ErrInternalServerError
+	}
+	requestLog(c).Info("Обработан запрос")
+	c.Set(fiber.HeaderContentType, contentType)
+
+	return c.Send(body)
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/xoticdsign/returnauf/internal/database"
+	"github.com/xoticdsign/returnauf/internal/feed"
+	"github.com/xoticdsign/returnauf/models/responses"
+)
+
+// Unit тест для хендлеров Feed и DailyFeed
+func TestUnitFeed(t *testing.T) {
+	updated := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name                     string
+		path                     string
+		ifModifiedSince          string
+		wantFeedStateToReturnErr error
+		wantStatus               int
+		wantContentType          string
+		wantBodyToContain        string
+	}{
+		{
+			name:              "rss case",
+			path:              "/feed.rss",
+			wantStatus:        200,
+			wantContentType:   feed.ContentTypes[feed.FormatRSS],
+			wantBodyToContain: "<guid isPermaLink=\"false\">urn:returnauf:quote:2</guid>",
+		},
+		{
+			name:              "atom case",
+			path:              "/feed.atom?limit=2",
+			wantStatus:        200,
+			wantContentType:   feed.ContentTypes[feed.FormatAtom],
+			wantBodyToContain: "<updated>2024-05-01T12:00:00Z</updated>",
+		},
+		{
+			name:              "daily case",
+			path:              "/feed/daily.atom?days=2",
+			wantStatus:        200,
+			wantContentType:   feed.ContentTypes[feed.FormatAtom],
+			wantBodyToContain: feed.DailyGUID(time.Now().UTC().Truncate(24 * time.Hour)),
+		},
+		{
+			name:            "not modified case",
+			path:            "/feed.rss",
+			ifModifiedSince: feed.LastModified(updated),
+			wantStatus:      304,
+		},
+		{
+			name:            "modified since case",
+			path:            "/feed.rss",
+			ifModifiedSince: feed.LastModified(updated.Add(-time.Hour)),
+			wantStatus:      200,
+			wantContentType: feed.ContentTypes[feed.FormatRSS],
+		},
+		{
+			name:       "unknown format case",
+			path:       "/feed.json",
+			wantStatus: 404,
+		},
+		{
+			name:       "bad limit case",
+			path:       "/feed.rss?limit=1000",
+			wantStatus: 400,
+		},
+		{
+			name:                     "db error case",
+			path:                     "/feed.rss",
+			wantFeedStateToReturnErr: errors.New("error"),
+			wantStatus:               500,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			mockDB := new(MockDB)
+			mockFeeder := new(MockFeeder)
+			mockLogger := new(MockLog)
+
+			dependencies := &Dependencies{
+				DB:     mockDB,
+				Feeder: mockFeeder,
+				Logger: mockLogger,
+			}
+
+			mockDB.On("ListAll", mock.Anything).Return(responses.TestQuotesForHandlers, nil)
+
+			mockFeeder.On("FeedState").Return(database.FeedState{Count: 3, Updated: updated}, cs.wantFeedStateToReturnErr)
+			mockFeeder.On("LatestQuotes", mock.Anything).Return([]database.FeedEntry{
+				{ID: 2, Quote: "Mock quote 2", CreatedAt: updated, UpdatedAt: updated},
+				{ID: 1, Quote: "Mock quote 1", CreatedAt: updated.Add(-time.Hour), UpdatedAt: updated.Add(-time.Hour)},
+			}, nil)
+
+			mockLogger.On("Info", mock.Anything, mock.Anything)
+			mockLogger.On("Warn", mock.Anything, mock.Anything)
+			mockLogger.On("Error", mock.Anything, mock.Anything)
+
+			mockApp := setupTestApp(dependencies)
+
+			mockApp.Get("/feed.:format", dependencies.Feed)
+			mockApp.Get("/feed/daily.:format", dependencies.DailyFeed)
+
+			req := httptest.NewRequest("GET", cs.path, nil)
+			if cs.ifModifiedSince != "" {
+				req.Header.Set("If-Modified-Since", cs.ifModifiedSince)
+			}
+			resp, _ := mockApp.Test(req, -1)
+
+			assert.Equal(t, cs.wantStatus, resp.StatusCode)
+
+			if cs.wantStatus == 200 {
+				gotBody, _ := io.ReadAll(resp.Body)
+
+				assert.Equal(t, cs.wantContentType, resp.Header.Get("Content-Type"))
+				assert.NotEmpty(t, resp.Header.Get("ETag"))
+				assert.NotEmpty(t, resp.Header.Get("Last-Modified"))
+				assert.Contains(t, string(gotBody), cs.wantBodyToContain)
+			}
+		})
+	}
+}
+
+// Unit тест для условного запроса ленты по ETag
+func TestUnitFeedETag(t *testing.T) {
+	mockFeeder := new(MockFeeder)
+	mockLogger := new(MockLog)
+
+	dependencies := &Dependencies{
+		Feeder: mockFeeder,
+		Logger: mockLogger,
+	}
+
+	mockFeeder.On("FeedState").Return(database.FeedState{Count: 3, Updated: time.Now()}, nil)
+	mockFeeder.On("LatestQuotes", mock.Anything).Return([]database.FeedEntry{}, nil)
+
+	mockLogger.On("Info", mock.Anything, mock.Anything)
+
+	mockApp := setupTestApp(dependencies)
+
+	mockApp.Get("/feed.:format", dependencies.Feed)
+
+	resp, _ := mockApp.Test(httptest.NewRequest("GET", "/feed.rss", nil), -1)
+	etag := resp.Header.Get("ETag")
+
+	req := httptest.NewRequest("GET", "/feed.rss", nil)
+	req.Header.Set("If-None-Match", etag)
+	resp, _ = mockApp.Test(req, -1)
+
+	assert.Equal(t, 304, resp.StatusCode)
+	mockFeeder.AssertNumberOfCalls(t, "LatestQuotes", 1)
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/xoticdsign/returnauf/internal/cache"
+	"github.com/xoticdsign/returnauf/internal/database"
+	"github.com/xoticdsign/returnauf/internal/gql"
+	"github.com/xoticdsign/returnauf/models/responses"
+)
+
+// Источник данных GraphQL поверх Кэша и БД
+type graphQLSource struct {
+	d *Dependencies
+}
+
+// Возвращает цитаты по ID: сначала из Кэша, а промахи - одним запросом к БД с
+// сохранением результата в Кэш. ID, отсеянные фильтром Блума, пропускаются
+func (s *graphQLSource) QuotesByID(ctx context.Context, ids []int) (map[int]responses.Quote, error) {
+	found := map[int]responses.Quote{}
+
+	var misses []int
+
+	for _, id := range ids {
+		key := strconv.Itoa(id)
+
+		if s.d.Filter != nil && !s.d.Filter.MayContain(ctx, key) {
+			continue
+		}
+
+		cached, err := s.d.Cache.Get(ctx, key)
+		if err == nil {
+			if cached != cache.Missing {
+				found[id] = responses.Quote{ID: id, Quote: cached}
+			}
+			continue
+		}
+		misses = append(misses, id)
+	}
+
+	if len(misses) == 0 {
+		return found, nil
+	}
+
+	quotes, err := s.d.Batcher.GetQuotes(ctx, misses)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, quote := range quotes {
+		found[quote.ID] = quote
+
+		// Ошибка Кэша не мешает ответу: цитата уже получена из БД
+		s.d.Cache.Set(ctx, strconv.Itoa(quote.ID), quote.Quote, s.d.cacheTTL())
+	}
+	return found, nil
+}
+
+// Возвращает количество цитат, подходящих под фильтр
+func (s *graphQLSource) CountQuotes(ctx context.Context, filter database.Filter) (int, error) {
+	return s.d.Exporter.CountQuotes(ctx, filter)
+}
+
+// Возвращает страницу цитат, подходящих под фильтр
+func (s *graphQLSource) PageQuotes(ctx context.Context, filter database.Filter, offset int, limit int) ([]responses.Quote, error) {
+	return s.d.Exporter.PageQuotes(ctx, filter, offset, limit)
+}
+
+// Возвращает случайное число от 0 до count
+func (s *graphQLSource) RandInt(count int) int {
+	n, _ := s.d.Support.RandInt(count)
+
+	return n
+}
+
+// @description Выполняет запрос GraphQL над цитатами: quote(id), quotes(filter, page), randomQuote(filter) и search(q). Запрос принимается в теле POST в формате JSON или в параметрах GET. Запросы, превышающие ограничения глубины или сложности, отклоняются с кодом 400. Если включен GraphiQL, GET из браузера без параметра query открывает GraphiQL.
+//
+// @id          graphql
+// @tags        GraphQL
+//
+// @summary     Выполняет запрос GraphQL
+// @accept      json
+// @produce     json
+// @produce     html
+// @param       query         query string false "Текст запроса для GET"
+// @param       operationName query string false "Имя операции для GET"
+// @param       variables     query string false "Переменные в формате JSON для GET"
+// @security    KeyAuth
+// @success     200 {object} map[string]interface{}
+// @failure     400 {object} map[string]interface{}
+// @failure     401 {object} responses.Problem
+// @failure     405 {object} responses.Problem
+// @failure     500 {object} responses.Problem
+// @router      /graphql [get]
+// @router      /graphql [post]
+func (d *Dependencies) GraphQL(c *fiber.Ctx) error {
+	if d.Schema == nil {
+		return fiber.ErrNotFound
+	}
+
+	var req gql.Request
+
+	if c.Method() == fiber.MethodGet {
+		if c.Query("query") == "" && d.Schema.GraphiQL() && c.Accepts(fiber.MIMETextHTML) == fiber.MIMETextHTML {
+			requestLog(c).Info("Обработан запрос")
+			c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+
+			return c.SendString(gql.GraphiQLPage)
+		}
+
+		req.Query = c.Query("query")
+		req.OperationName = c.Query("operationName")
+
+		if variables := c.Query("variables"); variables != "" {
+			err := json.Unmarshal([]byte(variables), &req.Variables)
+			if err != nil {
+				return ErrInvalidGraphQL.Wrap(err, "variables must be a JSON object")
+			}
+		}
+	} else {
+		err := json.Unmarshal(c.Body(), &req)
+		if err != nil {
+			return ErrInvalidGraphQL.Wrap(err, "request body must be a JSON object")
+		}
+	}
+
+	ctx, cancel := d.context(c)
+	defer cancel()
+
+	result, executed := d.Schema.Execute(ctx, &graphQLSource{d: d}, req)
+
+	status := fiber.StatusOK
+	if !executed {
+		status = fiber.StatusBadRequest
+	}
+	requestLog(c).Info("Обработан запрос")
+
+	return c.Status(status).JSON(result)
+}
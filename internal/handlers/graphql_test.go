@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/xoticdsign/returnauf/config"
+	"github.com/xoticdsign/returnauf/internal/gql"
+	"github.com/xoticdsign/returnauf/models/responses"
+)
+
+// Unit тест для хендлера GraphQL
+func TestUnitGraphQL(t *testing.T) {
+	cases := []struct {
+		name                    string
+		method                  string
+		path                    string
+		body                    string
+		accept                  string
+		graphiQL                bool
+		wantCacheGetToReturnErr error
+		wantStatus              int
+		wantBodyToBe            string
+		wantBodyToContain       string
+		wantGetQuotesToBeCalled bool
+	}{
+		{
+			name:                    "post case",
+			method:                  "POST",
+			path:                    "/graphql",
+			body:                    `{"query":"{ a: quote(id: 1) { quote } b: quote(id: 2) { quote } }"}`,
+			wantCacheGetToReturnErr: errors.New("error"),
+			wantStatus:              200,
+			wantBodyToBe:            `{"data":{"a":{"quote":"Mock quote 1"},"b":{"quote":"Mock quote 2"}}}`,
+			wantGetQuotesToBeCalled: true,
+		},
+		{
+			name:                    "cached case",
+			method:                  "GET",
+			path:                    "/graphql?query=" + strings.ReplaceAll("{ quote(id: 1) { id quote } }", " ", "%20"),
+			wantCacheGetToReturnErr: nil,
+			wantStatus:              200,
+			wantBodyToBe:            `{"data":{"quote":{"id":1,"quote":"Mock quote 1"}}}`,
+			wantGetQuotesToBeCalled: false,
+		},
+		{
+			name:              "graphiql case",
+			method:            "GET",
+			path:              "/graphql",
+			accept:            "text/html",
+			graphiQL:          true,
+			wantStatus:        200,
+			wantBodyToContain: "graphiql.min.js",
+		},
+		{
+			name:              "graphiql disabled case",
+			method:            "GET",
+			path:              "/graphql",
+			accept:            "text/html",
+			graphiQL:          false,
+			wantStatus:        400,
+			wantBodyToContain: gql.ErrEmptyQuery.Error(),
+		},
+		{
+			name:       "bad body case",
+			method:     "POST",
+			path:       "/graphql",
+			body:       `{"query":`,
+			wantStatus: 400,
+		},
+		{
+			name:              "depth limit case",
+			method:            "POST",
+			path:              "/graphql",
+			body:              `{"query":"{ quotes { items { id } } }"}`,
+			wantStatus:        400,
+			wantBodyToContain: "depth",
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			mockBatcher := new(MockBatcher)
+			mockCache := new(MockCache)
+			mockLogger := new(MockLog)
+
+			schema, _ := gql.New(config.GraphQL{MaxDepth: 2, GraphiQL: cs.graphiQL})
+
+			dependencies := &Dependencies{
+				Batcher:  mockBatcher,
+				Exporter: &MockExporter{quotes: responses.TestQuotes},
+				Cache:    mockCache,
+				Logger:   mockLogger,
+				Support:  &MockSupport{},
+				Schema:   schema,
+			}
+
+			mockBatcher.On("GetQuotes", mock.Anything).Return(responses.TestQuotes[:2], nil)
+
+			mockCache.On("Get", "1").Return(responses.TestQuotes[0].Quote, cs.wantCacheGetToReturnErr)
+			mockCache.On("Get", mock.Anything).Return("", errors.New("error"))
+			mockCache.On("Set", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+			mockLogger.On("Info", mock.Anything, mock.Anything)
+			mockLogger.On("Warn", mock.Anything, mock.Anything)
+			mockLogger.On("Error", mock.Anything, mock.Anything)
+
+			mockApp := setupTestApp(dependencies)
+
+			mockApp.Get("/graphql", dependencies.GraphQL)
+			mockApp.Post("/graphql", dependencies.GraphQL)
+
+			req := httptest.NewRequest(cs.method, cs.path, strings.NewReader(cs.body))
+			req.Header.Set("Content-Type", "application/json")
+			if cs.accept != "" {
+				req.Header.Set("Accept", cs.accept)
+			}
+			resp, _ := mockApp.Test(req, -1)
+
+			gotBody, _ := io.ReadAll(resp.Body)
+
+			assert.Equal(t, cs.wantStatus, resp.StatusCode)
+
+			if cs.wantBodyToBe != "" {
+				assert.JSONEq(t, cs.wantBodyToBe, string(gotBody))
+			}
+			if cs.wantBodyToContain != "" {
+				assert.Contains(t, string(gotBody), cs.wantBodyToContain)
+			}
+
+			if cs.wantGetQuotesToBeCalled {
+				mockBatcher.AssertNumberOfCalls(t, "GetQuotes", 1)
+			} else {
+				mockBatcher.AssertNotCalled(t, "GetQuotes", mock.Anything)
+			}
+		})
+	}
+}
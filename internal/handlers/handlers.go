@@ -1,64 +1,114 @@
 package handlers
 
 import (
+	"context"
 	"errors"
 	"strconv"
+	"sync/atomic"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/keyauth"
 
+	"github.com/xoticdsign/returnauf/config"
+	"github.com/xoticdsign/returnauf/internal/bloom"
 	"github.com/xoticdsign/returnauf/internal/cache"
 	"github.com/xoticdsign/returnauf/internal/database"
+	"github.com/xoticdsign/returnauf/internal/gql"
+	"github.com/xoticdsign/returnauf/internal/health"
 	"github.com/xoticdsign/returnauf/internal/logging"
+	"github.com/xoticdsign/returnauf/internal/problem"
+	"github.com/xoticdsign/returnauf/internal/render"
+	"github.com/xoticdsign/returnauf/internal/stream"
 	"github.com/xoticdsign/returnauf/internal/utils"
 	"github.com/xoticdsign/returnauf/models/responses"
 )
 
 // Структура, содержащая интерфейсы для инъекции
 type Dependencies struct {
-	DB      database.Queuer
-	Cache   cache.Cacher
-	Logger  logging.Logger
-	Support utils.Supporter
+	DB         database.Queuer
+	Importer   database.Importer
+	Exporter   database.Exporter
+	Feeder     database.Feeder
+	Batcher    database.BatchQueuer
+	Cache      cache.Cacher
+	Popularity cache.Ranker
+	Logger     logging.Logger
+	Support    utils.Supporter
+	Filter     bloom.Filterer
+	Schema     *gql.Server
+	Streams    *stream.Hub
+	Health     *health.Health
+	Heartbeat  time.Duration
+	Timeout    time.Duration
+	WarmUpTop  int
+
+	ttl atomic.Pointer[cacheTTL]
 }
 
-// Получает контекст и ошибку, а затем форматирует все в JSON
-func (d *Dependencies) Error(c *fiber.Ctx, err error) error {
-	if err == keyauth.ErrMissingOrMalformedAPIKey {
-		d.Logger.Error(fiber.ErrUnauthorized.Message, c)
+// Время жизни записей в Кэше, которое меняется при перезагрузке настроек
+type cacheTTL struct {
+	quote    time.Duration
+	negative time.Duration
+}
+
+// Задает время жизни цитат и отметок об отсутствии цитаты в Кэше. Нулевые значения
+// заменяются значениями по умолчанию. Безопасно вызывать во время обработки запросов
+func (d *Dependencies) SetCacheTTL(quote time.Duration, negative time.Duration) {
+	d.ttl.Store(&cacheTTL{quote: quote, negative: negative})
+}
+
+// Возвращает Логгер запроса из его контекста. Поля запроса в него добавляет
+// logging.Middleware
+func requestLog(c *fiber.Ctx) logging.Logger {
+	return logging.FromContext(c.UserContext())
+}
 
-		return c.Status(fiber.StatusUnauthorized).JSON(responses.Error{
-			Code:    fiber.StatusUnauthorized,
-			Message: fiber.ErrUnauthorized.Message,
-		})
+// Возвращает контекст запроса, ограниченный таймаутом операций с Кэшом и БД
+func (d *Dependencies) context(c *fiber.Ctx) (context.Context, context.CancelFunc) {
+	if d.Timeout <= 0 {
+		return context.WithCancel(c.UserContext())
 	}
+	return context.WithTimeout(c.UserContext(), d.Timeout)
+}
 
-	var e *fiber.Error
+// Возвращает время жизни цитат в Кэше
+func (d *Dependencies) cacheTTL() time.Duration {
+	ttl := d.ttl.Load()
+	if ttl == nil || ttl.quote <= 0 {
+		return config.DefaultCacheTTL
+	}
+	return ttl.quote
+}
 
-	if errors.As(err, &e) {
-		er, ok := responses.ErrDictionary[e.Code]
-		if !ok {
-			d.Logger.Warn("Необработанная ошибка: "+er.Message, c)
+// Возвращает время жизни отметки об отсутствии цитаты в Кэше
+func (d *Dependencies) negativeCacheTTL() time.Duration {
+	ttl := d.ttl.Load()
+	if ttl == nil || ttl.negative <= 0 {
+		return config.DefaultNegativeCacheTTL
+	}
+	return ttl.negative
+}
 
-			return c.Status(fiber.StatusInternalServerError).JSON(responses.Error{
-				Code:    fiber.StatusInternalServerError,
-				Message: fiber.ErrInternalServerError.Message,
-			})
-		}
-		d.Logger.Error(er.Message, c)
+// Получает контекст и ошибку и отвечает ошибкой в формате RFC 7807 с UUID запроса в
+// instance. Ошибки без вида из реестра считаются внутренними, их текст в ответ не попадает
+func (d *Dependencies) Error(c *fiber.Ctx, err error) error {
+	if err == keyauth.ErrMissingOrMalformedAPIKey {
+		err = problem.Unauthorized.Wrap(err, "missing or malformed API key")
+	}
 
-		return c.Status(er.Code).JSON(responses.Error{
-			Code:    er.Code,
-			Message: er.Message,
-		})
+	e := problem.From(err)
+
+	fields := []logging.Field{logging.String("Code", e.Kind.Code), logging.Err(err)}
+	if e.Kind.Status >= fiber.StatusInternalServerError {
+		requestLog(c).Error(e.Kind.Title, fields...)
+	} else {
+		requestLog(c).Warn(e.Kind.Title, fields...)
 	}
-	d.Logger.Error(fiber.ErrInternalServerError.Message, c)
 
-	return c.Status(fiber.StatusInternalServerError).JSON(responses.Error{
-		Code:    fiber.StatusInternalServerError,
-		Message: fiber.ErrInternalServerError.Message,
-	})
+	uuid, _ := c.Locals("uuid").(string)
+
+	return render.Render(c, e.Kind.Status, e.Response(uuid))
 }
 
 // @description Возвращает полный список цитат, хранящихся в базе данных. Полезно для получения всех доступных данных для анализа, отображения или других операций. Цитаты возвращаются в формате JSON.
@@ -68,110 +118,209 @@ func (d *Dependencies) Error(c *fiber.Ctx, err error) error {
 //
 // @summary     Предоставляет все цитаты
 // @produce     json
+// @produce     xml
+// @produce     plain
+// @produce     application/yaml
+// @produce     application/msgpack
+// @param       format   query string false "Формат ответа: json, xml, text, yaml или msgpack. Имеет приоритет над заголовком Accept"
+// @param       from_id  query int    false "Минимальный ID цитаты"
+// @param       to_id    query int    false "Максимальный ID цитаты"
+// @param       contains query string false "Подстрока, которую должна содержать цитата"
 // @security    KeyAuth
 // @success     200 {object} responses.Quote
-// @failure     401 {object} responses.Error
-// @failure     404 {object} responses.Error
-// @failure     405 {object} responses.Error
-// @failure     500 {object} responses.Error
+// @failure     400 {object} responses.Problem
+// @failure     401 {object} responses.Problem
+// @failure     404 {object} responses.Problem
+// @failure     405 {object} responses.Problem
+// @failure     500 {object} responses.Problem
+// @failure     503 {object} responses.Problem
 // @router      / [get]
 func (d *Dependencies) ListAll(c *fiber.Ctx) error {
-	quotes, err := d.DB.ListAll()
+	filter, err := parseFilter(c)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := d.context(c)
+	defer cancel()
+
+	quotes, err := d.DB.ListAll(ctx, filter)
 	if err != nil {
-		return fiber.ErrNotFound
+		return dbError(err)
 	}
-	d.Logger.Info("Обработан запрос", c)
+	requestLog(c).Info("Обработан запрос", logging.Int("Quotes", len(quotes)))
 
-	return c.JSON(quotes)
+	return render.Render(c, fiber.StatusOK, quotes)
 }
 
-// @description Возвращает случайную цитату из базы данных. Если цитата отсутствует в кэше, то она извлекается из базы данных, добавляется в кэш и возвращается пользователю. Позволяет отображать динамическое содержимое, не перегружая базу данных. Случайность обеспечивается генератором случайных чисел.
+// @description Возвращает случайную цитату из базы данных. Если цитата отсутствует в кэше, то она извлекается из базы данных, добавляется в кэш и возвращается пользователю. Позволяет отображать динамическое содержимое, не перегружая базу данных. Случайность обеспечивается генератором случайных чисел: выбирается случайная позиция среди существующих цитат, поэтому каждая цитата выбирается с равной вероятностью, даже если часть ID удалена.
 //
 // @id          random-quote
 // @tags        Операции с цитатами
 //
 // @summary     Предоставляет случайную цитату
 // @produce     json
+// @produce     xml
+// @produce     plain
+// @produce     application/yaml
+// @produce     application/msgpack
+// @param       format query string false "Формат ответа: json, xml, text, yaml или msgpack. Имеет приоритет над заголовком Accept"
 // @security    KeyAuth
 // @success     200 {object} responses.Quote
-// @failure     401 {object} responses.Error
-// @failure     404 {object} responses.Error
-// @failure     405 {object} responses.Error
-// @failure     500 {object} responses.Error
+// @failure     401 {object} responses.Problem
+// @failure     404 {object} responses.Problem
+// @failure     405 {object} responses.Problem
+// @failure     500 {object} responses.Problem
+// @failure     503 {object} responses.Problem
 // @router      /random [get]
 func (d *Dependencies) RandomQuote(c *fiber.Ctx) error {
-	count, err := d.DB.QuotesCount()
+	ctx, cancel := d.context(c)
+	defer cancel()
+
+	quote, hit, err := d.randomQuote(ctx)
 	if err != nil {
-		return fiber.ErrNotFound
+		return err
 	}
+	requestLog(c).Info("Обработан запрос", logging.Int("QuoteID", quote.ID), logging.Bool("CacheHit", hit))
 
-	idInt, id := d.Support.RandInt(count)
+	return render.Render(c, fiber.StatusOK, quote)
+}
 
-	quote, err := d.Cache.Get(id)
+// Выбирает случайную цитату и находит ее так же, как QuoteID
+func (d *Dependencies) randomQuote(ctx context.Context) (responses.Quote, bool, error) {
+	id, err := d.RandomQuoteID(ctx)
 	if err != nil {
-		quote, err := d.DB.GetQuote(id)
-		if err != nil {
-			return fiber.ErrNotFound
-		}
-
-		err = d.Cache.Set(id, quote.Quote, time.Minute*1)
-		if err != nil {
-			return fiber.ErrInternalServerError
-		}
-		d.Logger.Info("Обработан запрос", c)
+		return responses.Quote{}, false, err
+	}
+	return d.findQuote(ctx, id, strconv.Itoa(id))
+}
 
-		return c.JSON(quote)
+// Возвращает ID случайной цитаты. Выбирает случайное смещение среди существующих
+// записей, а не случайное число, поэтому пропуски в ID не приводят к 404 и каждая
+// цитата выбирается с равной вероятностью. Используется другими транспортами,
+// например gRPC
+func (d *Dependencies) RandomQuoteID(ctx context.Context) (int, error) {
+	count, err := d.DB.QuotesCount(ctx)
+	if err != nil {
+		return 0, dbError(err)
 	}
-	d.Logger.Info("Обработан запрос", c)
+	if count == 0 {
+		return 0, problem.NotFound.New("there are no quotes yet")
+	}
+
+	offset, _ := d.Support.RandInt(count)
 
-	return c.JSON(responses.Quote{
-		ID:    idInt,
-		Quote: quote,
-	})
+	id, err := d.DB.QuoteIDAt(ctx, offset)
+	if err != nil {
+		// Цитаты удалили между подсчетом и выбором
+		if errors.Is(err, database.ErrNotFound) {
+			return 0, problem.NotFound.New("there are no quotes yet")
+		}
+		return 0, dbError(err)
+	}
+	return id, nil
 }
 
-// @description Возвращает цитату по её уникальному идентификатору (ID). Если цитата не найдена в кэше, происходит обращение к базе данных. Полученная цитата затем сохраняется в кэш для ускорения последующих запросов. Если запрошенного ID нет в базе данных, возвращается ошибка.
+// @description Возвращает цитату по её уникальному идентификатору (ID). Если цитата не найдена в кэше, происходит обращение к базе данных. Полученная цитата затем сохраняется в кэш для ускорения последующих запросов. Если запрошенного ID нет в базе данных, возвращается ошибка 404, нечисловой ID отклоняется с кодом 400, а при недоступности базы данных возвращается 503.
 //
 // @id          quote-id
 // @tags        Операции с цитатами
 //
 // @summary     Предоставляет цитату по заданному ID
 // @produce     json
+// @produce     xml
+// @produce     plain
+// @produce     application/yaml
+// @produce     application/msgpack
+// @param       format query string false "Формат ответа: json, xml, text, yaml или msgpack. Имеет приоритет над заголовком Accept"
 // @param       id path string false "Позволяет указать ID цитаты" example(105)
 // @security    KeyAuth
 // @success     200 {object} responses.Quote
-// @failure     401 {object} responses.Error
-// @failure     404 {object} responses.Error
-// @failure     405 {object} responses.Error
-// @failure     500 {object} responses.Error
+// @failure     400 {object} responses.Problem
+// @failure     401 {object} responses.Problem
+// @failure     404 {object} responses.Problem
+// @failure     405 {object} responses.Problem
+// @failure     500 {object} responses.Problem
+// @failure     503 {object} responses.Problem
 // @router      /{id} [get]
 func (d *Dependencies) QuoteID(c *fiber.Ctx) error {
 	id := c.Params("id")
 
 	idInt, err := strconv.Atoi(id)
 	if err != nil {
-		return fiber.ErrNotFound
+		return ErrInvalidQuoteID.Wrap(err, "id must be an integer")
 	}
 
-	quote, err := d.Cache.Get(id)
+	ctx, cancel := d.context(c)
+	defer cancel()
+
+	quote, hit, err := d.findQuote(ctx, idInt, strconv.Itoa(idInt))
 	if err != nil {
-		quote, err := d.DB.GetQuote(id)
-		if err != nil {
-			return fiber.ErrNotFound
-		}
+		return err
+	}
+	d.countHit(ctx, strconv.Itoa(idInt))
+	requestLog(c).Info("Обработан запрос", logging.Int("QuoteID", idInt), logging.Bool("CacheHit", hit))
+
+	return render.Render(c, fiber.StatusOK, quote)
+}
+
+// Находит цитату по ID так же, как QuoteID, и учитывает обращение в рейтинге
+// популярности. Используется другими транспортами, например gRPC
+func (d *Dependencies) FindQuote(ctx context.Context, id int) (responses.Quote, error) {
+	quote, _, err := d.findQuote(ctx, id, strconv.Itoa(id))
+	if err == nil {
+		d.countHit(ctx, strconv.Itoa(id))
+	}
+	return quote, err
+}
+
+// Учитывает обращение к цитате по ID в рейтинге популярности, по которому прогревается
+// Кэш. Ошибка только пишется в журнал
+func (d *Dependencies) countHit(ctx context.Context, id string) {
+	if d.Popularity == nil {
+		return
+	}
+
+	err := d.Popularity.Hit(ctx, id)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Не удалось учесть обращение к цитате", logging.String("QuoteID", id), logging.Err(err))
+	}
+}
 
-		err = d.Cache.Set(id, quote.Quote, time.Minute*1)
-		if err != nil {
-			return fiber.ErrInternalServerError
+// Находит цитату в Кэше, а при промахе - в БД, сохраняя результат в Кэш. Отсутствующие
+// в БД ID отсекаются фильтром Блума и кратковременно кэшируются как отсутствующие.
+// Ошибка сохранения в Кэш только пишется в журнал, так как цитата уже получена из БД.
+// Возвращает также, была ли цитата или отметка об ее отсутствии найдена в Кэше
+func (d *Dependencies) findQuote(ctx context.Context, idInt int, id string) (responses.Quote, bool, error) {
+	if d.Filter != nil && !d.Filter.MayContain(ctx, id) {
+		return responses.Quote{}, false, fiber.ErrNotFound
+	}
+
+	cached, err := d.Cache.Get(ctx, id)
+	if err == nil {
+		if cached == cache.Missing {
+			return responses.Quote{}, true, fiber.ErrNotFound
 		}
-		d.Logger.Info("Обработан запрос", c)
+		return responses.Quote{
+			ID:    idInt,
+			Quote: cached,
+		}, true, nil
+	}
 
-		return c.JSON(quote)
+	quote, err := d.DB.GetQuote(ctx, id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			err := d.Cache.Set(ctx, id, cache.Missing, d.negativeCacheTTL())
+			if err != nil {
+				logging.FromContext(ctx).Warn("Не удалось сохранить отметку об отсутствии цитаты в Кэш", logging.String("QuoteID", id), logging.Err(err))
+			}
+		}
+		return responses.Quote{}, false, dbError(err)
 	}
-	d.Logger.Info("Обработан запрос", c)
 
-	return c.JSON(responses.Quote{
-		ID:    idInt,
-		Quote: quote,
-	})
+	err = d.Cache.Set(ctx, id, quote.Quote, d.cacheTTL())
+	if err != nil {
+		logging.FromContext(ctx).Warn("Не удалось сохранить цитату в Кэш", logging.String("QuoteID", id), logging.Err(err))
+	}
+	return quote, false, nil
 }
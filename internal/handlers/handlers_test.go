@@ -1,22 +1,31 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"io"
+	"net/http"
 	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/keyauth"
 	"github.com/gofiber/fiber/v2/middleware/requestid"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
+	"github.com/xoticdsign/returnauf/config"
 	"github.com/xoticdsign/returnauf/internal/cache"
 	"github.com/xoticdsign/returnauf/internal/database"
 	"github.com/xoticdsign/returnauf/internal/logging"
+	"github.com/xoticdsign/returnauf/internal/problem"
 	"github.com/xoticdsign/returnauf/internal/utils"
 	"github.com/xoticdsign/returnauf/models/responses"
 )
@@ -25,7 +34,7 @@ import (
 
 // Настройка Fiber для тестов
 func setupTestApp(dependencies *Dependencies) *fiber.App {
-	return fiber.New(fiber.Config{
+	app := fiber.New(fiber.Config{
 		StrictRouting: true,
 		CaseSensitive: true,
 		ReadTimeout:   time.Second * 20,
@@ -33,6 +42,39 @@ func setupTestApp(dependencies *Dependencies) *fiber.App {
 		ErrorHandler:  dependencies.Error,
 		AppName:       "returnauf",
 	})
+	app.Use(logging.Middleware(dependencies.Logger))
+
+	return app
+}
+
+// Возвращает ожидаемый ответ с ошибкой. В тестовом приложении нет requestid, поэтому
+// instance остается пустым
+func wantProblem(kind *problem.Kind, detail string) responses.Problem {
+	return kind.New(detail).Response("")
+}
+
+// Добавляет к ожидаемому ответу с ошибкой instance с UUID запроса из заголовка ответа
+func withInstance(want interface{}, res *http.Response) interface{} {
+	if problem, ok := want.(responses.Problem); ok {
+		problem.Instance = "urn:uuid:" + res.Header.Get(fiber.HeaderXRequestID)
+
+		return problem
+	}
+	return want
+}
+
+// Проверяет, подходит ли цитата под фильтр, так же, как условия запроса к БД
+func matchFilter(filter database.Filter, quote responses.Quote) bool {
+	if filter.FromID > 0 && quote.ID < filter.FromID {
+		return false
+	}
+	if filter.ToID > 0 && quote.ID > filter.ToID {
+		return false
+	}
+	if filter.Contains != "" && !strings.Contains(quote.Quote, filter.Contains) {
+		return false
+	}
+	return true
 }
 
 // Имитация БД, реализующая методы Queuer
@@ -41,63 +83,251 @@ type MockDB struct {
 }
 
 // Имитация метода QuotesCount
-func (m *MockDB) QuotesCount() (int, error) {
+func (m *MockDB) QuotesCount(ctx context.Context) (int, error) {
 	args := m.Called()
 
 	return args.Int(0), args.Error(1)
 }
 
-// Имитация метода ListAll
-func (m *MockDB) ListAll() ([]responses.Quote, error) {
-	args := m.Called()
+// Имитация метода QuoteIDAt
+func (m *MockDB) QuoteIDAt(ctx context.Context, offset int) (int, error) {
+	args := m.Called(offset)
 
-	return args.Get(0).([]responses.Quote), args.Error(1)
+	return args.Int(0), args.Error(1)
+}
+
+// Имитация метода ListAll. Возвращает цитаты, подходящие под фильтр, как это делает
+// запрос к БД
+func (m *MockDB) ListAll(ctx context.Context, filter database.Filter) ([]responses.Quote, error) {
+	args := m.Called(filter)
+
+	matched := []responses.Quote{}
+	for _, quote := range args.Get(0).([]responses.Quote) {
+		if matchFilter(filter, quote) {
+			matched = append(matched, quote)
+		}
+	}
+	return matched, args.Error(1)
 }
 
 // Имитация метода GetQuote
-func (m *MockDB) GetQuote(id string) (responses.Quote, error) {
+func (m *MockDB) GetQuote(ctx context.Context, id string) (responses.Quote, error) {
 	args := m.Called(id)
 
 	return args.Get(0).(responses.Quote), args.Error(1)
 }
 
+// Имитация БД, реализующая методы Importer
+type MockImporter struct {
+	mock.Mock
+}
+
+// Имитация метода ImportQuotes
+func (m *MockImporter) ImportQuotes(ctx context.Context, quotes []responses.Quote, policy string, dryRun bool) (database.ImportResult, error) {
+	args := m.Called(quotes, policy, dryRun)
+
+	return args.Get(0).(database.ImportResult), args.Error(1)
+}
+
+// Имитация БД, реализующая методы BatchQueuer
+type MockBatcher struct {
+	mock.Mock
+}
+
+// Имитация метода GetQuotes
+func (m *MockBatcher) GetQuotes(ctx context.Context, ids []int) ([]responses.Quote, error) {
+	args := m.Called(ids)
+
+	return args.Get(0).([]responses.Quote), args.Error(1)
+}
+
+// Имитация БД, реализующая методы Feeder
+type MockFeeder struct {
+	mock.Mock
+}
+
+// Имитация метода FeedState
+func (m *MockFeeder) FeedState(ctx context.Context) (database.FeedState, error) {
+	args := m.Called()
+
+	return args.Get(0).(database.FeedState), args.Error(1)
+}
+
+// Имитация метода LatestQuotes
+func (m *MockFeeder) LatestQuotes(ctx context.Context, limit int) ([]database.FeedEntry, error) {
+	args := m.Called(limit)
+
+	return args.Get(0).([]database.FeedEntry), args.Error(1)
+}
+
+// Имитация БД, реализующая методы Exporter. delay задерживает каждую строку, а err
+// возвращается после всех строк
+type MockExporter struct {
+	quotes []responses.Quote
+	delay  time.Duration
+	err    error
+}
+
+// Имитация метода StreamQuotes
+func (m *MockExporter) StreamQuotes(ctx context.Context, filter database.Filter, fn func(responses.Quote) error) error {
+	for _, quote := range m.quotes {
+		if !matchFilter(filter, quote) {
+			continue
+		}
+		time.Sleep(m.delay)
+
+		err := fn(quote)
+		if err != nil {
+			return err
+		}
+	}
+	return m.err
+}
+
+// Имитация метода CountQuotes
+func (m *MockExporter) CountQuotes(ctx context.Context, filter database.Filter) (int, error) {
+	count := 0
+	for _, quote := range m.quotes {
+		if matchFilter(filter, quote) {
+			count++
+		}
+	}
+	return count, m.err
+}
+
+// Имитация метода PageQuotes
+func (m *MockExporter) PageQuotes(ctx context.Context, filter database.Filter, offset int, limit int) ([]responses.Quote, error) {
+	quotes := []responses.Quote{}
+	for _, quote := range m.quotes {
+		if !matchFilter(filter, quote) {
+			continue
+		}
+		if offset > 0 {
+			offset--
+			continue
+		}
+		if len(quotes) < limit {
+			quotes = append(quotes, quote)
+		}
+	}
+	return quotes, m.err
+}
+
 // Имитация Кэша, реализующая методы Cacher
 type MockCache struct {
 	mock.Mock
 }
 
 // Имитация метода Set
-func (m *MockCache) Set(key string, value interface{}, expiration time.Duration) error {
+func (m *MockCache) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
 	args := m.Called(key, value, expiration)
 
 	return args.Error(0)
 }
 
 // Имитация метода Get
-func (m *MockCache) Get(key string) (string, error) {
+func (m *MockCache) Get(ctx context.Context, key string) (string, error) {
 	args := m.Called(key)
 
 	return args.String(0), args.Error(1)
 }
 
+// Имитация метода TTL
+func (m *MockCache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	args := m.Called(key)
+
+	return args.Get(0).(time.Duration), args.Error(1)
+}
+
+// Имитация метода Delete
+func (m *MockCache) Delete(ctx context.Context, keys ...string) (int, error) {
+	args := m.Called(keys)
+
+	return args.Int(0), args.Error(1)
+}
+
+// Имитация метода DeletePattern
+func (m *MockCache) DeletePattern(ctx context.Context, pattern string) (int, error) {
+	args := m.Called(pattern)
+
+	return args.Int(0), args.Error(1)
+}
+
+// Имитация метода Flush
+func (m *MockCache) Flush(ctx context.Context) (int, error) {
+	args := m.Called()
+
+	return args.Int(0), args.Error(1)
+}
+
 // Имитация Лог, реализующая методы Logger
 type MockLog struct {
 	mock.Mock
 }
 
+// Имитация метода Debug
+func (m *MockLog) Debug(message string, fields ...logging.Field) {
+	m.Called(message, fields)
+}
+
 // Имитация метода Info
-func (m *MockLog) Info(message string, c *fiber.Ctx) {
-	m.Called(message, c)
+func (m *MockLog) Info(message string, fields ...logging.Field) {
+	m.Called(message, fields)
 }
 
 // Имитация метода Warn
-func (m *MockLog) Warn(message string, c *fiber.Ctx) {
-	m.Called(message, c)
+func (m *MockLog) Warn(message string, fields ...logging.Field) {
+	m.Called(message, fields)
 }
 
 // Имитация метода Error
-func (m *MockLog) Error(message string, c *fiber.Ctx) {
-	m.Called(message, c)
+func (m *MockLog) Error(message string, fields ...logging.Field) {
+	m.Called(message, fields)
+}
+
+// Имитация метода With, возвращает ту же имитацию
+func (m *MockLog) With(fields ...logging.Field) logging.Logger {
+	return m
+}
+
+// Имитация фильтра Блума, реализующая методы Filterer
+type MockFilter struct {
+	mock.Mock
+}
+
+// Имитация метода MayContain
+func (m *MockFilter) MayContain(ctx context.Context, id string) bool {
+	args := m.Called(id)
+
+	return args.Bool(0)
+}
+
+// Имитация метода Load
+func (m *MockFilter) Load(ctx context.Context) (int, error) {
+	args := m.Called()
+
+	return args.Int(0), args.Error(1)
+}
+
+// Имитация рейтинга популярности, реализующая методы Ranker
+type MockRanker struct {
+	mock.Mock
+}
+
+// Имитация метода Hit
+func (m *MockRanker) Hit(ctx context.Context, member string) error {
+	args := m.Called(member)
+
+	return args.Error(0)
+}
+
+// Имитация метода Top
+func (m *MockRanker) Top(ctx context.Context, n int) ([]string, error) {
+	args := m.Called(n)
+
+	ids, _ := args.Get(0).([]string)
+
+	return ids, args.Error(1)
 }
 
 // Имитация Support, содержащая дополнительные методы хендлеров
@@ -108,6 +338,85 @@ func (m *MockSupport) RandInt(count int) (int, string) {
 	return 1, "1"
 }
 
+// Unit тест для хендлера Error
+func TestUnitError(t *testing.T) {
+	cases := []struct {
+		name         string
+		err          error
+		wantStatus   int
+		wantLevel    string
+		wantBodyToBe responses.Problem
+	}{
+		{
+			name:         "missing key case",
+			err:          keyauth.ErrMissingOrMalformedAPIKey,
+			wantStatus:   fiber.StatusUnauthorized,
+			wantLevel:    config.LogLevelWarn,
+			wantBodyToBe: wantProblem(problem.Unauthorized, "missing or malformed API key"),
+		},
+		{
+			name:         "registered kind case",
+			err:          ErrStreamLimit.New("limit reached"),
+			wantStatus:   fiber.StatusTooManyRequests,
+			wantLevel:    config.LogLevelWarn,
+			wantBodyToBe: wantProblem(ErrStreamLimit, "limit reached"),
+		},
+		{
+			name:         "fiber error case",
+			err:          fiber.ErrServiceUnavailable,
+			wantStatus:   fiber.StatusServiceUnavailable,
+			wantLevel:    config.LogLevelError,
+			wantBodyToBe: wantProblem(problem.ServiceUnavailable, ""),
+		},
+		{
+			name:         "unknown error case",
+			err:          errors.New("secret internal details"),
+			wantStatus:   fiber.StatusInternalServerError,
+			wantLevel:    config.LogLevelError,
+			wantBodyToBe: wantProblem(problem.Internal, ""),
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			recorder := logging.NewRecorder()
+
+			dependencies := &Dependencies{
+				Logger: recorder,
+			}
+
+			mockApp := setupTestApp(dependencies)
+
+			mockApp.Get("/", func(c *fiber.Ctx) error {
+				c.Locals("uuid", "0b3b7a8e")
+
+				return cs.err
+			})
+
+			req := httptest.NewRequest("GET", "/", nil)
+			res, _ := mockApp.Test(req, -1)
+
+			assert.Equal(t, cs.wantStatus, res.StatusCode)
+			assert.Equal(t, "application/problem+json", res.Header.Get(fiber.HeaderContentType))
+
+			gotBody, _ := io.ReadAll(res.Body)
+
+			want := cs.wantBodyToBe
+			want.Instance = "urn:uuid:0b3b7a8e"
+
+			wantBodyJSON, _ := json.Marshal(want)
+
+			assert.JSONEq(t, string(wantBodyJSON), string(gotBody))
+
+			got := recorder.Entries()
+			if assert.Len(t, got, 1) {
+				assert.Equal(t, cs.wantLevel, got[0].Level)
+				assert.Equal(t, cs.wantBodyToBe.Code, got[0].Fields["Code"])
+			}
+		})
+	}
+}
+
 // Unit тест для хендлера ListAll
 func TestUnitListAll(t *testing.T) {
 	cases := []struct {
@@ -115,6 +424,7 @@ func TestUnitListAll(t *testing.T) {
 		method                 string
 		path                   string
 		wantListAllToReturnErr error
+		wantFilter             database.Filter
 		wantBodyToBe           interface{}
 	}{
 		{
@@ -129,21 +439,36 @@ func TestUnitListAll(t *testing.T) {
 			method:                 "GET",
 			path:                   "/wrongpath",
 			wantListAllToReturnErr: nil,
-			wantBodyToBe:           responses.ErrDictionary[404],
+			wantBodyToBe:           wantProblem(problem.NotFound, "Cannot GET /wrongpath"),
 		},
 		{
 			name:                   "wrong method case",
 			method:                 "POST",
 			path:                   "/",
 			wantListAllToReturnErr: nil,
-			wantBodyToBe:           responses.ErrDictionary[405],
+			wantBodyToBe:           wantProblem(problem.MethodNotAllowed, ""),
 		},
 		{
-			name:                   "empty db case",
+			name:                   "db unavailable case",
 			method:                 "GET",
 			path:                   "/",
-			wantListAllToReturnErr: errors.New("error"),
-			wantBodyToBe:           responses.ErrDictionary[404],
+			wantListAllToReturnErr: &database.Error{Class: database.ErrUnavailable, Err: errors.New("database is locked")},
+			wantBodyToBe:           wantProblem(problem.ServiceUnavailable, ""),
+		},
+		{
+			name:                   "filter case",
+			method:                 "GET",
+			path:                   "/?from_id=2",
+			wantListAllToReturnErr: nil,
+			wantFilter:             database.Filter{FromID: 2},
+			wantBodyToBe:           responses.TestQuotesForHandlers[2:],
+		},
+		{
+			name:                   "bad filter case",
+			method:                 "GET",
+			path:                   "/?to_id=x",
+			wantListAllToReturnErr: nil,
+			wantBodyToBe:           wantProblem(ErrInvalidFilter, "to_id must be an integer"),
 		},
 	}
 
@@ -157,7 +482,7 @@ func TestUnitListAll(t *testing.T) {
 				Logger: mockLogger,
 			}
 
-			mockDB.On("ListAll").Return(responses.TestQuotesForHandlers, cs.wantListAllToReturnErr)
+			mockDB.On("ListAll", mock.Anything).Return(responses.TestQuotesForHandlers, cs.wantListAllToReturnErr)
 
 			mockLogger.On("Info", mock.Anything, mock.Anything)
 			mockLogger.On("Warn", mock.Anything, mock.Anything)
@@ -177,6 +502,10 @@ func TestUnitListAll(t *testing.T) {
 			wantBodyStr := string(wantBodyJSON)
 
 			assert.JSONEq(t, wantBodyStr, gotBodyStr)
+
+			if cs.wantFilter != (database.Filter{}) {
+				mockDB.AssertCalled(t, "ListAll", cs.wantFilter)
+			}
 		})
 	}
 }
@@ -188,6 +517,7 @@ func TestUnitRandomQuote(t *testing.T) {
 		method                     string
 		path                       string
 		wantQuotesCountToReturnErr error
+		wantQuoteIDAtToReturnErr   error
 		wantGetQuoteToReturnErr    error
 		wantCacheSetToReturnErr    error
 		wantCacheGetToReturnQuote  bool
@@ -225,7 +555,7 @@ func TestUnitRandomQuote(t *testing.T) {
 			wantCacheSetToReturnErr:    nil,
 			wantCacheGetToReturnQuote:  false,
 			wantCacheGetToReturnErr:    nil,
-			wantBodyToBe:               responses.ErrDictionary[404],
+			wantBodyToBe:               wantProblem(problem.NotFound, "Cannot GET /wrongpath"),
 		},
 		{
 			name:                       "wrong method case",
@@ -236,18 +566,30 @@ func TestUnitRandomQuote(t *testing.T) {
 			wantCacheSetToReturnErr:    nil,
 			wantCacheGetToReturnQuote:  false,
 			wantCacheGetToReturnErr:    nil,
-			wantBodyToBe:               responses.ErrDictionary[405],
+			wantBodyToBe:               wantProblem(problem.MethodNotAllowed, ""),
 		},
 		{
 			name:                       "empty db case",
 			method:                     "GET",
 			path:                       "/random",
 			wantQuotesCountToReturnErr: nil,
-			wantGetQuoteToReturnErr:    errors.New("error"),
+			wantGetQuoteToReturnErr:    database.ErrNotFound,
 			wantCacheSetToReturnErr:    nil,
 			wantCacheGetToReturnQuote:  false,
 			wantCacheGetToReturnErr:    errors.New("error"),
-			wantBodyToBe:               responses.ErrDictionary[404],
+			wantBodyToBe:               wantProblem(problem.NotFound, ""),
+		},
+		{
+			name:                       "quotes deleted before pick case",
+			method:                     "GET",
+			path:                       "/random",
+			wantQuotesCountToReturnErr: nil,
+			wantQuoteIDAtToReturnErr:   &database.Error{Class: database.ErrNotFound, Err: errors.New("error")},
+			wantGetQuoteToReturnErr:    nil,
+			wantCacheSetToReturnErr:    nil,
+			wantCacheGetToReturnQuote:  false,
+			wantCacheGetToReturnErr:    errors.New("error"),
+			wantBodyToBe:               wantProblem(problem.NotFound, "there are no quotes yet"),
 		},
 		{
 			name:                       "can't set cache case",
@@ -258,7 +600,7 @@ func TestUnitRandomQuote(t *testing.T) {
 			wantCacheSetToReturnErr:    errors.New("error"),
 			wantCacheGetToReturnQuote:  false,
 			wantCacheGetToReturnErr:    errors.New("error"),
-			wantBodyToBe:               responses.ErrDictionary[500],
+			wantBodyToBe:               responses.TestQuotesForHandlers[1],
 		},
 	}
 
@@ -277,6 +619,7 @@ func TestUnitRandomQuote(t *testing.T) {
 			}
 
 			mockDB.On("QuotesCount").Return(len(responses.TestQuotesForHandlers), cs.wantQuotesCountToReturnErr)
+			mockDB.On("QuoteIDAt", 1).Return(responses.TestQuotesForHandlers[1].ID, cs.wantQuoteIDAtToReturnErr)
 			mockDB.On("GetQuote", mock.Anything).Return(responses.TestQuotesForHandlers[1], cs.wantGetQuoteToReturnErr)
 
 			mockCache.On("Set", mock.Anything, mock.Anything, mock.Anything).Return(cs.wantCacheSetToReturnErr)
@@ -337,14 +680,14 @@ func TestUnitQuoteID(t *testing.T) {
 			wantBodyToBe:              responses.TestQuotesForHandlers[1],
 		},
 		{
-			name:                      "wrong path case",
+			name:                      "non-numeric id case",
 			method:                    "GET",
 			path:                      "/wrongpath",
 			wantGetQuoteToReturnErr:   nil,
 			wantCacheSetToReturnErr:   nil,
 			wantCacheGetToReturnQuote: false,
 			wantCacheGetToReturnErr:   nil,
-			wantBodyToBe:              responses.ErrDictionary[404],
+			wantBodyToBe:              wantProblem(ErrInvalidQuoteID, "id must be an integer"),
 		},
 		{
 			name:                      "wrong method case",
@@ -354,17 +697,17 @@ func TestUnitQuoteID(t *testing.T) {
 			wantCacheSetToReturnErr:   nil,
 			wantCacheGetToReturnQuote: false,
 			wantCacheGetToReturnErr:   nil,
-			wantBodyToBe:              responses.ErrDictionary[405],
+			wantBodyToBe:              wantProblem(problem.MethodNotAllowed, ""),
 		},
 		{
 			name:                      "empty db case",
 			method:                    "GET",
 			path:                      "/1",
-			wantGetQuoteToReturnErr:   errors.New("error"),
+			wantGetQuoteToReturnErr:   database.ErrNotFound,
 			wantCacheSetToReturnErr:   nil,
 			wantCacheGetToReturnQuote: false,
 			wantCacheGetToReturnErr:   errors.New("error"),
-			wantBodyToBe:              responses.ErrDictionary[404],
+			wantBodyToBe:              wantProblem(problem.NotFound, ""),
 		},
 		{
 			name:                      "can't set cache case",
@@ -374,7 +717,7 @@ func TestUnitQuoteID(t *testing.T) {
 			wantCacheSetToReturnErr:   errors.New("error"),
 			wantCacheGetToReturnQuote: false,
 			wantCacheGetToReturnErr:   errors.New("error"),
-			wantBodyToBe:              responses.ErrDictionary[500],
+			wantBodyToBe:              responses.TestQuotesForHandlers[1],
 		},
 	}
 
@@ -417,11 +760,406 @@ func TestUnitQuoteID(t *testing.T) {
 	}
 }
 
+// Unit тест для отсечения отсутствующих ID в хендлере QuoteID
+func TestUnitQuoteIDMissing(t *testing.T) {
+	cases := []struct {
+		name                     string
+		filterMayContain         bool
+		cacheGetReturn           string
+		cacheGetErr              error
+		wantGetQuoteToBeCalled   bool
+		wantNegativeCacheToBeSet bool
+	}{
+		{
+			name:                     "filter rejects id case",
+			filterMayContain:         false,
+			cacheGetReturn:           "",
+			cacheGetErr:              errors.New("error"),
+			wantGetQuoteToBeCalled:   false,
+			wantNegativeCacheToBeSet: false,
+		},
+		{
+			name:                     "negative cache hit case",
+			filterMayContain:         true,
+			cacheGetReturn:           cache.Missing,
+			cacheGetErr:              nil,
+			wantGetQuoteToBeCalled:   false,
+			wantNegativeCacheToBeSet: false,
+		},
+		{
+			name:                     "not found in db case",
+			filterMayContain:         true,
+			cacheGetReturn:           "",
+			cacheGetErr:              errors.New("error"),
+			wantGetQuoteToBeCalled:   true,
+			wantNegativeCacheToBeSet: true,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			mockDB := new(MockDB)
+			mockCache := new(MockCache)
+			mockLogger := new(MockLog)
+			mockFilter := new(MockFilter)
+
+			dependencies := &Dependencies{
+				DB:     mockDB,
+				Cache:  mockCache,
+				Logger: mockLogger,
+				Filter: mockFilter,
+			}
+			dependencies.SetCacheTTL(0, time.Second)
+
+			mockFilter.On("MayContain", "999").Return(cs.filterMayContain)
+
+			mockDB.On("GetQuote", "999").Return(responses.Quote{}, database.ErrNotFound)
+
+			mockCache.On("Get", "999").Return(cs.cacheGetReturn, cs.cacheGetErr)
+			mockCache.On("Set", "999", cache.Missing, time.Second).Return(nil)
+
+			mockLogger.On("Info", mock.Anything, mock.Anything)
+			mockLogger.On("Warn", mock.Anything, mock.Anything)
+			mockLogger.On("Error", mock.Anything, mock.Anything)
+
+			mockApp := setupTestApp(dependencies)
+
+			mockApp.Get("/:id", dependencies.QuoteID)
+
+			req := httptest.NewRequest("GET", "/999", nil)
+			resp, _ := mockApp.Test(req, -1)
+
+			assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+
+			if cs.wantGetQuoteToBeCalled {
+				mockDB.AssertCalled(t, "GetQuote", "999")
+			} else {
+				mockDB.AssertNotCalled(t, "GetQuote", "999")
+			}
+
+			if cs.wantNegativeCacheToBeSet {
+				mockCache.AssertCalled(t, "Set", "999", cache.Missing, time.Second)
+			} else {
+				mockCache.AssertNotCalled(t, "Set", "999", cache.Missing, time.Second)
+			}
+		})
+	}
+}
+
+// Unit тест для записей журнала функции QuoteID
+func TestUnitQuoteIDLogging(t *testing.T) {
+	cases := []struct {
+		name        string
+		getQuoteErr error
+		cacheGetErr error
+		cacheSetErr error
+		hitErr      error
+		wantLevels  []string
+		wantQuoteID []interface{}
+		wantHits    int
+	}{
+		{
+			name:        "cache hit case",
+			getQuoteErr: database.ErrNotFound,
+			cacheGetErr: nil,
+			cacheSetErr: nil,
+			wantLevels:  []string{config.LogLevelInfo},
+			wantQuoteID: []interface{}{int64(1)},
+			wantHits:    1,
+		},
+		{
+			name:        "negative cache not saved case",
+			getQuoteErr: database.ErrNotFound,
+			cacheGetErr: errors.New("error"),
+			cacheSetErr: errors.New("error"),
+			wantLevels:  []string{config.LogLevelWarn, config.LogLevelWarn},
+			wantQuoteID: []interface{}{"1", nil},
+			wantHits:    0,
+		},
+		{
+			name:        "quote not saved case",
+			getQuoteErr: nil,
+			cacheGetErr: errors.New("error"),
+			cacheSetErr: errors.New("error"),
+			wantLevels:  []string{config.LogLevelWarn, config.LogLevelInfo},
+			wantQuoteID: []interface{}{"1", int64(1)},
+			wantHits:    1,
+		},
+		{
+			name:        "hit not counted case",
+			getQuoteErr: database.ErrNotFound,
+			cacheGetErr: nil,
+			cacheSetErr: nil,
+			hitErr:      errors.New("error"),
+			wantLevels:  []string{config.LogLevelWarn, config.LogLevelInfo},
+			wantQuoteID: []interface{}{"1", int64(1)},
+			wantHits:    1,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			mockDB := new(MockDB)
+			mockCache := new(MockCache)
+			mockRanker := new(MockRanker)
+			recorder := logging.NewRecorder()
+
+			dependencies := &Dependencies{
+				DB:         mockDB,
+				Cache:      mockCache,
+				Popularity: mockRanker,
+				Logger:     recorder,
+			}
+
+			mockDB.On("GetQuote", "1").Return(responses.Quote{ID: 1, Quote: "quote"}, cs.getQuoteErr)
+			mockRanker.On("Hit", "1").Return(cs.hitErr)
+
+			mockCache.On("Get", "1").Return("quote", cs.cacheGetErr)
+			mockCache.On("Set", "1", mock.Anything, mock.Anything).Return(cs.cacheSetErr)
+
+			mockApp := setupTestApp(dependencies)
+
+			mockApp.Get("/:id", dependencies.QuoteID)
+
+			req := httptest.NewRequest("GET", "/1", nil)
+			mockApp.Test(req, -1)
+
+			got := recorder.Entries()
+
+			assert.Len(t, got, len(cs.wantLevels))
+			for i, entry := range got {
+				assert.Equal(t, cs.wantLevels[i], entry.Level)
+				assert.Equal(t, "/1", entry.Fields["Path"])
+				assert.Equal(t, cs.wantQuoteID[i], entry.Fields["QuoteID"])
+			}
+			mockRanker.AssertNumberOfCalls(t, "Hit", cs.wantHits)
+		})
+	}
+}
+
+// Unit тест для перевода классов ошибок БД в ответы хендлеров
+func TestUnitDBErrors(t *testing.T) {
+	dbErr := func(class error) error {
+		return &database.Error{Class: class, Err: errors.New("error")}
+	}
+
+	cases := []struct {
+		name          string
+		path          string
+		dbQuotes      []responses.Quote
+		dbCount       int
+		dbErr         error
+		wantStatus    int
+		wantBodyToBe  interface{}
+		wantDBToBeHit bool
+	}{
+		{
+			name:          "invalid class case",
+			path:          "/1",
+			dbErr:         dbErr(database.ErrInvalid),
+			wantStatus:    fiber.StatusBadRequest,
+			wantBodyToBe:  wantProblem(problem.BadRequest, ""),
+			wantDBToBeHit: true,
+		},
+		{
+			name:          "not found class case",
+			path:          "/1",
+			dbErr:         dbErr(database.ErrNotFound),
+			wantStatus:    fiber.StatusNotFound,
+			wantBodyToBe:  wantProblem(problem.NotFound, ""),
+			wantDBToBeHit: true,
+		},
+		{
+			name:          "conflict class case",
+			path:          "/1",
+			dbErr:         dbErr(database.ErrConflict),
+			wantStatus:    fiber.StatusConflict,
+			wantBodyToBe:  wantProblem(problem.Conflict, ""),
+			wantDBToBeHit: true,
+		},
+		{
+			name:          "unavailable class case",
+			path:          "/1",
+			dbErr:         dbErr(database.ErrUnavailable),
+			wantStatus:    fiber.StatusServiceUnavailable,
+			wantBodyToBe:  wantProblem(problem.ServiceUnavailable, ""),
+			wantDBToBeHit: true,
+		},
+		{
+			name:          "unclassified error case",
+			path:          "/1",
+			dbErr:         errors.New("error"),
+			wantStatus:    fiber.StatusInternalServerError,
+			wantBodyToBe:  wantProblem(problem.Internal, ""),
+			wantDBToBeHit: true,
+		},
+		{
+			name:          "non-numeric id case",
+			path:          "/abc",
+			wantStatus:    fiber.StatusBadRequest,
+			wantBodyToBe:  wantProblem(ErrInvalidQuoteID, "id must be an integer"),
+			wantDBToBeHit: false,
+		},
+		{
+			name:          "list unavailable case",
+			path:          "/",
+			dbErr:         dbErr(database.ErrUnavailable),
+			wantStatus:    fiber.StatusServiceUnavailable,
+			wantBodyToBe:  wantProblem(problem.ServiceUnavailable, ""),
+			wantDBToBeHit: true,
+		},
+		{
+			name:          "list empty table case",
+			path:          "/",
+			dbQuotes:      []responses.Quote{},
+			wantStatus:    fiber.StatusOK,
+			wantBodyToBe:  []responses.Quote{},
+			wantDBToBeHit: true,
+		},
+		{
+			name:          "random empty table case",
+			path:          "/random",
+			dbCount:       0,
+			wantStatus:    fiber.StatusNotFound,
+			wantBodyToBe:  wantProblem(problem.NotFound, "there are no quotes yet"),
+			wantDBToBeHit: true,
+		},
+		{
+			name:          "random unavailable case",
+			path:          "/random",
+			dbErr:         dbErr(database.ErrUnavailable),
+			wantStatus:    fiber.StatusServiceUnavailable,
+			wantBodyToBe:  wantProblem(problem.ServiceUnavailable, ""),
+			wantDBToBeHit: true,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			mockDB := new(MockDB)
+			mockCache := new(MockCache)
+			mockLogger := new(MockLog)
+
+			dependencies := &Dependencies{
+				DB:      mockDB,
+				Cache:   mockCache,
+				Logger:  mockLogger,
+				Support: &MockSupport{},
+			}
+
+			mockDB.On("ListAll", mock.Anything).Return(cs.dbQuotes, cs.dbErr)
+			mockDB.On("QuotesCount").Return(cs.dbCount, cs.dbErr)
+			mockDB.On("QuoteIDAt", mock.Anything).Return(0, cs.dbErr)
+			mockDB.On("GetQuote", mock.Anything).Return(responses.Quote{}, cs.dbErr)
+
+			mockCache.On("Get", mock.Anything).Return("", errors.New("error"))
+			mockCache.On("Set", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+			mockLogger.On("Info", mock.Anything, mock.Anything)
+			mockLogger.On("Warn", mock.Anything, mock.Anything)
+			mockLogger.On("Error", mock.Anything, mock.Anything)
+
+			mockApp := setupTestApp(dependencies)
+
+			mockApp.Get("/", dependencies.ListAll)
+			mockApp.Get("/random", dependencies.RandomQuote)
+			mockApp.Get("/:id", dependencies.QuoteID)
+
+			req := httptest.NewRequest("GET", cs.path, nil)
+			res, _ := mockApp.Test(req, -1)
+
+			assert.Equal(t, cs.wantStatus, res.StatusCode)
+
+			gotBody, _ := io.ReadAll(res.Body)
+
+			wantBodyJSON, _ := json.Marshal(cs.wantBodyToBe)
+
+			assert.JSONEq(t, string(wantBodyJSON), string(gotBody))
+			assert.Equal(t, cs.wantDBToBeHit, len(mockDB.Calls) > 0)
+		})
+	}
+}
+
+// Unit тест для функции QuoteID с выбором формата ответа
+func TestUnitQuoteIDNegotiation(t *testing.T) {
+	cases := []struct {
+		name            string
+		path            string
+		accept          string
+		wantStatus      int
+		wantContentType string
+		wantBodyToBe    string
+	}{
+		{
+			name:            "plain text case",
+			path:            "/1",
+			accept:          "text/plain",
+			wantStatus:      fiber.StatusOK,
+			wantContentType: fiber.MIMETextPlainCharsetUTF8,
+			wantBodyToBe:    responses.TestQuotesForHandlers[0].Quote + "\n",
+		},
+		{
+			name:            "xml override case",
+			path:            "/1?format=xml",
+			accept:          "application/json",
+			wantStatus:      fiber.StatusOK,
+			wantContentType: fiber.MIMEApplicationXML,
+			wantBodyToBe:    "<Quote><ID>" + strconv.Itoa(responses.TestQuotesForHandlers[0].ID) + "</ID><Quote>" + responses.TestQuotesForHandlers[0].Quote + "</Quote></Quote>",
+		},
+		{
+			name:            "plain text error case",
+			path:            "/999",
+			accept:          "text/plain",
+			wantStatus:      fiber.StatusNotFound,
+			wantContentType: fiber.MIMETextPlainCharsetUTF8,
+			wantBodyToBe:    "404 " + problem.NotFound.Title + "\n",
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			mockDB := new(MockDB)
+			mockCache := new(MockCache)
+			mockLogger := new(MockLog)
+
+			dependencies := &Dependencies{
+				DB:     mockDB,
+				Cache:  mockCache,
+				Logger: mockLogger,
+			}
+
+			mockDB.On("GetQuote", "1").Return(responses.TestQuotesForHandlers[0], nil)
+			mockDB.On("GetQuote", "999").Return(responses.Quote{}, database.ErrNotFound)
+
+			mockCache.On("Get", mock.Anything).Return("", errors.New("error"))
+			mockCache.On("Set", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+			mockLogger.On("Info", mock.Anything, mock.Anything)
+			mockLogger.On("Warn", mock.Anything, mock.Anything)
+			mockLogger.On("Error", mock.Anything, mock.Anything)
+
+			mockApp := setupTestApp(dependencies)
+
+			mockApp.Get("/:id", dependencies.QuoteID)
+
+			req := httptest.NewRequest("GET", cs.path, nil)
+			req.Header.Set("Accept", cs.accept)
+			resp, _ := mockApp.Test(req, -1)
+
+			gotBody, _ := io.ReadAll(resp.Body)
+
+			assert.Equal(t, cs.wantStatus, resp.StatusCode)
+			assert.Equal(t, cs.wantContentType, resp.Header.Get("Content-Type"))
+			assert.Equal(t, cs.wantBodyToBe, string(gotBody))
+		})
+	}
+}
+
 // Integration тесты
 
 // Настройка БД для интеграционных тестов
 func setupTestDB(emptyDB bool) *database.DB {
-	DB, _ := database.RunGORM("db_test.sqlite")
+	DB, _ := database.OpenGORM("db_test.sqlite")
 
 	if !emptyDB {
 		DB.MigrateQuotes()
@@ -430,17 +1168,46 @@ func setupTestDB(emptyDB bool) *database.DB {
 	return DB
 }
 
+// Адрес Redis, запущенного в процессе тестов
+var testRedisAddr string
+
+// Запускает встроенный Redis на время тестов пакета
+func TestMain(m *testing.M) {
+	server, err := miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+	testRedisAddr = server.Addr()
+
+	code := m.Run()
+
+	server.Close()
+	os.Exit(code)
+}
+
 // Настройка Кэша для интеграционных тестов
-func setupTestCache(emptyCache bool) *cache.Cache {
-	Cache, _ := cache.RunRedis("127.0.0.1:6379", "")
+func setupTestCache(emptyCache bool, DB database.Queuer) *cache.Cache {
+	Cache, _ := cache.RunRedis(config.Redis{
+		Mode:  config.RedisModeSingle,
+		Addrs: []string{testRedisAddr},
+	})
 
 	if !emptyCache {
-		Cache.PopulateCache()
+		dependencies := &Dependencies{DB: DB, Cache: Cache}
+		dependencies.SetCacheTTL(time.Minute*5, time.Minute)
+
+		dependencies.WarmUp(context.Background())
 	}
 
 	return Cache
 }
 
+// Очистка Кэша после интеграционных тестов
+func teardownTestCache(Cache *cache.Cache) {
+	Cache.Flush(context.Background())
+	Cache.Close()
+}
+
 // Integration тест для хендлера ListAll
 func TestIntegrationListAll(t *testing.T) {
 	cases := []struct {
@@ -461,6 +1228,15 @@ func TestIntegrationListAll(t *testing.T) {
 			wantStatus:   200,
 			wantBodyToBe: responses.TestQuotes,
 		},
+		{
+			name:         "filter case",
+			method:       "GET",
+			path:         "/?from_id=2&contains=3",
+			emptyDB:      false,
+			emptyCache:   true,
+			wantStatus:   200,
+			wantBodyToBe: responses.TestQuotes[2:],
+		},
 		{
 			name:         "wrong method case",
 			method:       "POST",
@@ -468,7 +1244,7 @@ func TestIntegrationListAll(t *testing.T) {
 			emptyDB:      false,
 			emptyCache:   true,
 			wantStatus:   405,
-			wantBodyToBe: responses.ErrDictionary[405],
+			wantBodyToBe: wantProblem(problem.MethodNotAllowed, ""),
 		},
 		{
 			name:         "wrong path case",
@@ -477,16 +1253,16 @@ func TestIntegrationListAll(t *testing.T) {
 			emptyDB:      false,
 			emptyCache:   true,
 			wantStatus:   404,
-			wantBodyToBe: responses.ErrDictionary[404],
+			wantBodyToBe: wantProblem(problem.NotFound, "Cannot GET /wrongpath"),
 		},
 		{
-			name:         "empty db case",
+			name:         "no schema case",
 			method:       "GET",
 			path:         "/",
 			emptyDB:      true,
 			emptyCache:   true,
-			wantStatus:   404,
-			wantBodyToBe: responses.ErrDictionary[404],
+			wantStatus:   503,
+			wantBodyToBe: wantProblem(problem.ServiceUnavailable, ""),
 		},
 	}
 
@@ -495,10 +1271,10 @@ func TestIntegrationListAll(t *testing.T) {
 			DB := setupTestDB(cs.emptyDB)
 			defer DB.TeardownDB()
 
-			Cache := setupTestCache(cs.emptyCache)
-			defer Cache.TeardownCache()
+			Cache := setupTestCache(cs.emptyCache, DB)
+			defer teardownTestCache(Cache)
 
-			Log, _ := logging.RunZap()
+			Log, _ := logging.RunZap(config.Logging{Level: config.LogLevelInfo})
 
 			dependencies := &Dependencies{
 				DB:      DB,
@@ -524,7 +1300,7 @@ func TestIntegrationListAll(t *testing.T) {
 			gotBody, _ := io.ReadAll(resp.Body)
 			gotBodyStr := string(gotBody)
 
-			wantBodyJSON, _ := json.Marshal(&cs.wantBodyToBe)
+			wantBodyJSON, _ := json.Marshal(withInstance(cs.wantBodyToBe, resp))
 			wantBodyStr := string(wantBodyJSON)
 
 			assert.JSONEq(t, wantBodyStr, gotBodyStr)
@@ -562,7 +1338,7 @@ func TestIntegrationRandomQuote(t *testing.T) {
 			emptyCache:           true,
 			wantCacheToReturnErr: false,
 			wantStatus:           405,
-			wantBodyToBe:         responses.ErrDictionary[405],
+			wantBodyToBe:         wantProblem(problem.MethodNotAllowed, ""),
 		},
 		{
 			name:                 "wrong path case",
@@ -572,17 +1348,17 @@ func TestIntegrationRandomQuote(t *testing.T) {
 			emptyCache:           true,
 			wantCacheToReturnErr: false,
 			wantStatus:           404,
-			wantBodyToBe:         responses.ErrDictionary[404],
+			wantBodyToBe:         wantProblem(problem.NotFound, "Cannot GET /wrongpath"),
 		},
 		{
-			name:                 "empty db case",
+			name:                 "no schema case",
 			method:               "GET",
 			path:                 "/random",
 			emptyDB:              true,
 			emptyCache:           true,
 			wantCacheToReturnErr: false,
-			wantStatus:           404,
-			wantBodyToBe:         responses.ErrDictionary[404],
+			wantStatus:           503,
+			wantBodyToBe:         wantProblem(problem.ServiceUnavailable, ""),
 		},
 		{
 			name:                 "quote from cache case",
@@ -601,8 +1377,8 @@ func TestIntegrationRandomQuote(t *testing.T) {
 			emptyDB:              false,
 			emptyCache:           true,
 			wantCacheToReturnErr: true,
-			wantStatus:           500,
-			wantBodyToBe:         responses.ErrDictionary[500],
+			wantStatus:           200,
+			wantBodyToBe:         1,
 		},
 	}
 
@@ -611,10 +1387,10 @@ func TestIntegrationRandomQuote(t *testing.T) {
 			DB := setupTestDB(cs.emptyDB)
 			defer DB.TeardownDB()
 
-			Cache := setupTestCache(cs.emptyCache)
-			defer Cache.TeardownCache()
+			Cache := setupTestCache(cs.emptyCache, DB)
+			defer teardownTestCache(Cache)
 
-			Log, _ := logging.RunZap()
+			Log, _ := logging.RunZap(config.Logging{Level: config.LogLevelInfo})
 
 			dependencies := &Dependencies{
 				DB:      DB,
@@ -633,7 +1409,7 @@ func TestIntegrationRandomQuote(t *testing.T) {
 			testApp.Get("/random", dependencies.RandomQuote)
 
 			if cs.wantCacheToReturnErr {
-				Cache.TeardownCache()
+				Cache.Close()
 			}
 
 			req := httptest.NewRequest(cs.method, cs.path, nil)
@@ -654,7 +1430,7 @@ func TestIntegrationRandomQuote(t *testing.T) {
 			} else {
 				gotBodyStr := string(gotBody)
 
-				wantBodyJSON, _ := json.Marshal(&cs.wantBodyToBe)
+				wantBodyJSON, _ := json.Marshal(withInstance(cs.wantBodyToBe, resp))
 				wantBodyStr := string(wantBodyJSON)
 
 				assert.JSONEq(t, wantBodyStr, gotBodyStr)
@@ -693,27 +1469,27 @@ func TestIntegrationQuoteID(t *testing.T) {
 			emptyCache:           true,
 			wantCacheToReturnErr: false,
 			wantStatus:           405,
-			wantBodyToBe:         responses.ErrDictionary[405],
+			wantBodyToBe:         wantProblem(problem.MethodNotAllowed, ""),
 		},
 		{
-			name:                 "wrong path case",
+			name:                 "non-numeric id case",
 			method:               "GET",
 			path:                 "/wrongpath",
 			emptyDB:              false,
 			emptyCache:           true,
 			wantCacheToReturnErr: false,
-			wantStatus:           404,
-			wantBodyToBe:         responses.ErrDictionary[404],
+			wantStatus:           400,
+			wantBodyToBe:         wantProblem(ErrInvalidQuoteID, "id must be an integer"),
 		},
 		{
-			name:                 "empty db case",
+			name:                 "no schema case",
 			method:               "GET",
 			path:                 "/1",
 			emptyDB:              true,
 			emptyCache:           true,
 			wantCacheToReturnErr: false,
-			wantStatus:           404,
-			wantBodyToBe:         responses.ErrDictionary[404],
+			wantStatus:           503,
+			wantBodyToBe:         wantProblem(problem.ServiceUnavailable, ""),
 		},
 		{
 			name:                 "quote from cache case",
@@ -732,8 +1508,8 @@ func TestIntegrationQuoteID(t *testing.T) {
 			emptyDB:              false,
 			emptyCache:           true,
 			wantCacheToReturnErr: true,
-			wantStatus:           500,
-			wantBodyToBe:         responses.ErrDictionary[500],
+			wantStatus:           200,
+			wantBodyToBe:         1,
 		},
 	}
 
@@ -742,10 +1518,10 @@ func TestIntegrationQuoteID(t *testing.T) {
 			DB := setupTestDB(cs.emptyDB)
 			defer DB.TeardownDB()
 
-			Cache := setupTestCache(cs.emptyCache)
-			defer Cache.TeardownCache()
+			Cache := setupTestCache(cs.emptyCache, DB)
+			defer teardownTestCache(Cache)
 
-			Log, _ := logging.RunZap()
+			Log, _ := logging.RunZap(config.Logging{Level: config.LogLevelInfo})
 
 			dependencies := &Dependencies{
 				DB:      DB,
@@ -764,7 +1540,7 @@ func TestIntegrationQuoteID(t *testing.T) {
 			testApp.Get("/:id", dependencies.QuoteID)
 
 			if cs.wantCacheToReturnErr {
-				Cache.TeardownCache()
+				Cache.Close()
 			}
 
 			req := httptest.NewRequest(cs.method, cs.path, nil)
@@ -785,7 +1561,7 @@ func TestIntegrationQuoteID(t *testing.T) {
 			} else {
 				gotBodyStr := string(gotBody)
 
-				wantBodyJSON, _ := json.Marshal(&cs.wantBodyToBe)
+				wantBodyJSON, _ := json.Marshal(withInstance(cs.wantBodyToBe, resp))
 				wantBodyStr := string(wantBodyJSON)
 
 				assert.JSONEq(t, wantBodyStr, gotBodyStr)
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/xoticdsign/returnauf/internal/health"
+	"github.com/xoticdsign/returnauf/models/responses"
+)
+
+// @description Сообщает, что процесс запущен и обрабатывает запросы. Зависимости не проверяются, поэтому эндпоинт подходит для проверки живости в оркестраторе. Аутентификация не требуется.
+//
+// @id          healthz
+// @tags        Состояние сервиса
+//
+// @summary     Проверяет живость процесса
+// @produce     json
+// @success     200 {object} responses.Health
+// @router      /healthz [get]
+func (d *Dependencies) Healthz(c *fiber.Ctx) error {
+	// Пробы приходят каждые несколько секунд, поэтому в журнал не пишутся
+	return c.JSON(responses.Health{Status: health.StatusAlive})
+}
+
+// @description Проверяет Redis и SQLite и возвращает состояние и задержку каждой зависимости в миллисекундах. Приложение не готово, если хотя бы одна зависимость недоступна, применяются миграции или сервер останавливается. Аутентификация не требуется.
+//
+// @id          readyz
+// @tags        Состояние сервиса
+//
+// @summary     Проверяет готовность принимать запросы
+// @produce     json
+// @success     200 {object} responses.Readiness
+// @failure     503 {object} responses.Readiness
+// @router      /readyz [get]
+func (d *Dependencies) Readyz(c *fiber.Ctx) error {
+	report, ok := d.Health.Check(c.UserContext())
+	if !ok {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(report)
+	}
+	return c.JSON(report)
+}
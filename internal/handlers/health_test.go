@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/xoticdsign/returnauf/internal/health"
+	"github.com/xoticdsign/returnauf/models/responses"
+)
+
+// Зависимость для тестов, возвращающая заданную ошибку
+type stubPinger struct {
+	err error
+}
+
+// Имитация метода Ping
+func (s *stubPinger) Ping(ctx context.Context) error {
+	return s.err
+}
+
+// Unit тест для хендлера Healthz
+func TestUnitHealthz(t *testing.T) {
+	dependencies := &Dependencies{}
+
+	mockApp := setupTestApp(dependencies)
+
+	mockApp.Get("/healthz", dependencies.Healthz)
+
+	resp, _ := mockApp.Test(httptest.NewRequest("GET", "/healthz", nil), -1)
+
+	var got responses.Health
+
+	json.NewDecoder(resp.Body).Decode(&got)
+
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, health.StatusAlive, got.Status)
+}
+
+// Unit тест для хендлера Readyz
+func TestUnitReadyz(t *testing.T) {
+	cases := []struct {
+		name       string
+		redisErr   error
+		sqliteErr  error
+		shutdown   bool
+		wantStatus int
+		wantReport string
+		wantChecks map[string]string
+	}{
+		{
+			name:       "ready case",
+			wantStatus: 200,
+			wantReport: health.StatusReady,
+			wantChecks: map[string]string{"redis": health.StatusUp, "sqlite": health.StatusUp},
+		},
+		{
+			name:       "migrating case",
+			sqliteErr:  errors.New("migrations in progress"),
+			wantStatus: 503,
+			wantReport: health.StatusNotReady,
+			wantChecks: map[string]string{"redis": health.StatusUp, "sqlite": health.StatusDown},
+		},
+		{
+			name:       "redis down case",
+			redisErr:   errors.New("connection refused"),
+			wantStatus: 503,
+			wantReport: health.StatusNotReady,
+			wantChecks: map[string]string{"redis": health.StatusDown, "sqlite": health.StatusUp},
+		},
+		{
+			name:       "shutting down case",
+			shutdown:   true,
+			wantStatus: 503,
+			wantReport: health.StatusNotReady,
+			wantChecks: map[string]string{},
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			dependencies := &Dependencies{
+				Health: health.New(0).
+					Add("redis", &stubPinger{err: cs.redisErr}).
+					Add("sqlite", &stubPinger{err: cs.sqliteErr}),
+			}
+			if cs.shutdown {
+				dependencies.Health.Shutdown()
+			}
+
+			mockApp := setupTestApp(dependencies)
+
+			mockApp.Get("/readyz", dependencies.Readyz)
+
+			resp, _ := mockApp.Test(httptest.NewRequest("GET", "/readyz", nil), -1)
+
+			var got responses.Readiness
+
+			json.NewDecoder(resp.Body).Decode(&got)
+
+			gotChecks := map[string]string{}
+			for name, status := range got.Checks {
+				gotChecks[name] = status.Status
+			}
+
+			assert.Equal(t, cs.wantStatus, resp.StatusCode)
+			assert.Equal(t, cs.wantReport, got.Status)
+			assert.Equal(t, cs.wantChecks, gotChecks)
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/xoticdsign/returnauf/internal/card"
+	"github.com/xoticdsign/returnauf/internal/logging"
+)
+
+// @description Возвращает цитату по её ID, отрисованную на карточке для публикации в соцсетях. Текст переносится по словам и уменьшается, чтобы поместиться на карточку. Готовая карточка сохраняется в кэш по ID цитаты, теме, размеру и формату.
+//
+// @id          quote-id-image
+// @tags        Операции с цитатами
+//
+// @summary     Предоставляет карточку цитаты по заданному ID
+// @produce     png
+// @produce     image/svg+xml
+// @param       id     path  string true  "Позволяет указать ID цитаты" example(105)
+// @param       theme  query string false "Тема карточки: light, dark или sepia" default(light)
+// @param       size   query string false "Размер карточки: og (1200x630), square (1080x1080) или story (1080x1920)" default(og)
+// @param       format query string false "Формат карточки: png или svg" default(png)
+// @security    KeyAuth
+// @success     200 {file}   file
+// @failure     400 {object} responses.Problem
+// @failure     401 {object} responses.Problem
+// @failure     404 {object} responses.Problem
+// @failure     405 {object} responses.Problem
+// @failure     500 {object} responses.Problem
+// @failure     503 {object} responses.Problem
+// @router      /{id}/image [get]
+func (d *Dependencies) QuoteIDImage(c *fiber.Ctx) error {
+	opts, err := parseCardOptions(c)
+	if err != nil {
+		return err
+	}
+
+	idInt, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return ErrInvalidQuoteID.Wrap(err, "id must be an integer")
+	}
+
+	ctx, cancel := d.context(c)
+	defer cancel()
+
+	return d.sendCard(ctx, c, idInt, opts)
+}
+
+// @description Возвращает случайную цитату, отрисованную на карточке для публикации в соцсетях. Параметры карточки те же, что и у карточки по ID.
+//
+// @id          random-quote-image
+// @tags        Операции с цитатами
+//
+// @summary     Предоставляет карточку случайной цитаты
+// @produce     png
+// @produce     image/svg+xml
+// @param       theme  query string false "Тема карточки: light, dark или sepia" default(light)
+// @param       size   query string false "Размер карточки: og (1200x630), square (1080x1080) или story (1080x1920)" default(og)
+// @param       format query string false "Формат карточки: png или svg" default(png)
+// @security    KeyAuth
+// @success     200 {file}   file
+// @failure     400 {object} responses.Problem
+// @failure     401 {object} responses.Problem
+// @failure     404 {object} responses.Problem
+// @failure     405 {object} responses.Problem
+// @failure     500 {object} responses.Problem
+// @failure     503 {object} responses.Problem
+// @router      /random/image [get]
+func (d *Dependencies) RandomQuoteImage(c *fiber.Ctx) error {
+	opts, err := parseCardOptions(c)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := d.context(c)
+	defer cancel()
+
+	idInt, err := d.RandomQuoteID(ctx)
+	if err != nil {
+		return err
+	}
+	return d.sendCard(ctx, c, idInt, opts)
+}
+
+// Разбирает параметры карточки из запроса
+func parseCardOptions(c *fiber.Ctx) (card.Options, error) {
+	opts, err := card.ParseOptions(c.Query("theme"), c.Query("size"), c.Query("format"))
+	if err != nil {
+		return card.Options{}, ErrInvalidCardOptions.Wrap(err, err.Error())
+	}
+	return opts, nil
+}
+
+// Отправляет карточку цитаты из Кэша, а при промахе - отрисовывает ее и сохраняет в Кэш
+func (d *Dependencies) sendCard(ctx context.Context, c *fiber.Ctx, idInt int, opts card.Options) error {
+	key := opts.Key(idInt)
+
+	cached, err := d.Cache.Get(ctx, key)
+	if err == nil {
+		requestLog(c).Info("Обработан запрос", logging.Int("QuoteID", idInt), logging.Bool("CacheHit", true))
+		c.Set(fiber.HeaderContentType, card.ContentTypes[opts.Format])
+
+		return c.SendString(cached)
+	}
+
+	quote, _, err := d.findQuote(ctx, idInt, strconv.Itoa(idInt))
+	if err != nil {
+		return err
+	}
+
+	image, err := card.Render(quote, opts)
+	if err != nil {
+		return fiber.ErrInternalServerError
+	}
+
+	err = d.Cache.Set(ctx, key, image, d.cacheTTL())
+	if err != nil {
+		requestLog(c).Warn("Не удалось сохранить карточку в Кэш", logging.Err(err))
+	}
+	requestLog(c).Info("Обработан запрос", logging.Int("QuoteID", idInt), logging.Bool("CacheHit", false))
+	c.Set(fiber.HeaderContentType, card.ContentTypes[opts.Format])
+
+	return c.Send(image)
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/xoticdsign/returnauf/internal/database"
+	"github.com/xoticdsign/returnauf/models/responses"
+)
+
+// Unit тест для хендлеров QuoteIDImage и RandomQuoteImage
+func TestUnitQuoteImage(t *testing.T) {
+	cases := []struct {
+		name                    string
+		path                    string
+		wantCacheGetToReturn    string
+		wantCacheGetToReturnErr error
+		wantStatus              int
+		wantContentType         string
+		wantRenderToBeCached    bool
+		wantCachedKey           string
+	}{
+		{
+			name:                    "png by id case",
+			path:                    "/1/image",
+			wantCacheGetToReturnErr: errors.New("error"),
+			wantStatus:              200,
+			wantContentType:         "image/png",
+			wantRenderToBeCached:    true,
+			wantCachedKey:           "image:1:light:og:png",
+		},
+		{
+			name:                    "svg random case",
+			path:                    "/random/image?format=svg&theme=dark&size=square",
+			wantCacheGetToReturnErr: errors.New("error"),
+			wantStatus:              200,
+			wantContentType:         "image/svg+xml",
+			wantRenderToBeCached:    true,
+			wantCachedKey:           "image:1:dark:square:svg",
+		},
+		{
+			name:                    "from cache case",
+			path:                    "/1/image?format=svg",
+			wantCacheGetToReturn:    "<svg/>",
+			wantCacheGetToReturnErr: nil,
+			wantStatus:              200,
+			wantContentType:         "image/svg+xml",
+			wantRenderToBeCached:    false,
+			wantCachedKey:           "image:1:light:og:svg",
+		},
+		{
+			name:                    "unknown theme case",
+			path:                    "/1/image?theme=neon",
+			wantCacheGetToReturnErr: errors.New("error"),
+			wantStatus:              400,
+			wantRenderToBeCached:    false,
+		},
+		{
+			name:                    "not found case",
+			path:                    "/999/image",
+			wantCacheGetToReturnErr: errors.New("error"),
+			wantStatus:              404,
+			wantRenderToBeCached:    false,
+			wantCachedKey:           "image:999:light:og:png",
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			mockDB := new(MockDB)
+			mockCache := new(MockCache)
+			mockLogger := new(MockLog)
+
+			dependencies := &Dependencies{
+				DB:      mockDB,
+				Cache:   mockCache,
+				Logger:  mockLogger,
+				Support: &MockSupport{},
+			}
+
+			mockDB.On("QuotesCount").Return(len(responses.TestQuotesForHandlers), nil)
+			mockDB.On("QuoteIDAt", mock.Anything).Return(1, nil)
+			mockDB.On("GetQuote", "1").Return(responses.TestQuotesForHandlers[1], nil)
+			mockDB.On("GetQuote", "999").Return(responses.Quote{}, database.ErrNotFound)
+
+			mockCache.On("Get", mock.MatchedBy(func(key string) bool { return key == cs.wantCachedKey })).Return(cs.wantCacheGetToReturn, cs.wantCacheGetToReturnErr)
+			mockCache.On("Get", mock.Anything).Return("", errors.New("error"))
+			mockCache.On("Set", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+			mockLogger.On("Info", mock.Anything, mock.Anything)
+			mockLogger.On("Warn", mock.Anything, mock.Anything)
+			mockLogger.On("Error", mock.Anything, mock.Anything)
+
+			mockApp := setupTestApp(dependencies)
+
+			mockApp.Get("/random/image", dependencies.RandomQuoteImage)
+			mockApp.Get("/:id/image", dependencies.QuoteIDImage)
+
+			req := httptest.NewRequest("GET", cs.path, nil)
+			resp, _ := mockApp.Test(req, -1)
+
+			assert.Equal(t, cs.wantStatus, resp.StatusCode)
+
+			if cs.wantStatus == 200 {
+				gotBody, _ := io.ReadAll(resp.Body)
+
+				assert.Equal(t, cs.wantContentType, resp.Header.Get("Content-Type"))
+				assert.NotEmpty(t, gotBody)
+			}
+
+			if cs.wantRenderToBeCached {
+				mockCache.AssertCalled(t, "Set", cs.wantCachedKey, mock.Anything, mock.Anything)
+			} else {
+				mockCache.AssertNotCalled(t, "Set", mock.MatchedBy(func(key string) bool { return key == cs.wantCachedKey }), mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/xoticdsign/returnauf/internal/database"
+	"github.com/xoticdsign/returnauf/internal/problem"
+)
+
+// Виды ошибок хендлеров. Код вида - часть API: клиенты различают по нему ошибки с
+// одинаковым статусом, поэтому коды не переименовываются
+var (
+	ErrInvalidQuoteID     = problem.Register("invalid_quote_id", fiber.StatusBadRequest, "Invalid quote ID")
+	ErrInvalidFilter      = problem.Register("invalid_filter", fiber.StatusBadRequest, "Invalid quote filter")
+	ErrInvalidFormat      = problem.Register("invalid_format", fiber.StatusBadRequest, "Unsupported format")
+	ErrInvalidInterval    = problem.Register("invalid_interval", fiber.StatusBadRequest, "Invalid stream interval")
+	ErrInvalidCardOptions = problem.Register("invalid_card_options", fiber.StatusBadRequest, "Invalid card options")
+	ErrInvalidPagination  = problem.Register("invalid_pagination", fiber.StatusBadRequest, "Invalid pagination parameter")
+	ErrInvalidImport      = problem.Register("invalid_import", fiber.StatusBadRequest, "Invalid import request")
+	ErrInvalidGraphQL     = problem.Register("invalid_graphql_request", fiber.StatusBadRequest, "Invalid GraphQL request")
+	ErrStreamLimit        = problem.Register("stream_limit_exceeded", fiber.StatusTooManyRequests, "Too many stream connections")
+)
+
+// Переводит ошибку БД в ошибку API по ее классу: некорректный запрос - 400, отсутствие
+// записи - 404, конфликт - 409, недоступность БД - 503. Ошибки без класса считаются
+// внутренними. Исходная ошибка остается причиной и пишется в журнал
+func dbError(err error) error {
+	switch {
+	case errors.Is(err, database.ErrInvalid):
+		return problem.BadRequest.Wrap(err, "")
+	case errors.Is(err, database.ErrNotFound):
+		return problem.NotFound.Wrap(err, "")
+	case errors.Is(err, database.ErrConflict):
+		return problem.Conflict.Wrap(err, "")
+	case errors.Is(err, database.ErrUnavailable):
+		return problem.ServiceUnavailable.Wrap(err, "")
+	}
+	return problem.Internal.Wrap(err, "")
+}
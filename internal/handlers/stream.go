@@ -0,0 +1,326 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/xoticdsign/returnauf/config"
+	"github.com/xoticdsign/returnauf/internal/logging"
+	"github.com/xoticdsign/returnauf/internal/middleware"
+	"github.com/xoticdsign/returnauf/internal/problem"
+	"github.com/xoticdsign/returnauf/internal/stream"
+	"github.com/xoticdsign/returnauf/models/responses"
+)
+
+// Ключ Locals, в котором контекст запроса передается в WebSocket
+const upgradeContextKey = "upgradeContext"
+
+// Интервал отправки случайных цитат в потоке по умолчанию
+const defaultStreamInterval = time.Second * 10
+
+// Минимальный и максимальный интервалы отправки случайных цитат
+const (
+	minStreamInterval = time.Second
+	maxStreamInterval = time.Hour
+)
+
+// Таймаут записи в WebSocket и в потоковый ответ. Срок записи продлевается перед
+// каждой отправкой, поэтому поток живет сколько угодно, пока клиент читает данные
+const streamWriteTimeout = time.Second * 10
+
+// Разбирает интервал потока: длительность вида 5s или число секунд
+func parseInterval(value string) (time.Duration, error) {
+	if value == "" {
+		return defaultStreamInterval, nil
+	}
+
+	interval, err := time.ParseDuration(value)
+	if err != nil {
+		seconds, err := strconv.Atoi(value)
+		if err != nil {
+			return 0, ErrInvalidInterval.New("interval must be a duration such as 5s or a number of seconds")
+		}
+		interval = time.Duration(seconds) * time.Second
+	}
+
+	if interval < minStreamInterval || interval > maxStreamInterval {
+		return 0, ErrInvalidInterval.New("interval must be between " + minStreamInterval.String() + " and " + maxStreamInterval.String())
+	}
+	return interval, nil
+}
+
+// Возвращает интервал сигналов активности
+func (d *Dependencies) heartbeat() time.Duration {
+	if d.Heartbeat <= 0 {
+		return config.DefaultStreamHeartbeat
+	}
+	return d.Heartbeat
+}
+
+// Подписывает поток на события, переводя ошибки хаба в ошибки API. Потоки считаются по
+// идентификатору ключа API, чтобы хаб не хранил сам ключ
+func (d *Dependencies) subscribe(key string) (*stream.Subscription, error) {
+	if d.Streams == nil {
+		return nil, fiber.ErrNotFound
+	}
+
+	sub, err := d.Streams.Subscribe(middleware.KeyID(key))
+	switch err {
+	case nil:
+		return sub, nil
+	case stream.ErrTooManyConnections:
+		return nil, ErrStreamLimit.Wrap(err, "the API key has reached its stream connection limit")
+	default:
+		return nil, problem.ServiceUnavailable.Wrap(err, "")
+	}
+}
+
+// Возвращает случайную цитату для потока, ограничивая каждое обращение таймаутом
+func (d *Dependencies) streamRandom(ctx context.Context) (responses.Quote, error) {
+	if d.Timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, d.Timeout)
+		defer cancel()
+	}
+	quote, _, err := d.randomQuote(ctx)
+
+	return quote, err
+}
+
+// Запись в потоковый ответ, которая продлевает срок записи в соединение и сразу
+// отправляет данные клиенту. Fasthttp задает WriteTimeout один раз на весь ответ,
+// поэтому без продления поток обрывается через WriteTimeout после открытия
+type deadlineWriter struct {
+	w    *bufio.Writer
+	conn net.Conn
+}
+
+// Продлевает срок записи и отправляет данные
+func (d *deadlineWriter) Write(p []byte) (int, error) {
+	d.conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+
+	n, err := d.w.Write(p)
+	if err != nil {
+		return n, err
+	}
+	return n, d.w.Flush()
+}
+
+// Задает потоковое тело ответа. Тело пишется после возврата из хендлера, поэтому
+// контекст записи сохраняет логгер и трассировку запроса, но не его срок, и отменяется
+// при остановке сервера
+func (d *Dependencies) streamBody(c *fiber.Ctx, write func(ctx context.Context, w *bufio.Writer)) {
+	ctx, cancel := context.WithCancel(context.WithoutCancel(c.UserContext()))
+	conn := c.Context().Conn()
+
+	var done <-chan struct{}
+	if d.Streams != nil {
+		done = d.Streams.Done()
+	}
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer cancel()
+
+		go func() {
+			select {
+			case <-done:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		bw := bufio.NewWriter(&deadlineWriter{w: w, conn: conn})
+
+		write(ctx, bw)
+		bw.Flush()
+	})
+}
+
+// Транспорт потока в формате Server-Sent Events
+type sseWriter struct {
+	w *bufio.Writer
+}
+
+// Отправляет событие с цитатой
+func (s *sseWriter) Send(event stream.Event) error {
+	data, err := json.Marshal(event.Quote)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(s.w, "event: %s\nid: %d\ndata: %s\n\n", event.Type, event.Quote.ID, data)
+	if err != nil {
+		return err
+	}
+	return s.w.Flush()
+}
+
+// Отправляет комментарий, не видимый клиенту, чтобы соединение не закрылось
+func (s *sseWriter) Heartbeat() error {
+	_, err := s.w.WriteString(": heartbeat\n\n")
+	if err != nil {
+		return err
+	}
+	return s.w.Flush()
+}
+
+// @description Открывает поток Server-Sent Events, который сразу и затем с заданным интервалом отправляет случайную цитату (событие random), а также сообщает о добавленных (created) и измененных (updated) цитатах. События created и updated отправляет только импорт через POST /admin/import на этом же экземпляре, импорт через CLI и изменения базы данных напрямую в поток не попадают. Раз в STREAM_HEARTBEAT отправляется комментарий heartbeat. Число одновременных потоков на ключ API ограничено, лишние отклоняются с кодом 429. При остановке сервера поток завершается.
+//
+// @id          stream-random
+// @tags        Потоки цитат
+//
+// @summary     Поток случайных цитат и изменений
+// @produce     text/event-stream
+// @param       interval query string false "Интервал случайных цитат: длительность (5s, 1m) или число секунд, от 1s до 1h" default(10s)
+// @security    KeyAuth
+// @success     200 {string} string
+// @failure     400 {object} responses.Problem
+// @failure     401 {object} responses.Problem
+// @failure     429 {object} responses.Problem
+// @failure     503 {object} responses.Problem
+// @router      /stream/random [get]
+func (d *Dependencies) StreamRandom(c *fiber.Ctx) error {
+	interval, err := parseInterval(c.Query("interval"))
+	if err != nil {
+		return err
+	}
+
+	sub, err := d.subscribe(c.Query(middleware.KeyParam))
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	opts := stream.Options{Interval: interval, Heartbeat: d.heartbeat()}
+	start := time.Now()
+
+	d.streamBody(c, func(ctx context.Context, w *bufio.Writer) {
+		defer sub.Close()
+
+		// Ошибка записи означает, что клиент отключился
+		stream.Run(ctx, sub, opts, d.streamRandom, &sseWriter{w: w})
+
+		logging.FromContext(ctx).Info("Обработан запрос", logging.Duration("Duration", time.Since(start)))
+	})
+
+	return nil
+}
+
+// Транспорт потока поверх WebSocket
+type wsWriter struct {
+	conn *websocket.Conn
+}
+
+// Отправляет событие с цитатой в виде JSON
+func (s *wsWriter) Send(event stream.Event) error {
+	s.conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+
+	return s.conn.WriteJSON(event)
+}
+
+// Отправляет ping, на который клиент отвечает pong
+func (s *wsWriter) Heartbeat() error {
+	return s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(streamWriteTimeout))
+}
+
+// Пропускает к WebSocket только запросы на переключение протокола
+func (d *Dependencies) WebSocketUpgrade(c *fiber.Ctx) error {
+	if !websocket.IsWebSocketUpgrade(c) {
+		return fiber.ErrUpgradeRequired
+	}
+
+	_, err := parseInterval(c.Query("interval"))
+	if err != nil {
+		return err
+	}
+
+	// Контекст с Логгером и спаном запроса переходит в соединение через Locals
+	c.Locals(upgradeContextKey, c.UserContext())
+
+	return c.Next()
+}
+
+// Возвращает контекст запроса на переключение протокола без его отмены и таймаута
+func upgradeContext(conn *websocket.Conn) context.Context {
+	ctx, ok := conn.Locals(upgradeContextKey).(context.Context)
+	if !ok {
+		return context.Background()
+	}
+	return context.WithoutCancel(ctx)
+}
+
+// @description Открывает WebSocket с теми же событиями, что и /stream/random, включая ограничения событий created и updated. Сообщения отправляются в формате JSON вида {"type": "random", "quote": {...}}, сигналы активности - кадрами ping. При превышении числа соединений на ключ API соединение закрывается с кодом 1008, при остановке сервера - с кодом 1001.
+//
+// @id          websocket
+// @tags        Потоки цитат
+//
+// @summary     WebSocket со случайными цитатами и изменениями
+// @param       interval query string false "Интервал случайных цитат: длительность (5s, 1m) или число секунд, от 1s до 1h" default(10s)
+// @security    KeyAuth
+// @success     101 {string} string
+// @failure     400 {object} responses.Problem
+// @failure     401 {object} responses.Problem
+// @failure     426 {object} responses.Problem
+// @router      /ws [get]
+func (d *Dependencies) WebSocket(conn *websocket.Conn) {
+	defer conn.Close()
+
+	// Интервал уже проверен в WebSocketUpgrade
+	interval, _ := parseInterval(conn.Query("interval"))
+
+	sub, err := d.subscribe(conn.Query(middleware.KeyParam))
+	if err != nil {
+		code := websocket.CloseGoingAway
+		if errors.Is(err, ErrStreamLimit) {
+			code = websocket.ClosePolicyViolation
+		}
+
+		// Причина закрытия ограничена 123 байтами, поэтому отправляется только описание вида
+		reason := problem.From(err).Kind.Title
+		logging.FromContext(upgradeContext(conn)).Warn(reason, logging.Err(err))
+
+		conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(streamWriteTimeout))
+		return
+	}
+	defer sub.Close()
+
+	ctx, cancel := context.WithCancel(upgradeContext(conn))
+	defer cancel()
+
+	start := time.Now()
+
+	// Сообщения клиента не ожидаются: чтение нужно, чтобы обрабатывать pong и
+	// заметить закрытие соединения
+	go func() {
+		defer cancel()
+
+		for {
+			_, _, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	opts := stream.Options{Interval: interval, Heartbeat: d.heartbeat()}
+
+	stream.Run(ctx, sub, opts, d.streamRandom, &wsWriter{conn: conn})
+
+	conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, ""), time.Now().Add(streamWriteTimeout))
+
+	logging.FromContext(ctx).Info("Обработан запрос", logging.Duration("Duration", time.Since(start)))
+}
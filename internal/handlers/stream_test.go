@@ -0,0 +1,352 @@
+package handlers
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fasthttp/websocket"
+	contribws "github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/xoticdsign/returnauf/config"
+	"github.com/xoticdsign/returnauf/internal/logging"
+	"github.com/xoticdsign/returnauf/internal/middleware"
+	"github.com/xoticdsign/returnauf/internal/stream"
+	"github.com/xoticdsign/returnauf/models/responses"
+)
+
+// Unit тест для функции parseInterval
+func TestUnitParseInterval(t *testing.T) {
+	cases := []struct {
+		name                      string
+		value                     string
+		wantParseIntervalToReturn time.Duration
+		wantParseIntervalToFail   bool
+	}{
+		{
+			name:                      "default case",
+			value:                     "",
+			wantParseIntervalToReturn: defaultStreamInterval,
+		},
+		{
+			name:                      "duration case",
+			value:                     "1m",
+			wantParseIntervalToReturn: time.Minute,
+		},
+		{
+			name:                      "seconds case",
+			value:                     "5",
+			wantParseIntervalToReturn: time.Second * 5,
+		},
+		{
+			name:                    "too short case",
+			value:                   "100ms",
+			wantParseIntervalToFail: true,
+		},
+		{
+			name:                    "too long case",
+			value:                   "2h",
+			wantParseIntervalToFail: true,
+		},
+		{
+			name:                    "garbage case",
+			value:                   "often",
+			wantParseIntervalToFail: true,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			got, gotErr := parseInterval(cs.value)
+
+			assert.Equal(t, cs.wantParseIntervalToReturn, got)
+			assert.Equal(t, cs.wantParseIntervalToFail, gotErr != nil)
+		})
+	}
+}
+
+// Unit тест для хендлера StreamRandom
+func TestUnitStreamRandom(t *testing.T) {
+	cases := []struct {
+		name              string
+		path              string
+		openStreams       int
+		closeHub          bool
+		wantStatus        int
+		wantBodyToContain string
+	}{
+		{
+			name:              "general case",
+			path:              "/stream/random?returnauf-key=key",
+			wantStatus:        200,
+			wantBodyToContain: "event: random\nid: 1\ndata: {\"ID\":1,\"Quote\":\"Mock quote 1\"}\n\n",
+		},
+		{
+			name:       "bad interval case",
+			path:       "/stream/random?returnauf-key=key&interval=0",
+			wantStatus: 400,
+		},
+		{
+			name:        "too many streams case",
+			path:        "/stream/random?returnauf-key=key",
+			openStreams: 2,
+			wantStatus:  429,
+		},
+		{
+			name:       "closed hub case",
+			path:       "/stream/random?returnauf-key=key",
+			closeHub:   true,
+			wantStatus: 503,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			mockDB := new(MockDB)
+			mockCache := new(MockCache)
+			mockLogger := new(MockLog)
+
+			dependencies := &Dependencies{
+				DB:      mockDB,
+				Cache:   mockCache,
+				Logger:  mockLogger,
+				Support: &MockSupport{},
+				Streams: stream.New(2),
+			}
+
+			for i := 0; i < cs.openStreams; i++ {
+				dependencies.Streams.Subscribe(middleware.KeyID("key"))
+			}
+			if cs.closeHub {
+				dependencies.Streams.Close()
+			}
+
+			mockDB.On("QuotesCount").Return(len(responses.TestQuotesForHandlers), nil)
+			mockDB.On("QuoteIDAt", mock.Anything).Return(1, nil)
+
+			mockCache.On("Get", "1").Return("Mock quote 1", nil)
+
+			mockLogger.On("Info", mock.Anything, mock.Anything)
+			mockLogger.On("Warn", mock.Anything, mock.Anything)
+			mockLogger.On("Error", mock.Anything, mock.Anything)
+
+			mockApp := setupTestApp(dependencies)
+
+			mockApp.Get("/stream/random", dependencies.StreamRandom)
+
+			// Поток завершается при остановке хаба
+			time.AfterFunc(time.Millisecond*100, dependencies.Streams.Close)
+
+			req := httptest.NewRequest("GET", cs.path, nil)
+			resp, _ := mockApp.Test(req, 2000)
+
+			assert.Equal(t, cs.wantStatus, resp.StatusCode)
+
+			if cs.wantBodyToContain != "" {
+				gotBody, _ := io.ReadAll(resp.Body)
+
+				assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+				assert.Contains(t, string(gotBody), cs.wantBodyToContain)
+			}
+		})
+	}
+}
+
+// Unit тест для хендлера StreamRandom на настоящем соединении: поток живет дольше
+// WriteTimeout и пишет журнал после завершения
+func TestUnitStreamRandomWriteTimeout(t *testing.T) {
+	const writeTimeout = time.Millisecond * 200
+
+	mockDB := new(MockDB)
+	mockCache := new(MockCache)
+	recorder := logging.NewRecorder()
+
+	dependencies := &Dependencies{
+		DB:        mockDB,
+		Cache:     mockCache,
+		Logger:    recorder,
+		Support:   &MockSupport{},
+		Streams:   stream.New(1),
+		Heartbeat: time.Millisecond * 50,
+	}
+
+	mockDB.On("QuotesCount").Return(len(responses.TestQuotesForHandlers), nil)
+	mockDB.On("QuoteIDAt", mock.Anything).Return(1, nil)
+
+	mockCache.On("Get", "1").Return("Mock quote 1", nil)
+
+	mockApp := fiber.New(fiber.Config{
+		WriteTimeout: writeTimeout,
+		ErrorHandler: dependencies.Error,
+	})
+	mockApp.Use(logging.Middleware(dependencies.Logger))
+	mockApp.Get("/stream/random", dependencies.StreamRandom)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go mockApp.Listener(listener)
+	defer mockApp.Shutdown()
+
+	resp, err := http.Get("http://" + listener.Addr().String() + "/stream/random?returnauf-key=key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	assert.Equal(t, 200, resp.StatusCode)
+
+	reader := bufio.NewReader(resp.Body)
+	start := time.Now()
+	heartbeats := 0
+
+	for time.Since(start) < writeTimeout*5 {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("stream closed after %s: %v", time.Since(start), err)
+		}
+		if line == ": heartbeat\n" && time.Since(start) > writeTimeout*2 {
+			heartbeats++
+		}
+	}
+
+	assert.Greater(t, heartbeats, 0)
+
+	dependencies.Streams.Close()
+	io.ReadAll(reader)
+
+	assert.Eventually(t, func() bool {
+		for _, entry := range recorder.Entries() {
+			if entry.Message == "Обработан запрос" {
+				_, ok := entry.Fields["Duration"]
+				return ok
+			}
+		}
+		return false
+	}, time.Second, time.Millisecond*10)
+}
+
+// Unit тест для хендлеров WebSocketUpgrade и WebSocket
+func TestUnitWebSocket(t *testing.T) {
+	mockDB := new(MockDB)
+	mockCache := new(MockCache)
+	recorder := logging.NewRecorder()
+
+	dependencies := &Dependencies{
+		DB:      mockDB,
+		Cache:   mockCache,
+		Logger:  recorder,
+		Support: &MockSupport{},
+		Streams: stream.New(1),
+	}
+
+	mockDB.On("QuotesCount").Return(len(responses.TestQuotesForHandlers), nil)
+	mockDB.On("QuoteIDAt", mock.Anything).Return(1, nil)
+
+	mockCache.On("Get", "1").Return("Mock quote 1", nil)
+
+	mockApp := setupTestApp(dependencies)
+
+	mockApp.Get("/ws", dependencies.WebSocketUpgrade, contribws.New(dependencies.WebSocket))
+
+	t.Run("not upgrade case", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/ws", nil)
+		resp, _ := mockApp.Test(req, -1)
+
+		assert.Equal(t, 426, resp.StatusCode)
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go mockApp.Listener(listener)
+	defer mockApp.Shutdown()
+
+	url := "ws://" + listener.Addr().String() + "/ws?returnauf-key=key"
+
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	t.Run("random event case", func(t *testing.T) {
+		var got stream.Event
+
+		err := conn.ReadJSON(&got)
+
+		assert.NoError(t, err)
+		assert.Equal(t, stream.Event{Type: stream.EventRandom, Quote: responses.Quote{ID: 1, Quote: "Mock quote 1"}}, got)
+	})
+
+	t.Run("published event case", func(t *testing.T) {
+		want := stream.Event{Type: stream.EventUpdated, Quote: responses.Quote{ID: 2, Quote: "Changed quote 2"}}
+
+		dependencies.Streams.Publish(want)
+
+		var got stream.Event
+
+		err := conn.ReadJSON(&got)
+
+		assert.NoError(t, err)
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("too many connections case", func(t *testing.T) {
+		extra, _, err := websocket.DefaultDialer.Dial(url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer extra.Close()
+
+		_, _, err = extra.ReadMessage()
+
+		var closeErr *websocket.CloseError
+
+		assert.True(t, errors.As(err, &closeErr))
+		assert.Equal(t, websocket.ClosePolicyViolation, closeErr.Code)
+	})
+
+	t.Run("shutdown case", func(t *testing.T) {
+		dependencies.Streams.Close()
+
+		_, _, err := conn.ReadMessage()
+
+		var closeErr *websocket.CloseError
+
+		assert.True(t, errors.As(err, &closeErr))
+		assert.Equal(t, websocket.CloseGoingAway, closeErr.Code)
+	})
+
+	t.Run("logging case", func(t *testing.T) {
+		var levels []string
+
+		assert.Eventually(t, func() bool {
+			levels = nil
+			for _, entry := range recorder.Entries() {
+				if entry.Fields["Path"] != "/ws" {
+					continue
+				}
+				levels = append(levels, entry.Level)
+
+				if entry.Message == "Обработан запрос" {
+					assert.Contains(t, entry.Fields, "Duration")
+				}
+			}
+			return len(levels) == 3
+		}, time.Second, time.Millisecond*10)
+
+		// Запрос без переключения протокола, отклоненное соединение и закрытое при остановке
+		assert.ElementsMatch(t, []string{config.LogLevelWarn, config.LogLevelWarn, config.LogLevelInfo}, levels)
+	})
+}
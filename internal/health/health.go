@@ -0,0 +1,125 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/xoticdsign/returnauf/models/responses"
+)
+
+// Состояния зависимостей и приложения
+const (
+	StatusAlive    = "alive"
+	StatusUp       = "up"
+	StatusDown     = "down"
+	StatusReady    = "ready"
+	StatusNotReady = "not_ready"
+)
+
+// Причина неготовности во время остановки
+const ReasonShuttingDown = "shutting down"
+
+// Время на проверку зависимостей по умолчанию
+const DefaultTimeout = time.Second * 2
+
+// Интерфейс зависимости, доступность которой можно проверить
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Именованная проверка зависимости
+type check struct {
+	name   string
+	pinger Pinger
+}
+
+// Структура, отвечающая за готовность приложения. Проверяет зависимости и помнит,
+// что приложение останавливается
+type Health struct {
+	checks   []check
+	timeout  time.Duration
+	stopping atomic.Bool
+}
+
+// Создает проверку готовности с ограничением времени на все зависимости
+func New(timeout time.Duration) *Health {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Health{timeout: timeout}
+}
+
+// Добавляет зависимость под заданным именем
+func (h *Health) Add(name string, pinger Pinger) *Health {
+	h.checks = append(h.checks, check{name: name, pinger: pinger})
+
+	return h
+}
+
+// Отмечает, что приложение останавливается. После этого оно больше не готово
+func (h *Health) Shutdown() {
+	h.stopping.Store(true)
+}
+
+// Проверяет зависимости параллельно и возвращает отчет. Во время остановки
+// зависимости не проверяются
+func (h *Health) Check(ctx context.Context) (responses.Readiness, bool) {
+	report := responses.Readiness{
+		Status: StatusReady,
+		Checks: map[string]responses.DependencyStatus{},
+	}
+
+	if h.stopping.Load() {
+		report.Status = StatusNotReady
+		report.Reason = ReasonShuttingDown
+
+		return report, false
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, ch := range h.checks {
+		wg.Add(1)
+
+		go func(ch check) {
+			defer wg.Done()
+
+			status := ping(ctx, ch.pinger)
+
+			mu.Lock()
+			report.Checks[ch.name] = status
+			mu.Unlock()
+		}(ch)
+	}
+	wg.Wait()
+
+	for _, status := range report.Checks {
+		if status.Status != StatusUp {
+			report.Status = StatusNotReady
+		}
+	}
+	return report, report.Status == StatusReady
+}
+
+// Проверяет одну зависимость и измеряет задержку
+func ping(ctx context.Context, pinger Pinger) responses.DependencyStatus {
+	start := time.Now()
+
+	err := pinger.Ping(ctx)
+
+	status := responses.DependencyStatus{
+		Status:  StatusUp,
+		Latency: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		status.Status = StatusDown
+		status.Error = err.Error()
+	}
+	return status
+}
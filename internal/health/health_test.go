@@ -0,0 +1,101 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Зависимость для тестов, возвращающая заданную ошибку
+type stubPinger struct {
+	err   error
+	delay time.Duration
+}
+
+// Имитация метода Ping
+func (s *stubPinger) Ping(ctx context.Context) error {
+	if s.delay > 0 {
+		select {
+		case <-time.After(s.delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return s.err
+}
+
+// Unit тест для функции Check
+func TestUnitCheck(t *testing.T) {
+	cases := []struct {
+		name          string
+		redis         *stubPinger
+		sqlite        *stubPinger
+		shutdown      bool
+		wantStatus    string
+		wantReason    string
+		wantChecks    map[string]string
+		wantCheckToOK bool
+	}{
+		{
+			name:          "ready case",
+			redis:         &stubPinger{},
+			sqlite:        &stubPinger{},
+			wantStatus:    StatusReady,
+			wantChecks:    map[string]string{"redis": StatusUp, "sqlite": StatusUp},
+			wantCheckToOK: true,
+		},
+		{
+			name:          "dependency down case",
+			redis:         &stubPinger{err: errors.New("connection refused")},
+			sqlite:        &stubPinger{},
+			wantStatus:    StatusNotReady,
+			wantChecks:    map[string]string{"redis": StatusDown, "sqlite": StatusUp},
+			wantCheckToOK: false,
+		},
+		{
+			name:          "timeout case",
+			redis:         &stubPinger{},
+			sqlite:        &stubPinger{delay: time.Second},
+			wantStatus:    StatusNotReady,
+			wantChecks:    map[string]string{"redis": StatusUp, "sqlite": StatusDown},
+			wantCheckToOK: false,
+		},
+		{
+			name:          "shutting down case",
+			redis:         &stubPinger{},
+			sqlite:        &stubPinger{},
+			shutdown:      true,
+			wantStatus:    StatusNotReady,
+			wantReason:    ReasonShuttingDown,
+			wantChecks:    map[string]string{},
+			wantCheckToOK: false,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			h := New(time.Millisecond*50).Add("redis", cs.redis).Add("sqlite", cs.sqlite)
+
+			if cs.shutdown {
+				h.Shutdown()
+			}
+
+			got, gotOK := h.Check(context.Background())
+
+			gotChecks := map[string]string{}
+			for name, status := range got.Checks {
+				gotChecks[name] = status.Status
+
+				assert.Equal(t, status.Status == StatusDown, status.Error != "")
+			}
+
+			assert.Equal(t, cs.wantStatus, got.Status)
+			assert.Equal(t, cs.wantReason, got.Reason)
+			assert.Equal(t, cs.wantChecks, gotChecks)
+			assert.Equal(t, cs.wantCheckToOK, gotOK)
+		})
+	}
+}
@@ -0,0 +1,355 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/xoticdsign/returnauf/internal/database"
+	"github.com/xoticdsign/returnauf/models/responses"
+)
+
+// Поддерживаемые форматы файлов импорта
+const (
+	FormatCSV    = "csv"
+	FormatJSON   = "json"
+	FormatNDJSON = "ndjson"
+)
+
+// Поля responses.Quote, в которые можно отобразить колонки
+const (
+	FieldID    = "id"
+	FieldQuote = "quote"
+)
+
+// Ошибка неизвестного формата файла
+var ErrUnknownFormat = errors.New("unknown import format")
+
+// Ошибка неверного отображения колонок
+var ErrBadMapping = errors.New("bad column mapping, expected column:field[,column:field]")
+
+// Определяет формат по явному значению, имени файла или Content-Type
+func DetectFormat(format string, filename string, contentType string) (string, error) {
+	format = strings.ToLower(format)
+
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(filename)) {
+		case ".csv":
+			format = FormatCSV
+		case ".json":
+			format = FormatJSON
+		case ".ndjson", ".jsonl":
+			format = FormatNDJSON
+		}
+	}
+
+	if format == "" {
+		contentType = strings.ToLower(contentType)
+
+		switch {
+		case strings.Contains(contentType, "csv"):
+			format = FormatCSV
+		case strings.Contains(contentType, "ndjson"), strings.Contains(contentType, "jsonl"):
+			format = FormatNDJSON
+		case strings.Contains(contentType, "json"):
+			format = FormatJSON
+		}
+	}
+
+	switch format {
+	case FormatCSV, FormatJSON, FormatNDJSON:
+		return format, nil
+	}
+	return "", ErrUnknownFormat
+}
+
+// Разбирает отображение колонок вида "text:quote,num:id"
+func ParseMapping(s string) (map[string]string, error) {
+	mapping := map[string]string{}
+
+	if strings.TrimSpace(s) == "" {
+		return mapping, nil
+	}
+
+	for _, pair := range strings.Split(s, ",") {
+		column, field, ok := strings.Cut(pair, ":")
+
+		column = strings.ToLower(strings.TrimSpace(column))
+		field = strings.ToLower(strings.TrimSpace(field))
+
+		if !ok || column == "" || (field != FieldID && field != FieldQuote) {
+			return nil, ErrBadMapping
+		}
+		mapping[column] = field
+	}
+	return mapping, nil
+}
+
+// Структура с промежуточной записью до проверки
+type record struct {
+	row    int
+	fields map[string]string
+	err    error
+}
+
+// Разбирает файл импорта и проверяет каждую строку. Возвращает корректные цитаты и
+// ошибки по строкам. Ошибка возвращается, только если файл не удалось прочитать целиком
+func Parse(r io.Reader, format string, mapping map[string]string) ([]responses.Quote, []responses.ImportRowError, error) {
+	var records []record
+	var err error
+
+	switch format {
+	case FormatCSV:
+		records, err = readCSV(r, mapping)
+	case FormatJSON:
+		records, err = readJSON(r, mapping)
+	case FormatNDJSON:
+		records, err = readNDJSON(r, mapping)
+	default:
+		return nil, nil, ErrUnknownFormat
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var quotes []responses.Quote
+	var rowErrors []responses.ImportRowError
+
+	seen := map[int]int{}
+
+	for _, rec := range records {
+		quote, err := validate(rec.fields)
+		if rec.err != nil {
+			err = rec.err
+		}
+		if err != nil {
+			rowErrors = append(rowErrors, responses.ImportRowError{
+				Row:     rec.row,
+				ID:      quote.ID,
+				Message: err.Error(),
+			})
+			continue
+		}
+
+		if first, ok := seen[quote.ID]; ok {
+			rowErrors = append(rowErrors, responses.ImportRowError{
+				Row:     rec.row,
+				ID:      quote.ID,
+				Message: fmt.Sprintf("duplicate id, first seen on row %d", first),
+			})
+			continue
+		}
+		seen[quote.ID] = rec.row
+
+		quotes = append(quotes, quote)
+	}
+	return quotes, rowErrors, nil
+}
+
+// Проверяет поля записи и собирает из них цитату
+func validate(fields map[string]string) (responses.Quote, error) {
+	var quote responses.Quote
+
+	rawID, ok := fields[FieldID]
+	if !ok || strings.TrimSpace(rawID) == "" {
+		return quote, errors.New("id is required")
+	}
+
+	id, err := strconv.Atoi(strings.TrimSpace(rawID))
+	if err != nil {
+		return quote, fmt.Errorf("id %q is not an integer", rawID)
+	}
+	quote.ID = id
+
+	if id <= 0 {
+		return quote, errors.New("id must be positive")
+	}
+
+	text := strings.TrimSpace(fields[FieldQuote])
+	if text == "" {
+		return quote, errors.New("quote is required")
+	}
+	quote.Quote = text
+
+	return quote, nil
+}
+
+// Возвращает поле цитаты, соответствующее колонке
+func resolve(column string, mapping map[string]string) (string, bool) {
+	column = strings.ToLower(strings.TrimSpace(column))
+
+	if field, ok := mapping[column]; ok {
+		return field, true
+	}
+	if column == FieldID || column == FieldQuote {
+		return column, true
+	}
+	return "", false
+}
+
+// Читает CSV с обязательной строкой заголовков
+func readCSV(r io.Reader, mapping map[string]string) ([]record, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	fieldAt := map[int]string{}
+	for i, column := range header {
+		if i == 0 {
+			column = strings.TrimPrefix(column, "\ufeff")
+		}
+		if field, ok := resolve(column, mapping); ok {
+			fieldAt[i] = field
+		}
+	}
+
+	var records []record
+
+	for row := 2; ; row++ {
+		values, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		fields := map[string]string{}
+		for i, value := range values {
+			if field, ok := fieldAt[i]; ok {
+				fields[field] = value
+			}
+		}
+		records = append(records, record{row: row, fields: fields})
+	}
+	return records, nil
+}
+
+// Читает JSON-массив объектов
+func readJSON(r io.Reader, mapping map[string]string) ([]record, error) {
+	var objects []map[string]interface{}
+
+	err := json.NewDecoder(r).Decode(&objects)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]record, len(objects))
+	for i, object := range objects {
+		records[i] = record{row: i + 1, fields: fromObject(object, mapping)}
+	}
+	return records, nil
+}
+
+// Читает JSON-объекты, по одному на строку
+func readNDJSON(r io.Reader, mapping map[string]string) ([]record, error) {
+	var records []record
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for row := 1; scanner.Scan(); row++ {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var object map[string]interface{}
+
+		err := json.Unmarshal(line, &object)
+		if err != nil {
+			records = append(records, record{row: row, err: fmt.Errorf("invalid json: %w", err)})
+			continue
+		}
+		records = append(records, record{row: row, fields: fromObject(object, mapping)})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// Приводит значения JSON-объекта к строкам полей цитаты
+func fromObject(object map[string]interface{}, mapping map[string]string) map[string]string {
+	fields := map[string]string{}
+
+	for key, value := range object {
+		field, ok := resolve(key, mapping)
+		if !ok {
+			continue
+		}
+
+		switch v := value.(type) {
+		case string:
+			fields[field] = v
+		case float64:
+			fields[field] = strconv.FormatFloat(v, 'f', -1, 64)
+		case nil:
+		default:
+			fields[field] = fmt.Sprint(v)
+		}
+	}
+	return fields
+}
+
+// Структура с параметрами импорта
+type Options struct {
+	Format  string
+	Policy  string
+	DryRun  bool
+	Mapping map[string]string
+}
+
+// Разбирает файл и, если все строки корректны, записывает цитаты в БД одной
+// транзакцией. При ошибках в строках БД не изменяется, а ошибки возвращаются в
+// отчете. Вместе с отчетом возвращается результат записи с добавленными и
+// измененными цитатами
+func Import(ctx context.Context, r io.Reader, opts Options, db database.Importer) (responses.ImportReport, database.ImportResult, error) {
+	report := responses.ImportReport{
+		Format: opts.Format,
+		Policy: opts.Policy,
+		DryRun: opts.DryRun,
+	}
+
+	if opts.Policy != database.PolicyUpsert && opts.Policy != database.PolicySkip {
+		return report, database.ImportResult{}, database.ErrUnknownPolicy
+	}
+
+	quotes, rowErrors, err := Parse(r, opts.Format, opts.Mapping)
+	if err != nil {
+		return report, database.ImportResult{}, err
+	}
+
+	report.Total = len(quotes) + len(rowErrors)
+	report.Errors = rowErrors
+
+	if len(rowErrors) > 0 {
+		return report, database.ImportResult{}, nil
+	}
+
+	result, err := db.ImportQuotes(ctx, quotes, opts.Policy, opts.DryRun)
+	if err != nil {
+		return report, database.ImportResult{}, err
+	}
+
+	report.Inserted = result.Inserted
+	report.Updated = result.Updated
+	report.Skipped = result.Skipped
+
+	return report, result, nil
+}
@@ -0,0 +1,267 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/xoticdsign/returnauf/internal/database"
+	"github.com/xoticdsign/returnauf/models/responses"
+)
+
+// Имитация БД, реализующая методы Importer
+type stubImporter struct {
+	called bool
+	err    error
+}
+
+// Имитация метода ImportQuotes
+func (s *stubImporter) ImportQuotes(ctx context.Context, quotes []responses.Quote, policy string, dryRun bool) (database.ImportResult, error) {
+	s.called = true
+
+	return database.ImportResult{Inserted: len(quotes)}, s.err
+}
+
+// Unit тест для функции DetectFormat
+func TestUnitDetectFormat(t *testing.T) {
+	cases := []struct {
+		name        string
+		format      string
+		filename    string
+		contentType string
+		want        string
+		wantErr     error
+	}{
+		{
+			name:    "explicit format case",
+			format:  "CSV",
+			want:    FormatCSV,
+			wantErr: nil,
+		},
+		{
+			name:     "file extension case",
+			filename: "quotes.jsonl",
+			want:     FormatNDJSON,
+			wantErr:  nil,
+		},
+		{
+			name:        "content type case",
+			contentType: "application/json; charset=utf-8",
+			want:        FormatJSON,
+			wantErr:     nil,
+		},
+		{
+			name:    "unknown format case",
+			format:  "xlsx",
+			want:    "",
+			wantErr: ErrUnknownFormat,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			got, gotErr := DetectFormat(cs.format, cs.filename, cs.contentType)
+
+			assert.Equal(t, cs.want, got)
+			assert.Equal(t, cs.wantErr, gotErr)
+		})
+	}
+}
+
+// Unit тест для функции ParseMapping
+func TestUnitParseMapping(t *testing.T) {
+	cases := []struct {
+		name    string
+		input   string
+		want    map[string]string
+		wantErr error
+	}{
+		{
+			name:    "general case",
+			input:   "Text:quote, num:ID",
+			want:    map[string]string{"text": FieldQuote, "num": FieldID},
+			wantErr: nil,
+		},
+		{
+			name:    "empty case",
+			input:   "",
+			want:    map[string]string{},
+			wantErr: nil,
+		},
+		{
+			name:    "unknown field case",
+			input:   "text:author",
+			want:    nil,
+			wantErr: ErrBadMapping,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			got, gotErr := ParseMapping(cs.input)
+
+			assert.Equal(t, cs.want, got)
+			assert.Equal(t, cs.wantErr, gotErr)
+		})
+	}
+}
+
+// Unit тест для функции Parse
+func TestUnitParse(t *testing.T) {
+	cases := []struct {
+		name           string
+		format         string
+		mapping        map[string]string
+		input          string
+		wantQuotes     []responses.Quote
+		wantErrorRows  []int
+		wantParseToErr bool
+	}{
+		{
+			name:   "csv case",
+			format: FormatCSV,
+			input:  "ID,Quote\n1,Mock quote 1\n2,\"Mock, quote 2\"\n",
+			wantQuotes: []responses.Quote{
+				{ID: 1, Quote: "Mock quote 1"},
+				{ID: 2, Quote: "Mock, quote 2"},
+			},
+			wantErrorRows: nil,
+		},
+		{
+			name:    "csv mapping case",
+			format:  FormatCSV,
+			mapping: map[string]string{"text": FieldQuote, "num": FieldID},
+			input:   "num,author,text\n1,someone,Mock quote 1\n",
+			wantQuotes: []responses.Quote{
+				{ID: 1, Quote: "Mock quote 1"},
+			},
+			wantErrorRows: nil,
+		},
+		{
+			name:   "csv invalid rows case",
+			format: FormatCSV,
+			input:  "id,quote\nx,Mock quote\n-1,Mock quote\n3,\n4,Mock quote 4\n4,Mock quote 4 again\n",
+			wantQuotes: []responses.Quote{
+				{ID: 4, Quote: "Mock quote 4"},
+			},
+			wantErrorRows: []int{2, 3, 4, 6},
+		},
+		{
+			name:   "json case",
+			format: FormatJSON,
+			input:  `[{"ID": 1, "Quote": "Mock quote 1"}, {"id": "2", "quote": "Mock quote 2"}, {"quote": "no id"}]`,
+			wantQuotes: []responses.Quote{
+				{ID: 1, Quote: "Mock quote 1"},
+				{ID: 2, Quote: "Mock quote 2"},
+			},
+			wantErrorRows: []int{3},
+		},
+		{
+			name:   "ndjson case",
+			format: FormatNDJSON,
+			input:  "{\"ID\": 1, \"Quote\": \"Mock quote 1\"}\n\n{broken\n{\"ID\": 3, \"Quote\": \"Mock quote 3\"}\n",
+			wantQuotes: []responses.Quote{
+				{ID: 1, Quote: "Mock quote 1"},
+				{ID: 3, Quote: "Mock quote 3"},
+			},
+			wantErrorRows: []int{3},
+		},
+		{
+			name:           "broken json case",
+			format:         FormatJSON,
+			input:          `{"ID": 1}`,
+			wantParseToErr: true,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			gotQuotes, gotErrors, gotErr := Parse(strings.NewReader(cs.input), cs.format, cs.mapping)
+			if cs.wantParseToErr {
+				assert.NotNil(t, gotErr)
+				return
+			}
+
+			assert.Nil(t, gotErr)
+			assert.Equal(t, cs.wantQuotes, gotQuotes)
+
+			var gotErrorRows []int
+			for _, e := range gotErrors {
+				gotErrorRows = append(gotErrorRows, e.Row)
+			}
+
+			assert.Equal(t, cs.wantErrorRows, gotErrorRows)
+		})
+	}
+}
+
+// Unit тест для функции Import
+func TestUnitImport(t *testing.T) {
+	cases := []struct {
+		name                  string
+		input                 string
+		policy                string
+		dbErr                 error
+		wantImporterCalled    bool
+		wantInserted          int
+		wantErrors            int
+		wantImportToReturnErr error
+	}{
+		{
+			name:                  "general case",
+			input:                 "id,quote\n1,Mock quote 1\n2,Mock quote 2\n",
+			policy:                database.PolicyUpsert,
+			wantImporterCalled:    true,
+			wantInserted:          2,
+			wantErrors:            0,
+			wantImportToReturnErr: nil,
+		},
+		{
+			name:                  "invalid rows case",
+			input:                 "id,quote\n1,Mock quote 1\nx,Mock quote 2\n",
+			policy:                database.PolicyUpsert,
+			wantImporterCalled:    false,
+			wantInserted:          0,
+			wantErrors:            1,
+			wantImportToReturnErr: nil,
+		},
+		{
+			name:                  "unknown policy case",
+			input:                 "id,quote\n1,Mock quote 1\n",
+			policy:                "replace",
+			wantImporterCalled:    false,
+			wantInserted:          0,
+			wantErrors:            0,
+			wantImportToReturnErr: database.ErrUnknownPolicy,
+		},
+		{
+			name:                  "db error case",
+			input:                 "id,quote\n1,Mock quote 1\n",
+			policy:                database.PolicySkip,
+			dbErr:                 errors.New("error"),
+			wantImporterCalled:    true,
+			wantInserted:          0,
+			wantErrors:            0,
+			wantImportToReturnErr: errors.New("error"),
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			db := &stubImporter{err: cs.dbErr}
+
+			report, _, gotErr := Import(context.Background(), strings.NewReader(cs.input), Options{
+				Format: FormatCSV,
+				Policy: cs.policy,
+			}, db)
+
+			assert.Equal(t, cs.wantImportToReturnErr, gotErr)
+			assert.Equal(t, cs.wantImporterCalled, db.called)
+			assert.Equal(t, cs.wantInserted, report.Inserted)
+			assert.Len(t, report.Errors, cs.wantErrors)
+		})
+	}
+}
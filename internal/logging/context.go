@@ -0,0 +1,110 @@
+package logging
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Тип ключа контекста с Логгером
+type loggerKey struct{}
+
+// Логгер, который используется, если в контексте Логгера нет
+var fallback atomic.Pointer[Logger]
+
+// Логгер, который ничего не пишет
+type nop struct{}
+
+// Ничего не делает
+func (nop) Debug(message string, fields ...Field) {}
+
+// Ничего не делает
+func (nop) Info(message string, fields ...Field) {}
+
+// Ничего не делает
+func (nop) Warn(message string, fields ...Field) {}
+
+// Ничего не делает
+func (nop) Error(message string, fields ...Field) {}
+
+// Возвращает тот же Логгер
+func (n nop) With(fields ...Field) Logger {
+	return n
+}
+
+// Возвращает Логгер, который ничего не пишет
+func Nop() Logger {
+	return nop{}
+}
+
+// Задает Логгер, который FromContext возвращает для контекста без Логгера, например в
+// фоновых задачах. Nil возвращает Логгер, который ничего не пишет
+func SetDefault(logger Logger) {
+	if logger == nil {
+		logger = Nop()
+	}
+	fallback.Store(&logger)
+}
+
+// Возвращает Логгер по умолчанию
+func Default() Logger {
+	if logger := fallback.Load(); logger != nil {
+		return *logger
+	}
+	return Nop()
+}
+
+// Возвращает контекст с Логгером. Nil Логгер оставляет контекст без изменений
+func NewContext(ctx context.Context, logger Logger) context.Context {
+	if logger == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// Возвращает Логгер из контекста, а если его нет или контекст nil - Логгер по умолчанию.
+// Результат никогда не бывает nil
+func FromContext(ctx context.Context) Logger {
+	if ctx != nil {
+		if logger, ok := ctx.Value(loggerKey{}).(Logger); ok {
+			return logger
+		}
+	}
+	return Default()
+}
+
+// Возвращает контекст, Логгер которого добавляет поля к каждой записи
+func With(ctx context.Context, fields ...Field) context.Context {
+	return NewContext(ctx, FromContext(ctx).With(fields...))
+}
+
+// Возвращает обработчик, который кладет в контекст запроса Логгер с UUID, методом и путем
+// запроса, а если запрос трассируется - и с TraceID. Должен идти после requestid и
+// промежуточного обработчика трассировки, иначе UUID и TraceID не попадут в записи. Для
+// nil Логгера используется Логгер по умолчанию
+func Middleware(logger Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		base := logger
+		if base == nil {
+			base = Default()
+		}
+
+		uuid, _ := c.Locals("uuid").(string)
+
+		fields := []Field{
+			String("UUID", uuid),
+			String("Method", c.Method()),
+			String("Path", c.Path()),
+		}
+
+		span := trace.SpanContextFromContext(c.UserContext())
+		if span.HasTraceID() {
+			fields = append(fields, String("TraceID", span.TraceID().String()))
+		}
+
+		c.SetUserContext(NewContext(c.UserContext(), base.With(fields...)))
+		return c.Next()
+	}
+}
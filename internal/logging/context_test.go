@@ -0,0 +1,171 @@
+package logging
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/xoticdsign/returnauf/config"
+)
+
+// Unit тест для функции FromContext
+func TestUnitFromContext(t *testing.T) {
+	defer SetDefault(nil)
+
+	recorder := NewRecorder()
+	fallback := NewRecorder()
+
+	cases := []struct {
+		name        string
+		ctx         context.Context
+		setDefault  bool
+		wantEntries int
+		wantDefault int
+	}{
+		{
+			name:        "logger in context case",
+			ctx:         NewContext(context.Background(), recorder),
+			wantEntries: 1,
+		},
+		{
+			name:        "empty context without default case",
+			ctx:         context.Background(),
+			wantEntries: 0,
+		},
+		{
+			name:        "nil context without default case",
+			ctx:         nil,
+			wantEntries: 0,
+		},
+		{
+			name:        "empty context with default case",
+			ctx:         context.Background(),
+			setDefault:  true,
+			wantDefault: 1,
+		},
+		{
+			name:        "nil logger in context case",
+			ctx:         NewContext(context.Background(), nil),
+			setDefault:  true,
+			wantDefault: 1,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			recorder.store.entries = nil
+			fallback.store.entries = nil
+
+			SetDefault(nil)
+			if cs.setDefault {
+				SetDefault(fallback)
+			}
+
+			got := FromContext(cs.ctx)
+
+			assert.NotNil(t, got)
+			assert.NotPanics(t, func() { got.Info("Обработан запрос") })
+			assert.Len(t, recorder.Entries(), cs.wantEntries)
+			assert.Len(t, fallback.Entries(), cs.wantDefault)
+		})
+	}
+}
+
+// Unit тест для функции With
+func TestUnitWith(t *testing.T) {
+	recorder := NewRecorder()
+
+	ctx := NewContext(context.Background(), recorder)
+	ctx = With(ctx, String("UUID", "uuid"))
+	ctx = With(ctx, Int("QuoteID", 1))
+
+	FromContext(ctx).Warn("Не удалось сохранить карточку в Кэш", Bool("CacheHit", false))
+	recorder.Info("Без полей")
+
+	got := recorder.Entries()
+
+	assert.Len(t, got, 2)
+	assert.Equal(t, Entry{
+		Level:   config.LogLevelWarn,
+		Message: "Не удалось сохранить карточку в Кэш",
+		Fields:  map[string]interface{}{"UUID": "uuid", "QuoteID": int64(1), "CacheHit": false},
+	}, got[0])
+	assert.Empty(t, got[1].Fields)
+}
+
+// Unit тест для функции Middleware
+func TestUnitMiddleware(t *testing.T) {
+	traceID := trace.TraceID{1, 2, 3}
+
+	cases := []struct {
+		name       string
+		uuid       interface{}
+		traced     bool
+		wantUUID   string
+		wantFields int
+	}{
+		{
+			name:       "request id case",
+			uuid:       "uuid",
+			wantUUID:   "uuid",
+			wantFields: 3,
+		},
+		{
+			name:       "request id middleware skipped case",
+			uuid:       nil,
+			wantUUID:   "",
+			wantFields: 3,
+		},
+		{
+			name:       "traced request case",
+			uuid:       "uuid",
+			traced:     true,
+			wantUUID:   "uuid",
+			wantFields: 4,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			recorder := NewRecorder()
+
+			mockApp := fiber.New()
+			mockApp.Use(func(c *fiber.Ctx) error {
+				if cs.uuid != nil {
+					c.Locals("uuid", cs.uuid)
+				}
+				if cs.traced {
+					c.SetUserContext(trace.ContextWithSpanContext(c.UserContext(), trace.NewSpanContext(trace.SpanContextConfig{
+						TraceID: traceID,
+						SpanID:  trace.SpanID{1},
+					})))
+				}
+				return c.Next()
+			})
+			mockApp.Use(Middleware(recorder))
+			mockApp.Get("/:id", func(c *fiber.Ctx) error {
+				FromContext(c.UserContext()).Info("Обработан запрос")
+				return nil
+			})
+
+			_, err := mockApp.Test(httptest.NewRequest(fiber.MethodGet, "/1", nil))
+			assert.NoError(t, err)
+
+			got := recorder.Entries()
+
+			assert.Len(t, got, 1)
+			assert.Len(t, got[0].Fields, cs.wantFields)
+			assert.Equal(t, cs.wantUUID, got[0].Fields["UUID"])
+			assert.Equal(t, fiber.MethodGet, got[0].Fields["Method"])
+			assert.Equal(t, "/1", got[0].Fields["Path"])
+
+			if cs.traced {
+				assert.Equal(t, traceID.String(), got[0].Fields["TraceID"])
+			}
+		})
+	}
+}
@@ -1,74 +1,220 @@
 package logging
 
 import (
-	"github.com/gofiber/fiber/v2"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+	"time"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+
+	"github.com/xoticdsign/returnauf/config"
+)
+
+// Структурированное поле записи журнала
+type Field = zap.Field
+
+// Конструкторы полей записи журнала
+var (
+	String   = zap.String
+	Int      = zap.Int
+	Bool     = zap.Bool
+	Duration = zap.Duration
+	Err      = zap.Error
+	Any      = zap.Any
 )
 
-// Интерфейс, содержащий методы для работы с Логгером
+// Интерфейс, содержащий методы для работы с Логгером. Логгер не зависит от транспорта:
+// поля запроса или вызова добавляются через With, а сам Логгер передается в контексте
+// через NewContext и FromContext
 type Logger interface {
-	Info(message string, c *fiber.Ctx)
-	Warn(message string, c *fiber.Ctx)
-	Error(message string, c *fiber.Ctx)
+	Debug(message string, fields ...Field)
+	Info(message string, fields ...Field)
+	Warn(message string, fields ...Field)
+	Error(message string, fields ...Field)
+	With(fields ...Field) Logger
 }
 
-// Структура, реализующая Logger
+// Структура, реализующая Logger. Методы нулевого указателя ничего не делают
 type Log struct {
-	logger *zap.Logger
+	logger  *zap.Logger
+	level   zap.AtomicLevel
+	closers []io.Closer
 }
 
-// Запускает Zap и возвращает структуру, реализующую Logger
-func RunZap() (*Log, error) {
-	config := zap.Config{
-		Level:    zap.NewAtomicLevelAt(zap.InfoLevel),
-		Encoding: "console",
-		EncoderConfig: zapcore.EncoderConfig{
-			MessageKey:       "MESSAGE",
-			LevelKey:         "LEVEL",
-			TimeKey:          "TIME",
-			EncodeLevel:      zapcore.CapitalLevelEncoder,
-			EncodeTime:       zapcore.TimeEncoderOfLayout("2006-01-02, 15:04:05.000"),
-			EncodeDuration:   zapcore.StringDurationEncoder,
-			ConsoleSeparator: " | ",
-		},
-		OutputPaths: []string{"stdout"},
-	}
-
-	zap, err := config.Build()
+// Запускает Zap с заданными форматом, уровнем, выборкой и выводом и возвращает
+// структуру, реализующую Logger. Пустой Output означает stdout
+func RunZap(conf config.Logging) (*Log, error) {
+	lvl, err := zap.ParseAtomicLevel(conf.Level)
 	if err != nil {
 		return nil, err
 	}
-	return &Log{logger: zap}, nil
+
+	encoder, err := newEncoder(conf.Encoding)
+	if err != nil {
+		return nil, err
+	}
+
+	sink, closers, err := openOutputs(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	core := zapcore.NewCore(encoder, sink, lvl)
+	if conf.SamplingInitial > 0 {
+		core = zapcore.NewSamplerWithOptions(core, time.Second, conf.SamplingInitial, conf.SamplingThereafter)
+	}
+
+	return &Log{
+		logger:  zap.New(core, zap.Fields(staticFields(conf)...)),
+		level:   lvl,
+		closers: closers,
+	}, nil
+}
+
+// Создает кодировщик записей. Формат console используется по умолчанию и предназначен
+// для чтения человеком, json включается для сборщиков журналов
+func newEncoder(encoding string) (zapcore.Encoder, error) {
+	encoderConfig := zapcore.EncoderConfig{
+		MessageKey:       "MESSAGE",
+		LevelKey:         "LEVEL",
+		TimeKey:          "TIME",
+		EncodeLevel:      zapcore.CapitalLevelEncoder,
+		EncodeTime:       zapcore.TimeEncoderOfLayout("2006-01-02, 15:04:05.000"),
+		EncodeDuration:   zapcore.StringDurationEncoder,
+		ConsoleSeparator: " | ",
+	}
+
+	switch encoding {
+	case config.LogEncodingConsole, "":
+		return zapcore.NewConsoleEncoder(encoderConfig), nil
+	case config.LogEncodingJSON:
+		encoderConfig.EncodeTime = zapcore.RFC3339NanoTimeEncoder
+		return zapcore.NewJSONEncoder(encoderConfig), nil
+	default:
+		return nil, fmt.Errorf("unknown log encoding %q", encoding)
+	}
+}
+
+// Открывает выводы журнала. Файлы открываются для дозаписи и ротируются по размеру
+func openOutputs(conf config.Logging) (zapcore.WriteSyncer, []io.Closer, error) {
+	outputs := conf.Output
+	if len(outputs) == 0 {
+		outputs = []string{"stdout"}
+	}
+
+	var syncers []zapcore.WriteSyncer
+	var closers []io.Closer
+
+	for _, output := range outputs {
+		switch output {
+		case "stdout":
+			syncers = append(syncers, zapcore.Lock(os.Stdout))
+		case "stderr":
+			syncers = append(syncers, zapcore.Lock(os.Stderr))
+		default:
+			r, err := openRotator(output, int64(conf.MaxSize)*1024*1024, conf.MaxBackups)
+			if err != nil {
+				for _, c := range closers {
+					c.Close()
+				}
+				return nil, nil, err
+			}
+			syncers = append(syncers, r)
+			closers = append(closers, r)
+		}
+	}
+	return zapcore.NewMultiWriteSyncer(syncers...), closers, nil
+}
+
+// Возвращает поля, добавляемые к каждой записи. Пустые значения пропускаются, а вместо
+// пустого имени экземпляра берется имя хоста
+func staticFields(conf config.Logging) []Field {
+	instance := conf.Instance
+	if instance == "" {
+		instance, _ = os.Hostname()
+	}
+
+	var fields []Field
+
+	for _, f := range []Field{
+		String("Service", conf.Service),
+		String("Version", conf.Version),
+		String("Instance", instance),
+	} {
+		if f.String != "" {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// Меняет уровень журнала без перезапуска
+func (l *Log) SetLevel(level string) error {
+	if l == nil {
+		return nil
+	}
+	return l.level.UnmarshalText([]byte(level))
+}
+
+// Создает отладочный лог
+func (l *Log) Debug(message string, fields ...Field) {
+	if l == nil {
+		return
+	}
+	l.logger.Debug(message, fields...)
 }
 
 // Создает информационный лог
-func (l *Log) Info(message string, c *fiber.Ctx) {
-	l.logger.Info(
-		message,
-		zap.String("UUID", c.Locals("uuid").(string)),
-		zap.String("Method", c.Method()),
-		zap.String("Path", c.Path()),
-	)
+func (l *Log) Info(message string, fields ...Field) {
+	if l == nil {
+		return
+	}
+	l.logger.Info(message, fields...)
 }
 
 // Создает лог-предупреждение
-func (l *Log) Warn(message string, c *fiber.Ctx) {
-	l.logger.Warn(
-		message,
-		zap.String("UUID", c.Locals("uuid").(string)),
-		zap.String("Method", c.Method()),
-		zap.String("Path", c.Path()),
-	)
+func (l *Log) Warn(message string, fields ...Field) {
+	if l == nil {
+		return
+	}
+	l.logger.Warn(message, fields...)
 }
 
 // Создает лог-ошибку
-func (l *Log) Error(message string, c *fiber.Ctx) {
-	l.logger.Error(
-		message,
-		zap.String("UUID", c.Locals("uuid").(string)),
-		zap.String("Method", c.Method()),
-		zap.String("Path", c.Path()),
-	)
+func (l *Log) Error(message string, fields ...Field) {
+	if l == nil {
+		return
+	}
+	l.logger.Error(message, fields...)
+}
+
+// Возвращает Логгер, добавляющий поля к каждой записи. Уровень у него общий с исходным, а
+// файлы журнала закрывает только исходный Логгер
+func (l *Log) With(fields ...Field) Logger {
+	if l == nil {
+		return l
+	}
+	return &Log{logger: l.logger.With(fields...), level: l.level}
+}
+
+// Сбрасывает буферизованные записи и закрывает файлы журнала. Ошибки синхронизации
+// терминала и каналов, которые не поддерживают fsync, игнорируются
+func (l *Log) Close() error {
+	if l == nil {
+		return nil
+	}
+
+	err := l.logger.Sync()
+	if errors.Is(err, syscall.EINVAL) || errors.Is(err, syscall.ENOTTY) {
+		err = nil
+	}
+
+	for _, c := range l.closers {
+		err = errors.Join(err, c.Close())
+	}
+	return err
 }
@@ -0,0 +1,217 @@
+package logging
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/xoticdsign/returnauf/config"
+)
+
+// Unit тест для функции RunZap
+func TestUnitRunZap(t *testing.T) {
+	cases := []struct {
+		name        string
+		conf        config.Logging
+		wantErr     bool
+		wantEntries int
+		wantFields  map[string]interface{}
+		wantConsole bool
+	}{
+		{
+			name: "json with static fields case",
+			conf: config.Logging{
+				Level:    config.LogLevelInfo,
+				Encoding: config.LogEncodingJSON,
+				Service:  "returnauf",
+				Version:  "1.0.0",
+				Instance: "node-1",
+			},
+			wantEntries: 3,
+			wantFields: map[string]interface{}{
+				"LEVEL":    "INFO",
+				"MESSAGE":  "Обработан запрос",
+				"Service":  "returnauf",
+				"Version":  "1.0.0",
+				"Instance": "node-1",
+				"UUID":     "uuid",
+				"Path":     "/1",
+				"QuoteID":  float64(1),
+				"CacheHit": true,
+			},
+		},
+		{
+			name: "level filters entries case",
+			conf: config.Logging{
+				Level:    config.LogLevelWarn,
+				Encoding: config.LogEncodingJSON,
+			},
+			wantEntries: 0,
+		},
+		{
+			name: "sampling drops repeated entries case",
+			conf: config.Logging{
+				Level:              config.LogLevelInfo,
+				Encoding:           config.LogEncodingJSON,
+				SamplingInitial:    1,
+				SamplingThereafter: 100,
+			},
+			wantEntries: 1,
+		},
+		{
+			name: "console by default case",
+			conf: config.Logging{
+				Level: config.LogLevelInfo,
+			},
+			wantEntries: 3,
+			wantConsole: true,
+		},
+		{
+			name: "unknown encoding case",
+			conf: config.Logging{
+				Level:    config.LogLevelInfo,
+				Encoding: "logfmt",
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown level case",
+			conf: config.Logging{
+				Level: "verbose",
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "app.log")
+			cs.conf.Output = []string{path}
+
+			log, gotErr := RunZap(cs.conf)
+			if cs.wantErr {
+				assert.Error(t, gotErr)
+				return
+			}
+			assert.NoError(t, gotErr)
+
+			request := log.With(String("UUID", "uuid"), String("Path", "/1"))
+
+			for i := 0; i < 3; i++ {
+				request.Info("Обработан запрос", Int("QuoteID", 1), Bool("CacheHit", true))
+			}
+			assert.NoError(t, log.Close())
+
+			data, err := os.ReadFile(path)
+			assert.NoError(t, err)
+
+			lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+			if len(data) == 0 {
+				lines = nil
+			}
+			assert.Len(t, lines, cs.wantEntries)
+
+			if cs.wantConsole {
+				assert.False(t, json.Valid([]byte(lines[0])))
+				assert.Contains(t, lines[0], " | INFO | Обработан запрос")
+			}
+
+			if cs.wantFields != nil {
+				got := map[string]interface{}{}
+
+				assert.NoError(t, json.Unmarshal([]byte(lines[0]), &got))
+				for key, want := range cs.wantFields {
+					assert.Equal(t, want, got[key], key)
+				}
+			}
+		})
+	}
+}
+
+// Unit тест для функции SetLevel
+func TestUnitSetLevel(t *testing.T) {
+	log, err := RunZap(config.Logging{Level: config.LogLevelInfo, Output: []string{filepath.Join(t.TempDir(), "app.log")}})
+	assert.NoError(t, err)
+	defer log.Close()
+
+	assert.NoError(t, log.SetLevel(config.LogLevelError))
+	assert.False(t, log.level.Enabled(zapcore.WarnLevel))
+	assert.Error(t, log.SetLevel("verbose"))
+}
+
+// Unit тест для методов нулевого Log
+func TestUnitNilLog(t *testing.T) {
+	var log *Log
+
+	assert.NotPanics(t, func() {
+		log.Info("Обработан запрос")
+		log.With(String("UUID", "uuid")).Error("Ошибка")
+		assert.NoError(t, log.SetLevel(config.LogLevelDebug))
+		assert.NoError(t, log.Close())
+	})
+}
+
+// Unit тест для функции rotator.Write
+func TestUnitRotatorWrite(t *testing.T) {
+	cases := []struct {
+		name        string
+		maxSize     int64
+		maxBackups  int
+		writes      int
+		wantBackups int
+	}{
+		{
+			name:        "no rotation case",
+			maxSize:     0,
+			maxBackups:  1,
+			writes:      5,
+			wantBackups: 0,
+		},
+		{
+			name:        "rotation keeps backups case",
+			maxSize:     10,
+			maxBackups:  2,
+			writes:      5,
+			wantBackups: 2,
+		},
+		{
+			name:        "rotation keeps all backups case",
+			maxSize:     10,
+			maxBackups:  0,
+			writes:      5,
+			wantBackups: 4,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "app.log")
+
+			r, err := openRotator(path, cs.maxSize, cs.maxBackups)
+			assert.NoError(t, err)
+
+			for i := 0; i < cs.writes; i++ {
+				_, err = r.Write([]byte("12345678\n"))
+				assert.NoError(t, err)
+
+				// Имена прежних файлов различаются меткой времени с миллисекундами
+				time.Sleep(time.Millisecond * 2)
+			}
+			assert.NoError(t, r.Close())
+
+			backups, err := filepath.Glob(path + ".*")
+			assert.NoError(t, err)
+			assert.Len(t, backups, cs.wantBackups)
+
+			data, err := os.ReadFile(path)
+			assert.NoError(t, err)
+			assert.NotEmpty(t, data)
+		})
+	}
+}
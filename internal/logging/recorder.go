@@ -0,0 +1,86 @@
+package logging
+
+import (
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+
+	"github.com/xoticdsign/returnauf/config"
+)
+
+// Запись журнала, сохраненная Recorder
+type Entry struct {
+	Level   string
+	Message string
+	Fields  map[string]interface{}
+}
+
+// Логгер для тестов, который сохраняет записи вместо вывода. Логгеры, полученные через
+// With, пишут в то же хранилище
+type Recorder struct {
+	store  *store
+	fields []Field
+}
+
+// Общее хранилище записей Recorder
+type store struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+// Создает пустой Recorder
+func NewRecorder() *Recorder {
+	return &Recorder{store: &store{}}
+}
+
+// Возвращает копию сохраненных записей
+func (r *Recorder) Entries() []Entry {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	return append([]Entry(nil), r.store.entries...)
+}
+
+// Сохраняет запись с уровнем, полями Recorder и полями записи
+func (r *Recorder) record(level string, message string, fields []Field) {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range r.fields {
+		f.AddTo(enc)
+	}
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.entries = append(r.store.entries, Entry{Level: level, Message: message, Fields: enc.Fields})
+}
+
+// Сохраняет отладочный лог
+func (r *Recorder) Debug(message string, fields ...Field) {
+	r.record(config.LogLevelDebug, message, fields)
+}
+
+// Сохраняет информационный лог
+func (r *Recorder) Info(message string, fields ...Field) {
+	r.record(config.LogLevelInfo, message, fields)
+}
+
+// Сохраняет лог-предупреждение
+func (r *Recorder) Warn(message string, fields ...Field) {
+	r.record(config.LogLevelWarn, message, fields)
+}
+
+// Сохраняет лог-ошибку
+func (r *Recorder) Error(message string, fields ...Field) {
+	r.record(config.LogLevelError, message, fields)
+}
+
+// Возвращает Recorder с тем же хранилищем, добавляющий поля к каждой записи
+func (r *Recorder) With(fields ...Field) Logger {
+	return &Recorder{
+		store:  r.store,
+		fields: append(append([]Field(nil), r.fields...), fields...),
+	}
+}
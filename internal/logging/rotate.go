@@ -0,0 +1,135 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Формат метки времени в имени прежнего файла журнала
+const backupLayout = "20060102T150405.000"
+
+// Файл журнала, который переименовывается и открывается заново, когда следующая запись
+// превысит maxSize байт. Хранится не больше maxBackups прежних файлов
+type rotator struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+// Открывает файл журнала для дозаписи. Нулевой maxSize отключает ротацию
+func openRotator(path string, maxSize int64, maxBackups int) (*rotator, error) {
+	r := &rotator{
+		path:       path,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+	}
+
+	err := r.open()
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Открывает файл и запоминает его текущий размер
+func (r *rotator) open() error {
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	r.file = f
+	r.size = info.Size()
+
+	return nil
+}
+
+// Записывает данные, предварительно ротируя файл, если запись не помещается
+func (r *rotator) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.maxSize > 0 && r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		err := r.rotate()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+
+	return n, err
+}
+
+// Переименовывает текущий файл в path.<время>, открывает новый и удаляет лишние прежние
+func (r *rotator) rotate() error {
+	err := r.file.Close()
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(r.path, r.path+"."+time.Now().Format(backupLayout))
+	if err != nil {
+		return err
+	}
+
+	err = r.open()
+	if err != nil {
+		return err
+	}
+	return r.prune()
+}
+
+// Удаляет самые старые прежние файлы сверх maxBackups
+func (r *rotator) prune() error {
+	if r.maxBackups <= 0 {
+		return nil
+	}
+
+	backups, err := filepath.Glob(r.path + ".*")
+	if err != nil {
+		return err
+	}
+	if len(backups) <= r.maxBackups {
+		return nil
+	}
+
+	sort.Strings(backups)
+
+	for _, backup := range backups[:len(backups)-r.maxBackups] {
+		err = os.Remove(backup)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Сбрасывает записанные данные на диск
+func (r *rotator) Sync() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.file.Sync()
+}
+
+// Закрывает файл журнала
+func (r *rotator) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.file.Close()
+}
@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/xoticdsign/returnauf/internal/cache"
+)
+
+// Декоратор Cacher, считающий операции с Кэшом
+type instrumentedCache struct {
+	next    cache.Cacher
+	metrics *Metrics
+}
+
+// Оборачивает Кэш, добавляя счетчики операций. Для Get считаются попадания,
+// промахи и ошибки, отметка об отсутствии цитаты считается попаданием
+func Cache(next cache.Cacher, m *Metrics) cache.Cacher {
+	return &instrumentedCache{next: next, metrics: m}
+}
+
+// Увеличивает счетчик операции
+func (c *instrumentedCache) count(operation string, result string) {
+	c.metrics.cacheOps.WithLabelValues(operation, result).Inc()
+}
+
+// Сохраняет значение в Кэш
+func (c *instrumentedCache) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	err := c.next.Set(ctx, key, value, expiration)
+	c.count("set", result(err))
+
+	return err
+}
+
+// Получает значение из Кэша
+func (c *instrumentedCache) Get(ctx context.Context, key string) (string, error) {
+	value, err := c.next.Get(ctx, key)
+
+	switch err {
+	case nil:
+		c.count("get", "hit")
+	case redis.Nil:
+		c.count("get", "miss")
+	default:
+		c.count("get", "error")
+	}
+	return value, err
+}
+
+// Возвращает оставшееся время жизни ключа
+func (c *instrumentedCache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := c.next.TTL(ctx, key)
+	c.count("ttl", result(err))
+
+	return ttl, err
+}
+
+// Удаляет ключи из Кэша
+func (c *instrumentedCache) Delete(ctx context.Context, keys ...string) (int, error) {
+	n, err := c.next.Delete(ctx, keys...)
+	c.count("delete", result(err))
+
+	return n, err
+}
+
+// Удаляет ключи по шаблону
+func (c *instrumentedCache) DeletePattern(ctx context.Context, pattern string) (int, error) {
+	n, err := c.next.DeletePattern(ctx, pattern)
+	c.count("delete_pattern", result(err))
+
+	return n, err
+}
+
+// Очищает Кэш
+func (c *instrumentedCache) Flush(ctx context.Context) (int, error) {
+	n, err := c.next.Flush(ctx)
+	c.count("flush", result(err))
+
+	return n, err
+}
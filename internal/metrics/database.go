@@ -0,0 +1,192 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/xoticdsign/returnauf/internal/database"
+	"github.com/xoticdsign/returnauf/models/responses"
+)
+
+// Декоратор Queuer, измеряющий длительность запросов к БД
+type instrumentedQueuer struct {
+	next    database.Queuer
+	metrics *Metrics
+}
+
+// Оборачивает БД, добавляя гистограмму длительности запросов. Отсутствие записи
+// считается результатом not_found, а не ошибкой
+func Queuer(next database.Queuer, m *Metrics) database.Queuer {
+	return &instrumentedQueuer{next: next, metrics: m}
+}
+
+// Записывает длительность запроса к БД. Отсутствие записи считается результатом
+// not_found, а не ошибкой
+func (m *Metrics) observeDB(operation string, start time.Time, err error) {
+	res := result(err)
+	if errors.Is(err, database.ErrNotFound) {
+		res = "not_found"
+	}
+	m.dbDuration.WithLabelValues(operation, res).Observe(time.Since(start).Seconds())
+}
+
+// Записывает длительность запроса
+func (q *instrumentedQueuer) observe(operation string, start time.Time, err error) {
+	q.metrics.observeDB(operation, start, err)
+}
+
+// Возвращает количество записей в БД
+func (q *instrumentedQueuer) QuotesCount(ctx context.Context) (int, error) {
+	start := time.Now()
+
+	count, err := q.next.QuotesCount(ctx)
+	q.observe("quotes_count", start, err)
+
+	return count, err
+}
+
+// Возвращает ID записи по смещению в порядке ID
+func (q *instrumentedQueuer) QuoteIDAt(ctx context.Context, offset int) (int, error) {
+	start := time.Now()
+
+	id, err := q.next.QuoteIDAt(ctx, offset)
+	q.observe("quote_id_at", start, err)
+
+	return id, err
+}
+
+// Возвращает цитаты, подходящие под фильтр
+func (q *instrumentedQueuer) ListAll(ctx context.Context, filter database.Filter) ([]responses.Quote, error) {
+	start := time.Now()
+
+	quotes, err := q.next.ListAll(ctx, filter)
+	q.observe("list_all", start, err)
+
+	return quotes, err
+}
+
+// Возвращает цитату по ID
+func (q *instrumentedQueuer) GetQuote(ctx context.Context, id string) (responses.Quote, error) {
+	start := time.Now()
+
+	quote, err := q.next.GetQuote(ctx, id)
+	q.observe("get_quote", start, err)
+
+	return quote, err
+}
+
+// Декоратор Importer, измеряющий длительность импорта
+type instrumentedImporter struct {
+	next    database.Importer
+	metrics *Metrics
+}
+
+// Оборачивает импорт в БД, добавляя его длительность в гистограмму запросов
+func Importer(next database.Importer, m *Metrics) database.Importer {
+	return &instrumentedImporter{next: next, metrics: m}
+}
+
+// Импортирует цитаты
+func (i *instrumentedImporter) ImportQuotes(ctx context.Context, quotes []responses.Quote, policy string, dryRun bool) (database.ImportResult, error) {
+	start := time.Now()
+
+	imported, err := i.next.ImportQuotes(ctx, quotes, policy, dryRun)
+	i.metrics.observeDB("import_quotes", start, err)
+
+	return imported, err
+}
+
+// Декоратор Exporter, измеряющий длительность выборок по фильтру
+type instrumentedExporter struct {
+	next    database.Exporter
+	metrics *Metrics
+}
+
+// Оборачивает выборки цитат по фильтру, добавляя их длительность в гистограмму
+// запросов. Длительность выгрузки включает время обработки каждой строки
+func Exporter(next database.Exporter, m *Metrics) database.Exporter {
+	return &instrumentedExporter{next: next, metrics: m}
+}
+
+// Построчно читает цитаты, подходящие под фильтр
+func (e *instrumentedExporter) StreamQuotes(ctx context.Context, filter database.Filter, fn func(responses.Quote) error) error {
+	start := time.Now()
+
+	err := e.next.StreamQuotes(ctx, filter, fn)
+	e.metrics.observeDB("stream_quotes", start, err)
+
+	return err
+}
+
+// Возвращает количество цитат, подходящих под фильтр
+func (e *instrumentedExporter) CountQuotes(ctx context.Context, filter database.Filter) (int, error) {
+	start := time.Now()
+
+	count, err := e.next.CountQuotes(ctx, filter)
+	e.metrics.observeDB("count_quotes", start, err)
+
+	return count, err
+}
+
+// Возвращает страницу цитат, подходящих под фильтр
+func (e *instrumentedExporter) PageQuotes(ctx context.Context, filter database.Filter, offset int, limit int) ([]responses.Quote, error) {
+	start := time.Now()
+
+	quotes, err := e.next.PageQuotes(ctx, filter, offset, limit)
+	e.metrics.observeDB("page_quotes", start, err)
+
+	return quotes, err
+}
+
+// Декоратор Feeder, измеряющий длительность запросов лент
+type instrumentedFeeder struct {
+	next    database.Feeder
+	metrics *Metrics
+}
+
+// Оборачивает запросы лент к БД, добавляя их длительность в гистограмму запросов
+func Feeder(next database.Feeder, m *Metrics) database.Feeder {
+	return &instrumentedFeeder{next: next, metrics: m}
+}
+
+// Возвращает состояние таблицы цитат
+func (f *instrumentedFeeder) FeedState(ctx context.Context) (database.FeedState, error) {
+	start := time.Now()
+
+	state, err := f.next.FeedState(ctx)
+	f.metrics.observeDB("feed_state", start, err)
+
+	return state, err
+}
+
+// Возвращает последние добавленные цитаты
+func (f *instrumentedFeeder) LatestQuotes(ctx context.Context, limit int) ([]database.FeedEntry, error) {
+	start := time.Now()
+
+	entries, err := f.next.LatestQuotes(ctx, limit)
+	f.metrics.observeDB("latest_quotes", start, err)
+
+	return entries, err
+}
+
+// Декоратор BatchQueuer, измеряющий длительность пакетных запросов
+type instrumentedBatcher struct {
+	next    database.BatchQueuer
+	metrics *Metrics
+}
+
+// Оборачивает пакетное чтение из БД, добавляя его длительность в гистограмму запросов
+func Batcher(next database.BatchQueuer, m *Metrics) database.BatchQueuer {
+	return &instrumentedBatcher{next: next, metrics: m}
+}
+
+// Возвращает цитаты с указанными ID
+func (b *instrumentedBatcher) GetQuotes(ctx context.Context, ids []int) ([]responses.Quote, error) {
+	start := time.Now()
+
+	quotes, err := b.next.GetQuotes(ctx, ids)
+	b.metrics.observeDB("get_quotes", start, err)
+
+	return quotes, err
+}
@@ -0,0 +1,113 @@
+package metrics
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Пространство имен метрик
+const namespace = "returnauf"
+
+// Маршрут для запросов, не дошедших до хендлера: не совпавших ни с одним маршрутом или
+// отклоненных промежуточным обработчиком. Путь запроса не используется как метка,
+// чтобы число рядов не росло от случайных адресов
+const unmatchedRoute = "unmatched"
+
+// Метрики приложения в собственном реестре
+type Metrics struct {
+	registry *prometheus.Registry
+
+	requests   *prometheus.HistogramVec
+	cacheOps   *prometheus.CounterVec
+	dbDuration *prometheus.HistogramVec
+}
+
+// Создает метрики и регистрирует их вместе с метриками среды выполнения Go и процесса
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Длительность обработки HTTP-запросов по маршрутам и статусам.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		cacheOps: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cache_operations_total",
+			Help:      "Операции с Кэшом по типу и результату: hit, miss, ok или error.",
+		}, []string{"operation", "result"}),
+		dbDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "db_query_duration_seconds",
+			Help:      "Длительность запросов к БД по типу и результату.",
+			Buckets:   []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5},
+		}, []string{"operation", "result"}),
+	}
+
+	m.registry.MustRegister(
+		m.requests,
+		m.cacheOps,
+		m.dbDuration,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// Возвращает реестр метрик
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+// Возвращает промежуточный обработчик, измеряющий длительность запросов. Статус
+// ошибки берется из fiber.Error, так как ответ пишет обработчик ошибок уже после
+// промежуточных обработчиков
+func (m *Metrics) Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			status = fiber.StatusInternalServerError
+
+			var e *fiber.Error
+			if errors.As(err, &e) {
+				status = e.Code
+			}
+		}
+
+		// Промежуточные обработчики зарегистрированы на "/", поэтому такой маршрут у
+		// запроса на другой путь означает, что хендлер не был найден
+		route := c.Route().Path
+		if route == "/" && c.Path() != "/" {
+			route = unmatchedRoute
+		}
+
+		m.requests.WithLabelValues(c.Method(), route, strconv.Itoa(status)).Observe(time.Since(start).Seconds())
+
+		return err
+	}
+}
+
+// Возвращает обработчик, отдающий метрики в текстовом формате Prometheus
+func (m *Metrics) Handler() fiber.Handler {
+	return adaptor.HTTPHandler(promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+}
+
+// Возвращает результат операции для метки
+func result(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "ok"
+}
@@ -0,0 +1,337 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/xoticdsign/returnauf/internal/database"
+	"github.com/xoticdsign/returnauf/models/responses"
+)
+
+// Кэш для тестов, возвращающий заданную ошибку
+type stubCache struct {
+	err error
+}
+
+// Имитация метода Set
+func (s *stubCache) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	return s.err
+}
+
+// Имитация метода Get
+func (s *stubCache) Get(ctx context.Context, key string) (string, error) {
+	return "", s.err
+}
+
+// Имитация метода TTL
+func (s *stubCache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	return 0, s.err
+}
+
+// Имитация метода Delete
+func (s *stubCache) Delete(ctx context.Context, keys ...string) (int, error) {
+	return len(keys), s.err
+}
+
+// Имитация метода DeletePattern
+func (s *stubCache) DeletePattern(ctx context.Context, pattern string) (int, error) {
+	return 0, s.err
+}
+
+// Имитация метода Flush
+func (s *stubCache) Flush(ctx context.Context) (int, error) {
+	return 0, s.err
+}
+
+// БД для тестов, возвращающая заданную ошибку
+type stubQueuer struct {
+	err error
+}
+
+// Имитация метода QuotesCount
+func (s *stubQueuer) QuotesCount(ctx context.Context) (int, error) {
+	return len(responses.TestQuotes), s.err
+}
+
+// Имитация метода QuoteIDAt
+func (s *stubQueuer) QuoteIDAt(ctx context.Context, offset int) (int, error) {
+	return offset + 1, s.err
+}
+
+// Имитация метода ListAll
+func (s *stubQueuer) ListAll(ctx context.Context, filter database.Filter) ([]responses.Quote, error) {
+	return responses.TestQuotes, s.err
+}
+
+// Имитация метода GetQuote
+func (s *stubQueuer) GetQuote(ctx context.Context, id string) (responses.Quote, error) {
+	return responses.TestQuotes[0], s.err
+}
+
+// Имитация метода ImportQuotes
+func (s *stubQueuer) ImportQuotes(ctx context.Context, quotes []responses.Quote, policy string, dryRun bool) (database.ImportResult, error) {
+	return database.ImportResult{Inserted: len(quotes)}, s.err
+}
+
+// Имитация метода StreamQuotes
+func (s *stubQueuer) StreamQuotes(ctx context.Context, filter database.Filter, fn func(responses.Quote) error) error {
+	return s.err
+}
+
+// Имитация метода CountQuotes
+func (s *stubQueuer) CountQuotes(ctx context.Context, filter database.Filter) (int, error) {
+	return len(responses.TestQuotes), s.err
+}
+
+// Имитация метода PageQuotes
+func (s *stubQueuer) PageQuotes(ctx context.Context, filter database.Filter, offset int, limit int) ([]responses.Quote, error) {
+	return responses.TestQuotes, s.err
+}
+
+// Имитация метода FeedState
+func (s *stubQueuer) FeedState(ctx context.Context) (database.FeedState, error) {
+	return database.FeedState{}, s.err
+}
+
+// Имитация метода LatestQuotes
+func (s *stubQueuer) LatestQuotes(ctx context.Context, limit int) ([]database.FeedEntry, error) {
+	return nil, s.err
+}
+
+// Имитация метода GetQuotes
+func (s *stubQueuer) GetQuotes(ctx context.Context, ids []int) ([]responses.Quote, error) {
+	return responses.TestQuotes, s.err
+}
+
+// Unit тест для декоратора Cache
+func TestUnitCache(t *testing.T) {
+	cases := []struct {
+		name       string
+		err        error
+		wantResult string
+	}{
+		{
+			name:       "hit case",
+			err:        nil,
+			wantResult: "hit",
+		},
+		{
+			name:       "miss case",
+			err:        redis.Nil,
+			wantResult: "miss",
+		},
+		{
+			name:       "error case",
+			err:        errors.New("error"),
+			wantResult: "error",
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			m := New()
+			c := Cache(&stubCache{err: cs.err}, m)
+
+			_, gotErr := c.Get(context.Background(), "1")
+			c.Set(context.Background(), "1", "Mock quote 1", time.Minute)
+
+			assert.Equal(t, cs.err, gotErr)
+			assert.Equal(t, 1.0, testutil.ToFloat64(m.cacheOps.WithLabelValues("get", cs.wantResult)))
+			assert.Equal(t, 1.0, testutil.ToFloat64(m.cacheOps.WithLabelValues("set", result(cs.err))))
+		})
+	}
+}
+
+// Unit тест для декоратора Queuer
+func TestUnitQueuer(t *testing.T) {
+	cases := []struct {
+		name       string
+		err        error
+		wantResult string
+	}{
+		{
+			name:       "ok case",
+			err:        nil,
+			wantResult: "ok",
+		},
+		{
+			name:       "not found case",
+			err:        database.ErrNotFound,
+			wantResult: "not_found",
+		},
+		{
+			name:       "error case",
+			err:        errors.New("error"),
+			wantResult: "error",
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			m := New()
+			q := Queuer(&stubQueuer{err: cs.err}, m)
+
+			gotQuote, gotErr := q.GetQuote(context.Background(), "1")
+			q.QuotesCount(context.Background())
+			q.ListAll(context.Background(), database.Filter{})
+
+			assert.Equal(t, responses.TestQuotes[0], gotQuote)
+			assert.Equal(t, cs.err, gotErr)
+			assert.Equal(t, 3, testutil.CollectAndCount(m.dbDuration))
+
+			expected := `returnauf_db_query_duration_seconds_count{operation="get_quote",result="` + cs.wantResult + `"} 1`
+
+			assert.Contains(t, scrape(t, m), expected)
+		})
+	}
+}
+
+// Unit тест для декораторов Importer, Exporter, Feeder и Batcher
+func TestUnitDBDecorators(t *testing.T) {
+	cases := []struct {
+		name          string
+		call          func(m *Metrics, db *stubQueuer) error
+		wantOperation string
+	}{
+		{
+			name: "importer case",
+			call: func(m *Metrics, db *stubQueuer) error {
+				_, err := Importer(db, m).ImportQuotes(context.Background(), responses.TestQuotes, "skip", false)
+				return err
+			},
+			wantOperation: "import_quotes",
+		},
+		{
+			name: "exporter stream case",
+			call: func(m *Metrics, db *stubQueuer) error {
+				return Exporter(db, m).StreamQuotes(context.Background(), database.Filter{}, nil)
+			},
+			wantOperation: "stream_quotes",
+		},
+		{
+			name: "exporter count case",
+			call: func(m *Metrics, db *stubQueuer) error {
+				_, err := Exporter(db, m).CountQuotes(context.Background(), database.Filter{})
+				return err
+			},
+			wantOperation: "count_quotes",
+		},
+		{
+			name: "exporter page case",
+			call: func(m *Metrics, db *stubQueuer) error {
+				_, err := Exporter(db, m).PageQuotes(context.Background(), database.Filter{}, 0, 1)
+				return err
+			},
+			wantOperation: "page_quotes",
+		},
+		{
+			name: "feeder state case",
+			call: func(m *Metrics, db *stubQueuer) error {
+				_, err := Feeder(db, m).FeedState(context.Background())
+				return err
+			},
+			wantOperation: "feed_state",
+		},
+		{
+			name: "feeder latest case",
+			call: func(m *Metrics, db *stubQueuer) error {
+				_, err := Feeder(db, m).LatestQuotes(context.Background(), 1)
+				return err
+			},
+			wantOperation: "latest_quotes",
+		},
+		{
+			name: "batcher case",
+			call: func(m *Metrics, db *stubQueuer) error {
+				_, err := Batcher(db, m).GetQuotes(context.Background(), []int{1})
+				return err
+			},
+			wantOperation: "get_quotes",
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			m := New()
+
+			gotErr := cs.call(m, &stubQueuer{err: errors.New("error")})
+
+			assert.Error(t, gotErr)
+			expected := `returnauf_db_query_duration_seconds_count{operation="` + cs.wantOperation + `",result="error"} 1`
+
+			assert.Contains(t, scrape(t, m), expected)
+		})
+	}
+}
+
+// Unit тест для функции Middleware
+func TestUnitMiddleware(t *testing.T) {
+	cases := []struct {
+		name       string
+		path       string
+		wantSample string
+	}{
+		{
+			name:       "ok case",
+			path:       "/1",
+			wantSample: `returnauf_http_request_duration_seconds_count{method="GET",route="/:id",status="200"} 1`,
+		},
+		{
+			name:       "handler error case",
+			path:       "/999",
+			wantSample: `returnauf_http_request_duration_seconds_count{method="GET",route="/:id",status="404"} 1`,
+		},
+		{
+			name:       "unmatched route case",
+			path:       "/1/unknown",
+			wantSample: `returnauf_http_request_duration_seconds_count{method="GET",route="unmatched",status="404"} 1`,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			m := New()
+
+			app := fiber.New()
+			app.Use(m.Middleware())
+			app.Get("/:id", func(c *fiber.Ctx) error {
+				if c.Params("id") == "999" {
+					return fiber.ErrNotFound
+				}
+				return c.SendString("ok")
+			})
+
+			resp, _ := app.Test(httptest.NewRequest("GET", cs.path, nil), -1)
+			resp.Body.Close()
+
+			assert.Contains(t, scrape(t, m), cs.wantSample)
+		})
+	}
+}
+
+// Возвращает метрики в текстовом формате через Handler
+func scrape(t *testing.T, m *Metrics) string {
+	app := fiber.New()
+	app.Get("/metrics", m.Handler())
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/metrics", nil), -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+
+	assert.Contains(t, string(body), "go_goroutines")
+
+	return string(body)
+}
@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/xoticdsign/returnauf/config"
+	"github.com/xoticdsign/returnauf/internal/logging"
+)
+
+// Длина идентификатора ключа API в журнале
+const keyIDLength = 12
+
+// Возвращает обработчик, который пишет в Логгер запроса из logging.Middleware одну запись
+// на каждый запрос: статус, время обработки, размер ответа, адрес и User-Agent клиента и
+// идентификатор ключа API. Ошибки обработчиков передаются в обработчик ошибок приложения
+// здесь же, чтобы в запись попали итоговые статус и размер ответа. Ответы 5xx пишутся
+// ошибкой, запросы дольше ACCESS_LOG_SLOW_THRESHOLD - предупреждением. Исключения и
+// порог берутся из действующих настроек, поэтому меняются без перезапуска
+func AccessLog() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		conf := config.Current().AccessLog
+
+		if excluded(c.Path(), conf.Exclude) {
+			return c.Next()
+		}
+
+		start := time.Now()
+
+		err := c.Next()
+		if err != nil {
+			err = c.App().ErrorHandler(c, err)
+			if err != nil {
+				c.Status(fiber.StatusInternalServerError)
+			}
+		}
+
+		latency := time.Since(start)
+		status := c.Response().StatusCode()
+
+		fields := []logging.Field{
+			logging.Int("Status", status),
+			logging.Duration("Latency", latency),
+			logging.Int("Bytes", responseSize(c)),
+			logging.String("IP", c.IP()),
+			logging.String("UserAgent", c.Get(fiber.HeaderUserAgent)),
+			logging.String("KeyID", KeyID(requestKey(c))),
+		}
+
+		logger := logging.FromContext(c.UserContext())
+
+		switch {
+		case status >= fiber.StatusInternalServerError:
+			logger.Error("Запрос завершен с ошибкой", fields...)
+		case conf.SlowThreshold > 0 && latency > conf.SlowThreshold:
+			logger.Warn("Медленный запрос", fields...)
+		default:
+			logger.Info("Запрос завершен", fields...)
+		}
+		return nil
+	}
+}
+
+// Проверяет, исключен ли путь из журнала запросов. Шаблон, заканчивающийся на *,
+// сравнивается по префиксу
+func excluded(path string, patterns []string) bool {
+	for _, pattern := range patterns {
+		prefix, ok := strings.CutSuffix(pattern, "*")
+		if ok && strings.HasPrefix(path, prefix) {
+			return true
+		}
+		if path == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// Возвращает размер тела ответа. Для потоковых ответов, например SSE, тело не читается,
+// а берется заявленная длина, равная -1, если она неизвестна
+func responseSize(c *fiber.Ctx) int {
+	if c.Response().IsBodyStream() {
+		return c.Response().Header.ContentLength()
+	}
+	return len(c.Response().Body())
+}
+
+// Возвращает ключ API из запроса, а если его нет - административный ключ
+func requestKey(c *fiber.Ctx) string {
+	key := c.Query(KeyParam)
+	if key == "" {
+		key = c.Query(AdminKeyParam)
+	}
+	return key
+}
+
+// Возвращает идентификатор ключа API для журнала: начало его хеша SHA-256. По
+// идентификатору можно отличить ключи друг от друга, но нельзя восстановить сам ключ.
+// Для пустого ключа возвращает пустую строку
+func KeyID(key string) string {
+	if key == "" {
+		return ""
+	}
+
+	sum := sha256.Sum256([]byte(key))
+
+	return hex.EncodeToString(sum[:])[:keyIDLength]
+}
@@ -0,0 +1,210 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/xoticdsign/returnauf/config"
+	"github.com/xoticdsign/returnauf/internal/logging"
+)
+
+// Возвращает заголовок прокси так же, как приложение: только при заданных доверенных
+// прокси
+func proxyHeader(trustedProxies []string) string {
+	if len(trustedProxies) == 0 {
+		return ""
+	}
+	return fiber.HeaderXForwardedFor
+}
+
+// Unit тест для функции AccessLog
+func TestUnitAccessLog(t *testing.T) {
+	cases := []struct {
+		name           string
+		path           string
+		forwardedFor   string
+		trustedProxies []string
+		wantEntries    int
+		wantLevel      string
+		wantStatus     int
+		wantBytes      int
+		wantIP         string
+		wantKeyID      string
+	}{
+		{
+			name:        "successful request case",
+			path:        "/ok?returnauf-key=secret",
+			wantEntries: 1,
+			wantLevel:   config.LogLevelInfo,
+			wantStatus:  fiber.StatusOK,
+			wantBytes:   2,
+			wantIP:      "0.0.0.0",
+			wantKeyID:   KeyID("secret"),
+		},
+		{
+			name:        "handler error case",
+			path:        "/missing",
+			wantEntries: 1,
+			wantLevel:   config.LogLevelInfo,
+			wantStatus:  fiber.StatusNotFound,
+			wantBytes:   len("missing"),
+			wantIP:      "0.0.0.0",
+		},
+		{
+			name:        "server error case",
+			path:        "/fail",
+			wantEntries: 1,
+			wantLevel:   config.LogLevelError,
+			wantStatus:  fiber.StatusInternalServerError,
+			wantBytes:   len("fail"),
+			wantIP:      "0.0.0.0",
+		},
+		{
+			name:        "slow request case",
+			path:        "/slow?returnauf-admin-key=admin",
+			wantEntries: 1,
+			wantLevel:   config.LogLevelWarn,
+			wantStatus:  fiber.StatusOK,
+			wantBytes:   2,
+			wantIP:      "0.0.0.0",
+			wantKeyID:   KeyID("admin"),
+		},
+		{
+			name:        "excluded path case",
+			path:        "/healthz",
+			wantEntries: 0,
+		},
+		{
+			name:        "excluded prefix case",
+			path:        "/swagger/index.html",
+			wantEntries: 0,
+		},
+		{
+			name:           "trusted proxy case",
+			path:           "/ok",
+			forwardedFor:   "203.0.113.7",
+			trustedProxies: []string{"0.0.0.0"},
+			wantEntries:    1,
+			wantLevel:      config.LogLevelInfo,
+			wantStatus:     fiber.StatusOK,
+			wantBytes:      2,
+			wantIP:         "203.0.113.7",
+		},
+		{
+			name:           "untrusted proxy case",
+			path:           "/ok",
+			forwardedFor:   "203.0.113.7",
+			trustedProxies: []string{"10.0.0.1"},
+			wantEntries:    1,
+			wantLevel:      config.LogLevelInfo,
+			wantStatus:     fiber.StatusOK,
+			wantBytes:      2,
+			wantIP:         "0.0.0.0",
+		},
+		{
+			name:         "no trusted proxies case",
+			path:         "/ok",
+			forwardedFor: "203.0.113.7",
+			wantEntries:  1,
+			wantLevel:    config.LogLevelInfo,
+			wantStatus:   fiber.StatusOK,
+			wantBytes:    2,
+			wantIP:       "0.0.0.0",
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			conf := config.Current()
+			conf.AccessLog = config.AccessLog{
+				Enabled:       true,
+				Exclude:       []string{"/healthz", "/swagger/*"},
+				SlowThreshold: time.Millisecond * 20,
+			}
+			config.Set(conf)
+
+			log := logging.NewRecorder()
+
+			mockApp := fiber.New(fiber.Config{
+				EnableTrustedProxyCheck: len(cs.trustedProxies) > 0,
+				TrustedProxies:          cs.trustedProxies,
+				ProxyHeader:             proxyHeader(cs.trustedProxies),
+				EnableIPValidation:      true,
+				ErrorHandler: func(c *fiber.Ctx, err error) error {
+					return c.Status(err.(*fiber.Error).Code).SendString(err.(*fiber.Error).Message)
+				},
+			})
+			mockApp.Use(logging.Middleware(log))
+			mockApp.Use(AccessLog())
+
+			mockApp.Get("/ok", func(c *fiber.Ctx) error { return c.SendString("ok") })
+			mockApp.Get("/healthz", func(c *fiber.Ctx) error { return c.SendString("ok") })
+			mockApp.Get("/missing", func(c *fiber.Ctx) error { return fiber.NewError(fiber.StatusNotFound, "missing") })
+			mockApp.Get("/fail", func(c *fiber.Ctx) error { return fiber.NewError(fiber.StatusInternalServerError, "fail") })
+			mockApp.Get("/slow", func(c *fiber.Ctx) error {
+				time.Sleep(time.Millisecond * 40)
+				return c.SendString("ok")
+			})
+
+			req := httptest.NewRequest(fiber.MethodGet, cs.path, nil)
+			req.Header.Set(fiber.HeaderUserAgent, "test-agent")
+			if cs.forwardedFor != "" {
+				req.Header.Set(fiber.HeaderXForwardedFor, cs.forwardedFor)
+			}
+
+			_, err := mockApp.Test(req)
+			assert.NoError(t, err)
+
+			entries := log.Entries()
+
+			assert.Len(t, entries, cs.wantEntries)
+			if cs.wantEntries == 0 {
+				return
+			}
+
+			got := entries[0]
+
+			assert.Equal(t, cs.wantLevel, got.Level)
+			assert.Equal(t, int64(cs.wantStatus), got.Fields["Status"])
+			assert.Equal(t, int64(cs.wantBytes), got.Fields["Bytes"])
+			assert.Equal(t, cs.wantIP, got.Fields["IP"])
+			assert.Equal(t, "test-agent", got.Fields["UserAgent"])
+			assert.Equal(t, cs.wantKeyID, got.Fields["KeyID"])
+			assert.Equal(t, fiber.MethodGet, got.Fields["Method"])
+			assert.NotContains(t, got.Fields, "Key")
+		})
+	}
+}
+
+// Unit тест для функции KeyID
+func TestUnitKeyID(t *testing.T) {
+	cases := []struct {
+		name    string
+		input   string
+		wantLen int
+	}{
+		{
+			name:    "key case",
+			input:   "testKey",
+			wantLen: keyIDLength,
+		},
+		{
+			name:    "empty key case",
+			input:   "",
+			wantLen: 0,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			got := KeyID(cs.input)
+
+			assert.Len(t, got, cs.wantLen)
+			assert.Equal(t, got, KeyID(cs.input))
+		})
+	}
+}
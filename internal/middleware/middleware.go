@@ -10,18 +10,57 @@ import (
 	"github.com/xoticdsign/returnauf/config"
 )
 
+// Параметры запроса с ключом API и административным ключом API
+const (
+	KeyParam      = "returnauf-key"
+	AdminKeyParam = "returnauf-admin-key"
+)
+
 // Фильтрует маршруты для аутентификации
 func AuthFiler(c *fiber.Ctx) bool {
 	path := c.Path()
 	if strings.Contains(path, "swagger") {
 		return true
 	}
+	if path == "/healthz" || path == "/readyz" {
+		return true
+	}
 	return false
 }
 
+// Фильтрует маршруты для аутентификации так же, как AuthFiler, и пропускает /admin и
+// /metrics, которые проверяют административный ключ сами. Используется, только если эти
+// маршруты подключены, иначе запросы к ним проходят обычную аутентификацию
+func AdminAuthFiler(c *fiber.Ctx) bool {
+	if AuthFiler(c) {
+		return true
+	}
+
+	path := c.Path()
+	return strings.HasPrefix(path, "/admin/") || path == "/metrics"
+}
+
 // Проверяет ключ API
 func KeyauthValidator(c *fiber.Ctx, key string) (bool, error) {
-	apiKey := config.LoadConfig().ApiKey
+	return ValidateKey(key)
+}
+
+// Проверяет ключ API вне Fiber, например в интерсепторах gRPC. Ключ берется из
+// действующих настроек, поэтому замененный при перезагрузке ключ действует сразу
+func ValidateKey(key string) (bool, error) {
+	return compareKeys(config.Current().ApiKey, key)
+}
+
+// Проверяет административный ключ API
+func AdminKeyauthValidator(c *fiber.Ctx, key string) (bool, error) {
+	return compareKeys(config.Current().AdminApiKey, key)
+}
+
+// Сравнивает ключи за постоянное время
+func compareKeys(apiKey string, key string) (bool, error) {
+	if apiKey == "" {
+		return false, fiber.ErrUnauthorized
+	}
 
 	hRealKey := sha256.Sum256([]byte(apiKey))
 	hKey := sha256.Sum256([]byte(key))
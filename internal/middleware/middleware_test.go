@@ -1,20 +1,31 @@
 package middleware
 
 import (
-	"os"
 	"testing"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/valyala/fasthttp"
+
+	"github.com/xoticdsign/returnauf/config"
 )
 
-// Unit тест для функции AuthFiler
+// Заменяет ключи API в действующих настройках
+func setKeys(apiKey string, adminKey string) {
+	conf := config.Current()
+	conf.ApiKey = apiKey
+	conf.AdminApiKey = adminKey
+
+	config.Set(conf)
+}
+
+// Unit тест для функций AuthFiler и AdminAuthFiler
 func TestUnitAuthFilter(t *testing.T) {
 	cases := []struct {
-		name string
-		path string
-		want bool
+		name  string
+		path  string
+		admin bool
+		want  bool
 	}{
 		{
 			name: "page that needs auth case",
@@ -26,6 +37,50 @@ func TestUnitAuthFilter(t *testing.T) {
 			path: "/swagger",
 			want: true,
 		},
+		{
+			name:  "metrics page with admin auth case",
+			path:  "/metrics",
+			admin: true,
+			want:  true,
+		},
+		{
+			name: "metrics page without admin routes case",
+			path: "/metrics",
+			want: false,
+		},
+		{
+			name: "liveness probe case",
+			path: "/healthz",
+			want: true,
+		},
+		{
+			name: "readiness probe case",
+			path: "/readyz",
+			want: true,
+		},
+		{
+			name:  "admin page with its own auth case",
+			path:  "/admin/cache/1",
+			admin: true,
+			want:  true,
+		},
+		{
+			name: "admin page without admin routes case",
+			path: "/admin/cache/1",
+			want: false,
+		},
+		{
+			name:  "page that needs auth with admin routes case",
+			path:  "/",
+			admin: true,
+			want:  false,
+		},
+		{
+			name:  "liveness probe with admin routes case",
+			path:  "/healthz",
+			admin: true,
+			want:  true,
+		},
 	}
 
 	for _, cs := range cases {
@@ -36,7 +91,12 @@ func TestUnitAuthFilter(t *testing.T) {
 			defer mockApp.ReleaseCtx(c)
 
 			c.Path(cs.path)
-			got := AuthFiler(c)
+
+			filter := AuthFiler
+			if cs.admin {
+				filter = AdminAuthFiler
+			}
+			got := filter(c)
 
 			assert.Equal(t, cs.want, got)
 		})
@@ -73,8 +133,7 @@ func TestUnitKeyauthValidator(t *testing.T) {
 
 	for _, cs := range cases {
 		t.Run(cs.name, func(t *testing.T) {
-			os.Setenv("API_KEY", "valid")
-			defer os.Unsetenv("API_KEY")
+			setKeys("valid", "")
 
 			mockApp := fiber.New()
 
@@ -88,3 +147,96 @@ func TestUnitKeyauthValidator(t *testing.T) {
 		})
 	}
 }
+
+// Unit тест для функции AdminKeyauthValidator
+func TestUnitAdminKeyauthValidator(t *testing.T) {
+	cases := []struct {
+		name     string
+		adminKey string
+		input    string
+		want     bool
+		wantErr  error
+	}{
+		{
+			name:     "valid key case",
+			adminKey: "admin",
+			input:    "admin",
+			want:     true,
+			wantErr:  nil,
+		},
+		{
+			name:     "regular key case",
+			adminKey: "admin",
+			input:    "valid",
+			want:     false,
+			wantErr:  fiber.ErrUnauthorized,
+		},
+		{
+			name:     "admin key not set case",
+			adminKey: "",
+			input:    "",
+			want:     false,
+			wantErr:  fiber.ErrUnauthorized,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			setKeys("valid", cs.adminKey)
+
+			mockApp := fiber.New()
+
+			c := mockApp.AcquireCtx(&fasthttp.RequestCtx{})
+			defer mockApp.ReleaseCtx(c)
+
+			got, gotErr := AdminKeyauthValidator(c, cs.input)
+
+			assert.Equal(t, cs.want, got)
+			assert.Equal(t, cs.wantErr, gotErr)
+		})
+	}
+}
+
+// Unit тест для функции ValidateKey
+func TestUnitValidateKey(t *testing.T) {
+	cases := []struct {
+		name    string
+		apiKey  string
+		input   string
+		want    bool
+		wantErr error
+	}{
+		{
+			name:    "valid key case",
+			apiKey:  "valid",
+			input:   "valid",
+			want:    true,
+			wantErr: nil,
+		},
+		{
+			name:    "wrong key case",
+			apiKey:  "valid",
+			input:   "wrong",
+			want:    false,
+			wantErr: fiber.ErrUnauthorized,
+		},
+		{
+			name:    "api key not set case",
+			apiKey:  "",
+			input:   "",
+			want:    false,
+			wantErr: fiber.ErrUnauthorized,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			setKeys(cs.apiKey, "")
+
+			got, gotErr := ValidateKey(cs.input)
+
+			assert.Equal(t, cs.want, got)
+			assert.Equal(t, cs.wantErr, gotErr)
+		})
+	}
+}
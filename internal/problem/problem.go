@@ -0,0 +1,235 @@
+package problem
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+
+	"github.com/xoticdsign/returnauf/models/responses"
+)
+
+// Префикс URI типа ошибки, к которому добавляется ее код
+const TypePrefix = "urn:returnauf:problem:"
+
+// Префикс URI экземпляра ошибки, к которому добавляется UUID запроса
+const InstancePrefix = "urn:uuid:"
+
+// Вид ошибки из реестра: стабильный машиночитаемый код, HTTP статус и краткое описание,
+// которое не меняется от случая к случаю. Реализует error, поэтому вид можно вернуть
+// из хендлера как есть или сравнить с ошибкой через errors.Is
+type Kind struct {
+	Code   string
+	Status int
+	Title  string
+
+	fiber *fiber.Error
+}
+
+// Ошибка с видом из реестра, подробностями конкретного случая и причиной. Причина
+// пишется в журнал, но в ответ не попадает
+type Error struct {
+	Kind   *Kind
+	Detail string
+	Err    error
+}
+
+// Реестр видов ошибок. Пакеты регистрируют свои виды при инициализации
+var registry = struct {
+	sync.RWMutex
+	byCode   map[string]*Kind
+	byStatus map[int]*Kind
+}{
+	byCode:   map[string]*Kind{},
+	byStatus: map[int]*Kind{},
+}
+
+// Общие виды ошибок для HTTP статусов, у которых нет более точного вида
+var (
+	BadRequest          = generic("bad_request", fiber.StatusBadRequest)
+	Unauthorized        = generic("unauthorized", fiber.StatusUnauthorized)
+	Forbidden           = generic("forbidden", fiber.StatusForbidden)
+	NotFound            = generic("not_found", fiber.StatusNotFound)
+	MethodNotAllowed    = generic("method_not_allowed", fiber.StatusMethodNotAllowed)
+	RequestTimeout      = generic("request_timeout", fiber.StatusRequestTimeout)
+	Conflict            = generic("conflict", fiber.StatusConflict)
+	PayloadTooLarge     = generic("payload_too_large", fiber.StatusRequestEntityTooLarge)
+	UnsupportedMedia    = generic("unsupported_media_type", fiber.StatusUnsupportedMediaType)
+	Unprocessable       = generic("unprocessable_entity", fiber.StatusUnprocessableEntity)
+	UpgradeRequired     = generic("upgrade_required", fiber.StatusUpgradeRequired)
+	TooManyRequests     = generic("too_many_requests", fiber.StatusTooManyRequests)
+	Internal            = generic("internal_error", fiber.StatusInternalServerError)
+	ServiceUnavailable  = generic("service_unavailable", fiber.StatusServiceUnavailable)
+	GatewayTimeout      = generic("gateway_timeout", fiber.StatusGatewayTimeout)
+	RequestHeaderTooBig = generic("request_header_too_large", fiber.StatusRequestHeaderFieldsTooLarge)
+)
+
+// Регистрирует вид ошибки и возвращает его. Повторный код вызывает панику, так как
+// регистрация происходит при инициализации пакетов и повтор означает ошибку в коде
+func Register(code string, status int, title string) *Kind {
+	registry.Lock()
+	defer registry.Unlock()
+
+	if _, ok := registry.byCode[code]; ok {
+		panic(fmt.Sprintf("problem: code %q is already registered", code))
+	}
+
+	kind := &Kind{
+		Code:   code,
+		Status: status,
+		Title:  title,
+		fiber:  fiber.NewError(status, title),
+	}
+	registry.byCode[code] = kind
+
+	return kind
+}
+
+// Регистрирует общий вид ошибки для HTTP статуса с его стандартным описанием
+func generic(code string, status int) *Kind {
+	kind := Register(code, status, utils.StatusMessage(status))
+
+	registry.Lock()
+	registry.byStatus[status] = kind
+	registry.Unlock()
+
+	return kind
+}
+
+// Возвращает вид ошибки по коду
+func Lookup(code string) (*Kind, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	kind, ok := registry.byCode[code]
+
+	return kind, ok
+}
+
+// Возвращает все зарегистрированные виды ошибок, упорядоченные по коду
+func Kinds() []*Kind {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	kinds := make([]*Kind, 0, len(registry.byCode))
+	for _, kind := range registry.byCode {
+		kinds = append(kinds, kind)
+	}
+	sort.Slice(kinds, func(i, j int) bool { return kinds[i].Code < kinds[j].Code })
+
+	return kinds
+}
+
+// Возвращает общий вид ошибки для HTTP статуса. Для статуса без общего вида создает
+// незарегистрированный вид с кодом http_<статус>
+func ForStatus(status int) *Kind {
+	registry.RLock()
+	kind, ok := registry.byStatus[status]
+	registry.RUnlock()
+
+	if ok {
+		return kind
+	}
+	return &Kind{
+		Code:   "http_" + strconv.Itoa(status),
+		Status: status,
+		Title:  utils.StatusMessage(status),
+		fiber:  fiber.NewError(status, utils.StatusMessage(status)),
+	}
+}
+
+// Возвращает описание вида
+func (k *Kind) Error() string {
+	return k.Title
+}
+
+// Возвращает fiber.Error с тем же статусом, чтобы errors.As находил статус ошибки так
+// же, как у ошибок Fiber
+func (k *Kind) Unwrap() error {
+	return k.fiber
+}
+
+// Создает ошибку этого вида с подробностями
+func (k *Kind) New(detail string) *Error {
+	return &Error{Kind: k, Detail: detail}
+}
+
+// Создает ошибку этого вида с подробностями и причиной
+func (k *Kind) Wrap(err error, detail string) *Error {
+	return &Error{Kind: k, Detail: detail, Err: err}
+}
+
+// Возвращает описание вида, подробности и причину
+func (e *Error) Error() string {
+	msg := e.Kind.Title
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Возвращает вид и причину для errors.Is и errors.As
+func (e *Error) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// Приводит любую ошибку к Error. Ошибки Fiber получают общий вид по статусу, а их
+// сообщение, если оно отличается от стандартного, становится подробностями. Остальные
+// ошибки считаются внутренними, а их текст остается только причиной
+func From(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+
+	var kind *Kind
+	if errors.As(err, &kind) {
+		return &Error{Kind: kind, Err: unwrapped(err, kind)}
+	}
+
+	var fe *fiber.Error
+	if errors.As(err, &fe) {
+		kind := ForStatus(fe.Code)
+
+		detail := fe.Message
+		if detail == kind.Title {
+			detail = ""
+		}
+		return &Error{Kind: kind, Detail: detail}
+	}
+
+	return &Error{Kind: Internal, Err: err}
+}
+
+// Возвращает причину, если вид был обернут в другую ошибку
+func unwrapped(err error, kind *Kind) error {
+	if err == error(kind) {
+		return nil
+	}
+	return err
+}
+
+// Возвращает ответ RFC 7807. Пустой UUID запроса оставляет instance пустым
+func (e *Error) Response(requestID string) responses.Problem {
+	problem := responses.Problem{
+		Type:   TypePrefix + e.Kind.Code,
+		Title:  e.Kind.Title,
+		Status: e.Kind.Status,
+		Detail: e.Detail,
+		Code:   e.Kind.Code,
+	}
+	if requestID != "" {
+		problem.Instance = InstancePrefix + requestID
+	}
+	return problem
+}
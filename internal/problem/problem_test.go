@@ -0,0 +1,220 @@
+package problem
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/xoticdsign/returnauf/models/responses"
+)
+
+// Вид ошибки для тестов
+var errTestKind = Register("test_kind", fiber.StatusConflict, "Test kind")
+
+// Unit тест для функции From
+func TestUnitFrom(t *testing.T) {
+	cause := errors.New("cause")
+
+	cases := []struct {
+		name       string
+		err        error
+		wantKind   *Kind
+		wantDetail string
+		wantErr    error
+	}{
+		{
+			name:     "kind case",
+			err:      errTestKind,
+			wantKind: errTestKind,
+		},
+		{
+			name:       "error case",
+			err:        errTestKind.Wrap(cause, "detail"),
+			wantKind:   errTestKind,
+			wantDetail: "detail",
+			wantErr:    cause,
+		},
+		{
+			name:       "wrapped error case",
+			err:        fmt.Errorf("context: %w", errTestKind.New("detail")),
+			wantKind:   errTestKind,
+			wantDetail: "detail",
+		},
+		{
+			name:     "fiber error case",
+			err:      fiber.ErrNotFound,
+			wantKind: NotFound,
+		},
+		{
+			name:       "fiber error with message case",
+			err:        fiber.NewError(fiber.StatusNotFound, "Cannot GET /x"),
+			wantKind:   NotFound,
+			wantDetail: "Cannot GET /x",
+		},
+		{
+			name:     "unknown error case",
+			err:      cause,
+			wantKind: Internal,
+			wantErr:  cause,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			got := From(cs.err)
+
+			assert.Equal(t, cs.wantKind, got.Kind)
+			assert.Equal(t, cs.wantDetail, got.Detail)
+			assert.Equal(t, cs.wantErr, got.Err)
+		})
+	}
+}
+
+// Unit тест для функции ForStatus
+func TestUnitForStatus(t *testing.T) {
+	cases := []struct {
+		name      string
+		status    int
+		wantCode  string
+		wantTitle string
+	}{
+		{
+			name:      "registered status case",
+			status:    fiber.StatusTooManyRequests,
+			wantCode:  "too_many_requests",
+			wantTitle: "Too Many Requests",
+		},
+		{
+			name:      "unregistered status case",
+			status:    fiber.StatusTeapot,
+			wantCode:  "http_418",
+			wantTitle: "I'm a teapot",
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			got := ForStatus(cs.status)
+
+			assert.Equal(t, cs.wantCode, got.Code)
+			assert.Equal(t, cs.status, got.Status)
+			assert.Equal(t, cs.wantTitle, got.Title)
+		})
+	}
+}
+
+// Unit тест для функции Register
+func TestUnitRegister(t *testing.T) {
+	cases := []struct {
+		name      string
+		code      string
+		wantPanic bool
+	}{
+		{
+			name:      "new code case",
+			code:      "test_register",
+			wantPanic: false,
+		},
+		{
+			name:      "duplicate code case",
+			code:      "test_kind",
+			wantPanic: true,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			register := func() { Register(cs.code, fiber.StatusBadRequest, "Test") }
+
+			if cs.wantPanic {
+				assert.Panics(t, register)
+				return
+			}
+			assert.NotPanics(t, register)
+
+			_, ok := Lookup(cs.code)
+			assert.True(t, ok)
+		})
+	}
+}
+
+// Unit тест для функции Unwrap
+func TestUnitUnwrap(t *testing.T) {
+	cause := errors.New("cause")
+
+	cases := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCause  bool
+	}{
+		{
+			name:       "kind case",
+			err:        errTestKind,
+			wantStatus: fiber.StatusConflict,
+			wantCause:  false,
+		},
+		{
+			name:       "error with cause case",
+			err:        errTestKind.Wrap(cause, ""),
+			wantStatus: fiber.StatusConflict,
+			wantCause:  true,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			var fe *fiber.Error
+
+			if assert.True(t, errors.As(cs.err, &fe)) {
+				assert.Equal(t, cs.wantStatus, fe.Code)
+			}
+			assert.True(t, errors.Is(cs.err, errTestKind))
+			assert.Equal(t, cs.wantCause, errors.Is(cs.err, cause))
+		})
+	}
+}
+
+// Unit тест для функции Response
+func TestUnitResponse(t *testing.T) {
+	cases := []struct {
+		name      string
+		err       *Error
+		requestID string
+		want      responses.Problem
+	}{
+		{
+			name:      "with request id case",
+			err:       errTestKind.New("detail"),
+			requestID: "0b3b7a8e",
+			want: responses.Problem{
+				Type:     "urn:returnauf:problem:test_kind",
+				Title:    "Test kind",
+				Status:   fiber.StatusConflict,
+				Detail:   "detail",
+				Instance: "urn:uuid:0b3b7a8e",
+				Code:     "test_kind",
+			},
+		},
+		{
+			name:      "without request id case",
+			err:       NotFound.New(""),
+			requestID: "",
+			want: responses.Problem{
+				Type:   "urn:returnauf:problem:not_found",
+				Title:  "Not Found",
+				Status: fiber.StatusNotFound,
+				Code:   "not_found",
+			},
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			assert.Equal(t, cs.want, cs.err.Response(cs.requestID))
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package render
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/vmihailenco/msgpack/v5"
+	"gopkg.in/yaml.v3"
+
+	"github.com/xoticdsign/returnauf/models/responses"
+)
+
+// Поддерживаемые форматы ответа
+const (
+	FormatJSON    = "json"
+	FormatXML     = "xml"
+	FormatText    = "text"
+	FormatYAML    = "yaml"
+	FormatMsgPack = "msgpack"
+)
+
+// MIME-типы форматов ответа
+const (
+	MIMEYAML    = "application/yaml"
+	MIMEMsgPack = "application/msgpack"
+
+	MIMEProblemJSON = "application/problem+json"
+	MIMEProblemXML  = "application/problem+xml"
+)
+
+// Соответствие MIME-типов из заголовка Accept форматам ответа. Порядок важен:
+// при равном приоритете выбирается тип, стоящий раньше
+var offers = []struct {
+	mime   string
+	format string
+}{
+	{fiber.MIMEApplicationJSON, FormatJSON},
+	{fiber.MIMEApplicationXML, FormatXML},
+	{fiber.MIMETextXML, FormatXML},
+	{fiber.MIMETextPlain, FormatText},
+	{MIMEYAML, FormatYAML},
+	{"application/x-yaml", FormatYAML},
+	{"text/yaml", FormatYAML},
+	{MIMEMsgPack, FormatMsgPack},
+	{"application/x-msgpack", FormatMsgPack},
+	{"application/vnd.msgpack", FormatMsgPack},
+}
+
+// Обертка для вывода списка цитат в XML с корневым элементом
+type xmlQuotes struct {
+	XMLName xml.Name          `xml:"Quotes"`
+	Quotes  []responses.Quote `xml:"Quote"`
+}
+
+// Определяет формат ответа по параметру format, а затем по заголовку Accept.
+// Если ни один формат не подходит, используется JSON
+func Negotiate(c *fiber.Ctx) string {
+	switch format := strings.ToLower(c.Query("format")); format {
+	case FormatJSON, FormatXML, FormatText, FormatYAML, FormatMsgPack:
+		return format
+	}
+
+	if c.Get(fiber.HeaderAccept) == "" {
+		return FormatJSON
+	}
+
+	mimes := make([]string, len(offers))
+	for i, offer := range offers {
+		mimes[i] = offer.mime
+	}
+
+	accepted := c.Accepts(mimes...)
+	for _, offer := range offers {
+		if offer.mime == accepted {
+			return offer.format
+		}
+	}
+	return FormatJSON
+}
+
+// Отправляет значение со статусом в формате, выбранном через Negotiate
+func Render(c *fiber.Ctx, status int, v interface{}) error {
+	c.Status(status)
+	c.Vary(fiber.HeaderAccept)
+
+	switch Negotiate(c) {
+	case FormatXML:
+		if quotes, ok := v.([]responses.Quote); ok {
+			v = xmlQuotes{Quotes: quotes}
+		}
+		if _, ok := v.(responses.Problem); ok {
+			b, err := xml.Marshal(v)
+			if err != nil {
+				return err
+			}
+			c.Set(fiber.HeaderContentType, MIMEProblemXML)
+
+			return c.Send(b)
+		}
+		return c.XML(v)
+
+	case FormatText:
+		c.Set(fiber.HeaderContentType, fiber.MIMETextPlainCharsetUTF8)
+
+		return c.SendString(Text(v))
+
+	case FormatYAML:
+		b, err := yaml.Marshal(v)
+		if err != nil {
+			return err
+		}
+		c.Set(fiber.HeaderContentType, MIMEYAML+"; charset=utf-8")
+
+		return c.Send(b)
+
+	case FormatMsgPack:
+		b, err := msgpack.Marshal(v)
+		if err != nil {
+			return err
+		}
+		c.Set(fiber.HeaderContentType, MIMEMsgPack)
+
+		return c.Send(b)
+	}
+	if _, ok := v.(responses.Problem); ok {
+		return c.JSON(v, MIMEProblemJSON)
+	}
+	return c.JSON(v)
+}
+
+// Представляет значение в виде простого текста для скриптов: цитата - ее текст,
+// список - строки "ID<TAB>цитата", ошибка - "статус описание: подробности"
+func Text(v interface{}) string {
+	switch v := v.(type) {
+	case responses.Quote:
+		return v.Quote + "\n"
+
+	case []responses.Quote:
+		var b strings.Builder
+		for _, quote := range v {
+			b.WriteString(strconv.Itoa(quote.ID))
+			b.WriteByte('\t')
+			b.WriteString(quote.Quote)
+			b.WriteByte('\n')
+		}
+		return b.String()
+
+	case responses.Problem:
+		if v.Detail == "" {
+			return strconv.Itoa(v.Status) + " " + v.Title + "\n"
+		}
+		return strconv.Itoa(v.Status) + " " + v.Title + ": " + v.Detail + "\n"
+	}
+	return fmt.Sprintln(v)
+}
@@ -0,0 +1,227 @@
+package render
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v5"
+	"gopkg.in/yaml.v3"
+
+	"github.com/xoticdsign/returnauf/models/responses"
+)
+
+// Unit тест для функции Negotiate
+func TestUnitNegotiate(t *testing.T) {
+	cases := []struct {
+		name   string
+		path   string
+		accept string
+		want   string
+	}{
+		{
+			name:   "no accept case",
+			path:   "/",
+			accept: "",
+			want:   FormatJSON,
+		},
+		{
+			name:   "browser accept case",
+			path:   "/",
+			accept: "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8",
+			want:   FormatXML,
+		},
+		{
+			name:   "plain text case",
+			path:   "/",
+			accept: "text/plain",
+			want:   FormatText,
+		},
+		{
+			name:   "weighted accept case",
+			path:   "/",
+			accept: "application/json;q=0.5, application/x-yaml",
+			want:   FormatYAML,
+		},
+		{
+			name:   "format override case",
+			path:   "/?format=msgpack",
+			accept: "application/json",
+			want:   FormatMsgPack,
+		},
+		{
+			name:   "unsupported accept case",
+			path:   "/",
+			accept: "image/png",
+			want:   FormatJSON,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			app := fiber.New()
+
+			var got string
+
+			app.Get("/", func(c *fiber.Ctx) error {
+				got = Negotiate(c)
+
+				return nil
+			})
+
+			req := httptest.NewRequest("GET", cs.path, nil)
+			if cs.accept != "" {
+				req.Header.Set("Accept", cs.accept)
+			}
+			app.Test(req, -1)
+
+			assert.Equal(t, cs.want, got)
+		})
+	}
+}
+
+// Unit тест для функции Render
+func TestUnitRender(t *testing.T) {
+	quote := responses.Quote{ID: 1, Quote: "Mock quote 1"}
+
+	cases := []struct {
+		name            string
+		path            string
+		value           interface{}
+		wantContentType string
+		decode          func(body []byte) (interface{}, error)
+		wantDecoded     interface{}
+	}{
+		{
+			name:            "json case",
+			path:            "/",
+			value:           quote,
+			wantContentType: fiber.MIMEApplicationJSON,
+			decode:          func(body []byte) (interface{}, error) { return string(body), nil },
+			wantDecoded:     `{"ID":1,"Quote":"Mock quote 1"}`,
+		},
+		{
+			name:            "xml list case",
+			path:            "/?format=xml",
+			value:           []responses.Quote{quote},
+			wantContentType: fiber.MIMEApplicationXML,
+			decode: func(body []byte) (interface{}, error) {
+				var v xmlQuotes
+				err := xml.Unmarshal(body, &v)
+
+				return v.Quotes, err
+			},
+			wantDecoded: []responses.Quote{quote},
+		},
+		{
+			name:            "text error case",
+			path:            "/?format=text",
+			value:           responses.Problem{Status: 404, Title: "Not Found"},
+			wantContentType: fiber.MIMETextPlainCharsetUTF8,
+			decode:          func(body []byte) (interface{}, error) { return string(body), nil },
+			wantDecoded:     "404 Not Found\n",
+		},
+		{
+			name:            "text error with detail case",
+			path:            "/?format=text",
+			value:           responses.Problem{Status: 400, Title: "Bad Request", Detail: "to_id must be a number"},
+			wantContentType: fiber.MIMETextPlainCharsetUTF8,
+			decode:          func(body []byte) (interface{}, error) { return string(body), nil },
+			wantDecoded:     "400 Bad Request: to_id must be a number\n",
+		},
+		{
+			name:            "json error case",
+			path:            "/",
+			value:           responses.Problem{Type: "urn:returnauf:problem:not_found", Title: "Not Found", Status: 404, Code: "not_found"},
+			wantContentType: MIMEProblemJSON,
+			decode:          func(body []byte) (interface{}, error) { return string(body), nil },
+			wantDecoded:     `{"type":"urn:returnauf:problem:not_found","title":"Not Found","status":404,"code":"not_found"}`,
+		},
+		{
+			name:            "xml error case",
+			path:            "/?format=xml",
+			value:           responses.Problem{Type: "urn:returnauf:problem:not_found", Title: "Not Found", Status: 404, Code: "not_found"},
+			wantContentType: MIMEProblemXML,
+			decode:          func(body []byte) (interface{}, error) { return string(body), nil },
+			wantDecoded:     `<problem xmlns="urn:ietf:rfc:7807"><type>urn:returnauf:problem:not_found</type><title>Not Found</title><status>404</status><code>not_found</code></problem>`,
+		},
+		{
+			name:            "yaml case",
+			path:            "/?format=yaml",
+			value:           quote,
+			wantContentType: MIMEYAML + "; charset=utf-8",
+			decode: func(body []byte) (interface{}, error) {
+				var v responses.Quote
+				err := yaml.Unmarshal(body, &v)
+
+				return v, err
+			},
+			wantDecoded: quote,
+		},
+		{
+			name:            "msgpack case",
+			path:            "/?format=msgpack",
+			value:           quote,
+			wantContentType: MIMEMsgPack,
+			decode: func(body []byte) (interface{}, error) {
+				var v responses.Quote
+				err := msgpack.Unmarshal(body, &v)
+
+				return v, err
+			},
+			wantDecoded: quote,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			app := fiber.New()
+
+			app.Get("/", func(c *fiber.Ctx) error {
+				return Render(c, fiber.StatusTeapot, cs.value)
+			})
+
+			req := httptest.NewRequest("GET", cs.path, nil)
+			resp, _ := app.Test(req, -1)
+
+			assert.Equal(t, fiber.StatusTeapot, resp.StatusCode)
+			assert.Equal(t, cs.wantContentType, resp.Header.Get("Content-Type"))
+
+			body, _ := io.ReadAll(resp.Body)
+
+			gotDecoded, err := cs.decode(body)
+			if assert.Nil(t, err) {
+				assert.Equal(t, cs.wantDecoded, gotDecoded)
+			}
+		})
+	}
+}
+
+// Unit тест для функции Text
+func TestUnitText(t *testing.T) {
+	cases := []struct {
+		name  string
+		value interface{}
+		want  string
+	}{
+		{
+			name:  "quote case",
+			value: responses.Quote{ID: 1, Quote: "Mock quote 1"},
+			want:  "Mock quote 1\n",
+		},
+		{
+			name:  "list case",
+			value: responses.TestQuotes[:2],
+			want:  "1\tMock quote 1\n2\tMock quote 2\n",
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			assert.Equal(t, cs.want, Text(cs.value))
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/xoticdsign/returnauf/internal/logging"
+	"github.com/xoticdsign/returnauf/internal/middleware"
+)
+
+// Ключ метаданных с ключом API, совпадает с параметром запроса REST API
+const keyMetadata = "returnauf-key"
+
+// Ключ метаданных с идентификатором запроса
+const requestIDMetadata = "x-request-id"
+
+// Тип ключа контекста с идентификатором запроса
+type requestIDKey struct{}
+
+// Возвращает идентификатор запроса из контекста вызова
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+
+	return id
+}
+
+// Берет идентификатор запроса из метаданных или создает новый, возвращает его
+// клиенту в заголовке и кладет в контекст
+func withRequestID(ctx context.Context) context.Context {
+	var id string
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(requestIDMetadata); len(values) > 0 && values[0] != "" {
+		id = values[0]
+	} else {
+		id = uuid.NewString()
+	}
+
+	// Ошибка означает, что заголовки уже отправлены, и не мешает вызову
+	grpc.SetHeader(ctx, metadata.Pairs(requestIDMetadata, id))
+
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// Кладет в контекст вызова Логгер с идентификатором запроса и методом, так же как
+// logging.Middleware для REST API
+func withLogger(ctx context.Context, logger logging.Logger, method string) context.Context {
+	if logger == nil {
+		logger = logging.Default()
+	}
+	return logging.NewContext(ctx, logger.With(
+		logging.String("UUID", RequestID(ctx)),
+		logging.String("Method", method),
+	))
+}
+
+// Логирует результат вызова в Логгер из контекста так же, как REST API логирует
+// результат запроса
+func logCall(ctx context.Context, err error) {
+	if err != nil {
+		logging.FromContext(ctx).Error(status.Convert(err).Message(), logging.String("Code", status.Code(err).String()))
+		return
+	}
+	logging.FromContext(ctx).Info("Обработан вызов")
+}
+
+// Возвращает унарный интерсептор идентификатора запроса и логирования
+func requestIDUnaryInterceptor(logger logging.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx = withLogger(withRequestID(ctx), logger, info.FullMethod)
+
+		resp, err := handler(ctx, req)
+		logCall(ctx, err)
+
+		return resp, err
+	}
+}
+
+// Возвращает потоковый интерсептор идентификатора запроса и логирования
+func requestIDStreamInterceptor(logger logging.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := withLogger(withRequestID(ss.Context()), logger, info.FullMethod)
+
+		err := handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+		logCall(ctx, err)
+
+		return err
+	}
+}
+
+// Проверяет ключ API из метаданных так же, как middleware.KeyauthValidator
+func authorize(ctx context.Context) error {
+	var key string
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(keyMetadata); len(values) > 0 {
+		key = values[0]
+	}
+	if key == "" {
+		return status.Error(codes.Unauthenticated, "missing or malformed API Key")
+	}
+
+	ok, _ := middleware.ValidateKey(key)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "invalid API Key")
+	}
+	return nil
+}
+
+// Унарный интерсептор проверки ключа API
+func authUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	err := authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// Потоковый интерсептор проверки ключа API
+func authStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	err := authorize(ss.Context())
+	if err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+// Поток с подмененным контекстом, чтобы обработчик видел идентификатор запроса
+type contextStream struct {
+	grpc.ServerStream
+
+	ctx context.Context
+}
+
+// Возвращает контекст потока
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,222 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/xoticdsign/returnauf/internal/database"
+	"github.com/xoticdsign/returnauf/internal/handlers"
+	"github.com/xoticdsign/returnauf/internal/logging"
+	"github.com/xoticdsign/returnauf/internal/problem"
+	"github.com/xoticdsign/returnauf/models/responses"
+	returnaufv1 "github.com/xoticdsign/returnauf/proto/returnauf/v1"
+)
+
+// Количество цитат в ответе Search по умолчанию
+const defaultSearchLimit = 20
+
+// Максимальное количество цитат в ответе Search
+const maxSearchLimit = 100
+
+// Ошибка, которой прерывается чтение цитат после набора нужного количества
+var errEnough = errors.New("enough quotes")
+
+// Структура, реализующая QuoteService поверх тех же зависимостей, что и REST API
+type Server struct {
+	returnaufv1.UnimplementedQuoteServiceServer
+
+	d *handlers.Dependencies
+}
+
+// Создает сервер gRPC с QuoteService и интерсепторами идентификатора запроса,
+// логирования и проверки ключа API
+func New(d *handlers.Dependencies, logger logging.Logger) *grpc.Server {
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			requestIDUnaryInterceptor(logger),
+			authUnaryInterceptor,
+		),
+		grpc.ChainStreamInterceptor(
+			requestIDStreamInterceptor(logger),
+			authStreamInterceptor,
+		),
+	)
+	returnaufv1.RegisterQuoteServiceServer(server, &Server{d: d})
+
+	return server
+}
+
+// Возвращает контекст вызова, ограниченный таймаутом операций с Кэшом и БД
+func (s *Server) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.d.Timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, s.d.Timeout)
+}
+
+// Возвращает цитату по ID
+func (s *Server) GetQuote(ctx context.Context, req *returnaufv1.GetQuoteRequest) (*returnaufv1.GetQuoteResponse, error) {
+	if req.GetId() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "id must not be negative")
+	}
+
+	ctx, cancel := s.context(ctx)
+	defer cancel()
+
+	quote, err := s.d.FindQuote(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return &returnaufv1.GetQuoteResponse{Quote: toProto(quote)}, nil
+}
+
+// Потоково возвращает цитаты, подходящие под фильтр
+func (s *Server) ListQuotes(req *returnaufv1.ListQuotesRequest, stream returnaufv1.QuoteService_ListQuotesServer) error {
+	filter, err := toFilter(req.GetFilter())
+	if err != nil {
+		return err
+	}
+
+	ctx := stream.Context()
+
+	err = s.d.Exporter.StreamQuotes(ctx, filter, func(quote responses.Quote) error {
+		return stream.Send(&returnaufv1.ListQuotesResponse{Quote: toProto(quote)})
+	})
+	if err != nil {
+		return toStatus(ctx, err)
+	}
+	return nil
+}
+
+// Возвращает случайную цитату
+func (s *Server) RandomQuote(ctx context.Context, req *returnaufv1.RandomQuoteRequest) (*returnaufv1.RandomQuoteResponse, error) {
+	ctx, cancel := s.context(ctx)
+	defer cancel()
+
+	id, err := s.d.RandomQuoteID(ctx)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	quote, err := s.d.FindQuote(ctx, id)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return &returnaufv1.RandomQuoteResponse{Quote: toProto(quote)}, nil
+}
+
+// Возвращает первые limit цитат, содержащих подстроку
+func (s *Server) Search(ctx context.Context, req *returnaufv1.SearchRequest) (*returnaufv1.SearchResponse, error) {
+	query := strings.TrimSpace(req.GetQuery())
+	if query == "" {
+		return nil, status.Error(codes.InvalidArgument, "query must not be empty")
+	}
+
+	limit := int(req.GetLimit())
+	switch {
+	case limit == 0:
+		limit = defaultSearchLimit
+	case limit < 0 || limit > maxSearchLimit:
+		return nil, status.Errorf(codes.InvalidArgument, "limit must be between 1 and %d", maxSearchLimit)
+	}
+
+	ctx, cancel := s.context(ctx)
+	defer cancel()
+
+	resp := &returnaufv1.SearchResponse{}
+
+	err := s.d.Exporter.StreamQuotes(ctx, database.Filter{Contains: query}, func(quote responses.Quote) error {
+		resp.Quotes = append(resp.Quotes, toProto(quote))
+		if len(resp.Quotes) >= limit {
+			return errEnough
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errEnough) {
+		return nil, toStatus(ctx, err)
+	}
+	return resp, nil
+}
+
+// Преобразует фильтр из запроса в фильтр БД
+func toFilter(filter *returnaufv1.QuoteFilter) (database.Filter, error) {
+	if filter.GetFromId() < 0 || filter.GetToId() < 0 {
+		return database.Filter{}, status.Error(codes.InvalidArgument, "filter ids must not be negative")
+	}
+	if filter.GetToId() > 0 && filter.GetFromId() > filter.GetToId() {
+		return database.Filter{}, status.Error(codes.InvalidArgument, "from_id must not exceed to_id")
+	}
+
+	return database.Filter{
+		FromID:   int(filter.GetFromId()),
+		ToID:     int(filter.GetToId()),
+		Contains: filter.GetContains(),
+	}, nil
+}
+
+// Преобразует цитату в сообщение protobuf
+func toProto(quote responses.Quote) *returnaufv1.Quote {
+	return &returnaufv1.Quote{
+		Id:    int64(quote.ID),
+		Quote: quote.Quote,
+	}
+}
+
+// Преобразует ошибку обработчиков в статус gRPC. Классы ошибок БД, виды ошибок и коды Fiber
+// переводятся в ближайшие коды gRPC, истечение контекста - в DeadlineExceeded или Canceled
+func toStatus(ctx context.Context, err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	if ctx.Err() != nil {
+		return status.FromContextError(ctx.Err()).Err()
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return status.FromContextError(err).Err()
+	}
+
+	switch {
+	case errors.Is(err, database.ErrInvalid):
+		return status.Error(codes.InvalidArgument, fiber.ErrBadRequest.Message)
+	case errors.Is(err, database.ErrNotFound):
+		return status.Error(codes.NotFound, fiber.ErrNotFound.Message)
+	case errors.Is(err, database.ErrConflict):
+		return status.Error(codes.AlreadyExists, fiber.ErrConflict.Message)
+	case errors.Is(err, database.ErrUnavailable):
+		return status.Error(codes.Unavailable, fiber.ErrServiceUnavailable.Message)
+	}
+
+	// Ошибки с видом из реестра переводятся по статусу вида, подробности становятся сообщением
+	var pe *problem.Error
+	if errors.As(err, &pe) {
+		message := pe.Detail
+		if message == "" {
+			message = pe.Kind.Title
+		}
+		err = fiber.NewError(pe.Kind.Status, message)
+	}
+
+	var e *fiber.Error
+
+	if errors.As(err, &e) {
+		switch e.Code {
+		case fiber.StatusBadRequest:
+			return status.Error(codes.InvalidArgument, e.Message)
+		case fiber.StatusUnauthorized:
+			return status.Error(codes.Unauthenticated, e.Message)
+		case fiber.StatusNotFound:
+			return status.Error(codes.NotFound, e.Message)
+		case fiber.StatusConflict:
+			return status.Error(codes.AlreadyExists, e.Message)
+		case fiber.StatusServiceUnavailable:
+			return status.Error(codes.Unavailable, e.Message)
+		}
+	}
+	return status.Error(codes.Internal, fiber.ErrInternalServerError.Message)
+}
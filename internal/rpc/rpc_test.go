@@ -0,0 +1,312 @@
+package rpc
+
+import (
+	"context"
+	"io"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/xoticdsign/returnauf/config"
+	"github.com/xoticdsign/returnauf/internal/cache"
+	"github.com/xoticdsign/returnauf/internal/database"
+	"github.com/xoticdsign/returnauf/internal/handlers"
+	"github.com/xoticdsign/returnauf/internal/logging"
+	returnaufv1 "github.com/xoticdsign/returnauf/proto/returnauf/v1"
+)
+
+// Имитация Support, всегда выбирающая последнюю цитату
+type stubSupport struct{}
+
+// Имитация метода RandInt
+func (s *stubSupport) RandInt(count int) (int, string) {
+	return count - 1, strconv.Itoa(count - 1)
+}
+
+// Запускает сервер gRPC поверх тестовых БД и Кэша и возвращает клиента
+func setupTestClient(t *testing.T, logger logging.Logger) returnaufv1.QuoteServiceClient {
+	conf := config.Current()
+	conf.ApiKey = "valid"
+	config.Set(conf)
+
+	redis, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(redis.Close)
+
+	Cache, err := cache.RunRedis(config.Redis{
+		Mode:  config.RedisModeSingle,
+		Addrs: []string{redis.Addr()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	DB, err := database.OpenGORM("db_test.sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	DB.MigrateQuotes()
+	t.Cleanup(DB.TeardownDB)
+
+	server := New(&handlers.Dependencies{
+		DB:       DB,
+		Exporter: DB,
+		Cache:    Cache,
+		Support:  &stubSupport{},
+		Timeout:  time.Second * 5,
+	}, logger)
+
+	listener := bufconn.Listen(1024 * 1024)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient(
+		"passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return returnaufv1.NewQuoteServiceClient(conn)
+}
+
+// Возвращает контекст с ключом API в метаданных
+func withKey(key string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), keyMetadata, key)
+}
+
+// Unit тест для функции GetQuote
+func TestUnitGetQuote(t *testing.T) {
+	cases := []struct {
+		name      string
+		key       string
+		id        int64
+		wantQuote string
+		wantCode  codes.Code
+	}{
+		{
+			name:      "existing quote case",
+			key:       "valid",
+			id:        1,
+			wantQuote: "Mock quote 1",
+			wantCode:  codes.OK,
+		},
+		{
+			name:     "missing quote case",
+			key:      "valid",
+			id:       999,
+			wantCode: codes.NotFound,
+		},
+		{
+			name:     "negative id case",
+			key:      "valid",
+			id:       -1,
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "wrong key case",
+			key:      "wrong",
+			id:       1,
+			wantCode: codes.Unauthenticated,
+		},
+		{
+			name:     "missing key case",
+			key:      "",
+			id:       1,
+			wantCode: codes.Unauthenticated,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			client := setupTestClient(t, logging.NewRecorder())
+
+			resp, err := client.GetQuote(withKey(cs.key), &returnaufv1.GetQuoteRequest{Id: cs.id})
+
+			assert.Equal(t, cs.wantCode, status.Code(err))
+			assert.Equal(t, cs.wantQuote, resp.GetQuote().GetQuote())
+		})
+	}
+}
+
+// Unit тест для функции ListQuotes
+func TestUnitListQuotes(t *testing.T) {
+	cases := []struct {
+		name     string
+		filter   *returnaufv1.QuoteFilter
+		wantIDs  []int64
+		wantCode codes.Code
+	}{
+		{
+			name:     "all quotes case",
+			filter:   nil,
+			wantIDs:  []int64{1, 2, 3},
+			wantCode: codes.OK,
+		},
+		{
+			name:     "filtered quotes case",
+			filter:   &returnaufv1.QuoteFilter{FromId: 2, Contains: "3"},
+			wantIDs:  []int64{3},
+			wantCode: codes.OK,
+		},
+		{
+			name:     "invalid range case",
+			filter:   &returnaufv1.QuoteFilter{FromId: 3, ToId: 1},
+			wantCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			client := setupTestClient(t, logging.NewRecorder())
+
+			stream, err := client.ListQuotes(withKey("valid"), &returnaufv1.ListQuotesRequest{Filter: cs.filter})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var gotIDs []int64
+
+			for {
+				resp, err := stream.Recv()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					assert.Equal(t, cs.wantCode, status.Code(err))
+					break
+				}
+				gotIDs = append(gotIDs, resp.GetQuote().GetId())
+			}
+
+			assert.Equal(t, cs.wantIDs, gotIDs)
+		})
+	}
+}
+
+// Unit тест для функции RandomQuote
+func TestUnitRPCRandomQuote(t *testing.T) {
+	client := setupTestClient(t, logging.NewRecorder())
+
+	resp, err := client.RandomQuote(withKey("valid"), &returnaufv1.RandomQuoteRequest{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), resp.GetQuote().GetId())
+}
+
+// Unit тест для функции Search
+func TestUnitSearch(t *testing.T) {
+	cases := []struct {
+		name     string
+		query    string
+		limit    int32
+		wantIDs  []int64
+		wantCode codes.Code
+	}{
+		{
+			name:     "default limit case",
+			query:    "quote",
+			wantIDs:  []int64{1, 2, 3},
+			wantCode: codes.OK,
+		},
+		{
+			name:     "limited case",
+			query:    "quote",
+			limit:    2,
+			wantIDs:  []int64{1, 2},
+			wantCode: codes.OK,
+		},
+		{
+			name:     "empty query case",
+			query:    " ",
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "limit too large case",
+			query:    "quote",
+			limit:    maxSearchLimit + 1,
+			wantCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			client := setupTestClient(t, logging.NewRecorder())
+
+			resp, err := client.Search(withKey("valid"), &returnaufv1.SearchRequest{Query: cs.query, Limit: cs.limit})
+
+			assert.Equal(t, cs.wantCode, status.Code(err))
+
+			var gotIDs []int64
+			for _, quote := range resp.GetQuotes() {
+				gotIDs = append(gotIDs, quote.GetId())
+			}
+			assert.Equal(t, cs.wantIDs, gotIDs)
+		})
+	}
+}
+
+// Unit тест для интерсептора идентификатора запроса
+func TestUnitRequestIDInterceptor(t *testing.T) {
+	cases := []struct {
+		name      string
+		requestID string
+	}{
+		{
+			name:      "client request id case",
+			requestID: "client-id",
+		},
+		{
+			name:      "generated request id case",
+			requestID: "",
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			logger := logging.NewRecorder()
+			client := setupTestClient(t, logger)
+
+			ctx := withKey("valid")
+			if cs.requestID != "" {
+				ctx = metadata.AppendToOutgoingContext(ctx, requestIDMetadata, cs.requestID)
+			}
+
+			var header metadata.MD
+
+			client.GetQuote(ctx, &returnaufv1.GetQuoteRequest{Id: 1}, grpc.Header(&header))
+
+			got := header.Get(requestIDMetadata)
+
+			assert.Len(t, got, 1)
+			assert.NotEmpty(t, got[0])
+
+			entries := logger.Entries()
+
+			assert.Len(t, entries, 1)
+			assert.Equal(t, got[0], entries[0].Fields["UUID"])
+			assert.Equal(t, returnaufv1.QuoteService_GetQuote_FullMethodName, entries[0].Fields["Method"])
+
+			if cs.requestID != "" {
+				assert.Equal(t, cs.requestID, got[0])
+			}
+		})
+	}
+}
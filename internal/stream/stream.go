@@ -0,0 +1,214 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/xoticdsign/returnauf/models/responses"
+)
+
+// Типы событий потока
+const (
+	EventRandom  = "random"
+	EventCreated = "created"
+	EventUpdated = "updated"
+)
+
+// Размер буфера событий одного подписчика. Медленный подписчик пропускает события,
+// не задерживая остальных
+const bufferSize = 16
+
+// Ошибка при превышении числа одновременных потоков для ключа API
+var ErrTooManyConnections = errors.New("too many stream connections for this API key")
+
+// Ошибка при подписке на остановленный хаб
+var ErrClosed = errors.New("stream hub is closed")
+
+// Событие потока цитат
+type Event struct {
+	Type  string          `json:"type"`
+	Quote responses.Quote `json:"quote"`
+}
+
+// Хаб, рассылающий события об изменении цитат всем открытым потокам и
+// ограничивающий число потоков на ключ API
+type Hub struct {
+	mu          sync.Mutex
+	limit       int
+	subscribers map[*Subscription]struct{}
+	conns       map[string]int
+	done        chan struct{}
+	closed      bool
+}
+
+// Подписка на события хаба. Закрывается вызовом Close
+type Subscription struct {
+	hub    *Hub
+	key    string
+	events chan Event
+	once   sync.Once
+}
+
+// Создает хаб. limit ограничивает число одновременных потоков на ключ API, 0 - без
+// ограничения
+func New(limit int) *Hub {
+	return &Hub{
+		limit:       limit,
+		subscribers: map[*Subscription]struct{}{},
+		conns:       map[string]int{},
+		done:        make(chan struct{}),
+	}
+}
+
+// Меняет ограничение числа потоков на ключ API. Уже открытые потоки не закрываются
+func (h *Hub) SetLimit(limit int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.limit = limit
+}
+
+// Подписывает поток с ключом API на события
+func (h *Hub) Subscribe(key string) (*Subscription, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil, ErrClosed
+	}
+	if h.limit > 0 && h.conns[key] >= h.limit {
+		return nil, ErrTooManyConnections
+	}
+
+	sub := &Subscription{
+		hub:    h,
+		key:    key,
+		events: make(chan Event, bufferSize),
+	}
+	h.subscribers[sub] = struct{}{}
+	h.conns[key]++
+
+	return sub, nil
+}
+
+// Рассылает события всем подписчикам
+func (h *Hub) Publish(events ...Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subscribers {
+		for _, event := range events {
+			select {
+			case sub.events <- event:
+			default:
+			}
+		}
+	}
+}
+
+// Возвращает число открытых потоков для ключа API
+func (h *Hub) Connections(key string) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.conns[key]
+}
+
+// Возвращает канал, закрывающийся при остановке хаба
+func (h *Hub) Done() <-chan struct{} {
+	return h.done
+}
+
+// Останавливает хаб: новые подписки отклоняются, открытые потоки завершаются
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return
+	}
+	h.closed = true
+	close(h.done)
+}
+
+// Возвращает канал событий подписки
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Отписывает поток от хаба и освобождает место в лимите ключа API
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		s.hub.mu.Lock()
+		defer s.hub.mu.Unlock()
+
+		delete(s.hub.subscribers, s)
+
+		s.hub.conns[s.key]--
+		if s.hub.conns[s.key] <= 0 {
+			delete(s.hub.conns, s.key)
+		}
+	})
+}
+
+// Интерфейс транспорта потока: SSE или WebSocket
+type Writer interface {
+	Send(event Event) error
+	Heartbeat() error
+}
+
+// Параметры потока
+type Options struct {
+	Interval  time.Duration
+	Heartbeat time.Duration
+}
+
+// Отправляет случайную цитату сразу и затем раз в Interval, пересылает события
+// подписки и шлет сигналы активности раз в Heartbeat. Завершается при ошибке записи,
+// отмене контекста или остановке хаба. Ошибка получения случайной цитаты пропускает
+// один такт
+func Run(ctx context.Context, sub *Subscription, opts Options, random func(ctx context.Context) (responses.Quote, error), w Writer) error {
+	sendRandom := func() error {
+		quote, err := random(ctx)
+		if err != nil {
+			return nil
+		}
+		return w.Send(Event{Type: EventRandom, Quote: quote})
+	}
+
+	err := sendRandom()
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+
+	heartbeat := time.NewTicker(opts.Heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case <-sub.hub.Done():
+			return nil
+
+		case event := <-sub.Events():
+			err = w.Send(event)
+
+		case <-ticker.C:
+			err = sendRandom()
+
+		case <-heartbeat.C:
+			err = w.Heartbeat()
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/xoticdsign/returnauf/models/responses"
+)
+
+// Транспорт для тестов, запоминающий события и останавливающийся после заданного
+// числа событий
+type stubWriter struct {
+	events     []Event
+	heartbeats int
+	stopAfter  int
+	err        error
+}
+
+// Имитация метода Send
+func (w *stubWriter) Send(event Event) error {
+	w.events = append(w.events, event)
+
+	if w.err != nil {
+		return w.err
+	}
+	if w.stopAfter > 0 && len(w.events) >= w.stopAfter {
+		return errStop
+	}
+	return nil
+}
+
+// Имитация метода Heartbeat
+func (w *stubWriter) Heartbeat() error {
+	w.heartbeats++
+
+	return nil
+}
+
+// Ошибка, которой тестовый транспорт завершает поток
+var errStop = errors.New("stop")
+
+// Unit тест для функции Subscribe
+func TestUnitSubscribe(t *testing.T) {
+	cases := []struct {
+		name                  string
+		limit                 int
+		open                  int
+		closeHub              bool
+		wantSubscribeToReturn error
+	}{
+		{
+			name:                  "within limit case",
+			limit:                 2,
+			open:                  1,
+			wantSubscribeToReturn: nil,
+		},
+		{
+			name:                  "limit exceeded case",
+			limit:                 2,
+			open:                  2,
+			wantSubscribeToReturn: ErrTooManyConnections,
+		},
+		{
+			name:                  "no limit case",
+			limit:                 0,
+			open:                  10,
+			wantSubscribeToReturn: nil,
+		},
+		{
+			name:                  "closed hub case",
+			limit:                 2,
+			open:                  0,
+			closeHub:              true,
+			wantSubscribeToReturn: ErrClosed,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			hub := New(cs.limit)
+
+			for i := 0; i < cs.open; i++ {
+				hub.Subscribe("key")
+			}
+			if cs.closeHub {
+				hub.Close()
+			}
+
+			_, gotErr := hub.Subscribe("key")
+
+			assert.Equal(t, cs.wantSubscribeToReturn, gotErr)
+
+			_, otherErr := hub.Subscribe("other")
+
+			assert.Equal(t, cs.closeHub, otherErr == ErrClosed)
+		})
+	}
+}
+
+// Unit тест для функции SetLimit
+func TestUnitSetLimit(t *testing.T) {
+	hub := New(1)
+
+	hub.Subscribe("key")
+
+	_, err := hub.Subscribe("key")
+
+	assert.Equal(t, ErrTooManyConnections, err)
+
+	hub.SetLimit(2)
+
+	_, err = hub.Subscribe("key")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, hub.Connections("key"))
+}
+
+// Unit тест для функции Close подписки
+func TestUnitSubscriptionClose(t *testing.T) {
+	hub := New(1)
+
+	sub, err := hub.Subscribe("key")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sub.Close()
+	sub.Close()
+
+	assert.Equal(t, 0, hub.Connections("key"))
+
+	_, err = hub.Subscribe("key")
+
+	assert.NoError(t, err)
+}
+
+// Unit тест для функции Publish
+func TestUnitPublish(t *testing.T) {
+	hub := New(0)
+
+	a, _ := hub.Subscribe("a")
+	b, _ := hub.Subscribe("b")
+	closed, _ := hub.Subscribe("c")
+	closed.Close()
+
+	event := Event{Type: EventCreated, Quote: responses.Quote{ID: 10, Quote: "New quote"}}
+
+	for i := 0; i < bufferSize+5; i++ {
+		hub.Publish(event)
+	}
+
+	assert.Len(t, a.Events(), bufferSize)
+	assert.Len(t, b.Events(), bufferSize)
+	assert.Len(t, closed.Events(), 0)
+	assert.Equal(t, event, <-a.Events())
+}
+
+// Unit тест для функции Run
+func TestUnitRun(t *testing.T) {
+	random := func(ctx context.Context) (responses.Quote, error) {
+		return responses.TestQuotes[0], nil
+	}
+
+	cases := []struct {
+		name          string
+		opts          Options
+		publish       []Event
+		random        func(ctx context.Context) (responses.Quote, error)
+		writer        *stubWriter
+		closeHub      bool
+		wantTypes     []string
+		wantRunToFail bool
+	}{
+		{
+			name:          "random and published events case",
+			opts:          Options{Interval: time.Millisecond * 10, Heartbeat: time.Hour},
+			publish:       []Event{{Type: EventUpdated, Quote: responses.TestQuotes[1]}},
+			random:        random,
+			writer:        &stubWriter{stopAfter: 3},
+			wantTypes:     []string{EventRandom, EventUpdated, EventRandom},
+			wantRunToFail: true,
+		},
+		{
+			name:          "random error skips tick case",
+			opts:          Options{Interval: time.Millisecond * 10, Heartbeat: time.Hour},
+			publish:       []Event{{Type: EventCreated, Quote: responses.TestQuotes[2]}},
+			random:        func(ctx context.Context) (responses.Quote, error) { return responses.Quote{}, errors.New("error") },
+			writer:        &stubWriter{stopAfter: 1},
+			wantTypes:     []string{EventCreated},
+			wantRunToFail: true,
+		},
+		{
+			name:          "closed hub case",
+			opts:          Options{Interval: time.Hour, Heartbeat: time.Hour},
+			random:        random,
+			writer:        &stubWriter{},
+			closeHub:      true,
+			wantTypes:     []string{EventRandom},
+			wantRunToFail: false,
+		},
+		{
+			name:          "write error case",
+			opts:          Options{Interval: time.Hour, Heartbeat: time.Hour},
+			random:        random,
+			writer:        &stubWriter{err: errors.New("error")},
+			wantTypes:     []string{EventRandom},
+			wantRunToFail: true,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			hub := New(0)
+
+			sub, _ := hub.Subscribe("key")
+			defer sub.Close()
+
+			hub.Publish(cs.publish...)
+			if cs.closeHub {
+				hub.Close()
+			}
+
+			gotErr := Run(context.Background(), sub, cs.opts, cs.random, cs.writer)
+
+			gotTypes := []string{}
+			for _, event := range cs.writer.events {
+				gotTypes = append(gotTypes, event.Type)
+			}
+
+			assert.Equal(t, cs.wantTypes, gotTypes)
+			assert.Equal(t, cs.wantRunToFail, gotErr != nil)
+		})
+	}
+}
+
+// Unit тест для сигналов активности в функции Run
+func TestUnitRunHeartbeat(t *testing.T) {
+	hub := New(0)
+
+	sub, _ := hub.Subscribe("key")
+	defer sub.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*55)
+	defer cancel()
+
+	writer := &stubWriter{}
+
+	err := Run(ctx, sub, Options{Interval: time.Hour, Heartbeat: time.Millisecond * 10}, func(ctx context.Context) (responses.Quote, error) {
+		return responses.TestQuotes[0], nil
+	}, writer)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.GreaterOrEqual(t, writer.heartbeats, 3)
+}
@@ -0,0 +1,111 @@
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/xoticdsign/returnauf/internal/cache"
+)
+
+// Декоратор Cacher, создающий спан на каждую операцию с Кэшом
+type tracedCache struct {
+	next   cache.Cacher
+	tracer trace.Tracer
+}
+
+// Оборачивает Кэш, добавляя спаны. Промах Get ошибкой не считается
+func Cache(next cache.Cacher, tracer trace.Tracer) cache.Cacher {
+	return &tracedCache{next: next, tracer: tracer}
+}
+
+// Начинает спан операции
+func (c *tracedCache) start(ctx context.Context, operation string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append(attrs,
+		attribute.String("db.system", "redis"),
+		attribute.String("db.operation.name", operation),
+	)
+
+	return c.tracer.Start(ctx, "cache."+operation, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+// Завершает спан, отмечая ошибку
+func end(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Сохраняет значение в Кэш
+func (c *tracedCache) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	ctx, span := c.start(ctx, "set", attribute.String("cache.key", key))
+
+	err := c.next.Set(ctx, key, value, expiration)
+	end(span, err)
+
+	return err
+}
+
+// Получает значение из Кэша
+func (c *tracedCache) Get(ctx context.Context, key string) (string, error) {
+	ctx, span := c.start(ctx, "get", attribute.String("cache.key", key))
+
+	value, err := c.next.Get(ctx, key)
+
+	span.SetAttributes(attribute.Bool("cache.hit", err == nil))
+	if err == redis.Nil {
+		end(span, nil)
+	} else {
+		end(span, err)
+	}
+	return value, err
+}
+
+// Возвращает оставшееся время жизни ключа
+func (c *tracedCache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	ctx, span := c.start(ctx, "ttl", attribute.String("cache.key", key))
+
+	ttl, err := c.next.TTL(ctx, key)
+	if err == redis.Nil {
+		end(span, nil)
+	} else {
+		end(span, err)
+	}
+	return ttl, err
+}
+
+// Удаляет ключи из Кэша
+func (c *tracedCache) Delete(ctx context.Context, keys ...string) (int, error) {
+	ctx, span := c.start(ctx, "delete", attribute.Int("cache.keys", len(keys)))
+
+	n, err := c.next.Delete(ctx, keys...)
+	end(span, err)
+
+	return n, err
+}
+
+// Удаляет ключи по шаблону
+func (c *tracedCache) DeletePattern(ctx context.Context, pattern string) (int, error) {
+	ctx, span := c.start(ctx, "delete_pattern", attribute.String("cache.pattern", pattern))
+
+	n, err := c.next.DeletePattern(ctx, pattern)
+	end(span, err)
+
+	return n, err
+}
+
+// Очищает Кэш
+func (c *tracedCache) Flush(ctx context.Context) (int, error) {
+	ctx, span := c.start(ctx, "flush")
+
+	n, err := c.next.Flush(ctx)
+	end(span, err)
+
+	return n, err
+}
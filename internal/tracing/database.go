@@ -0,0 +1,215 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/xoticdsign/returnauf/internal/database"
+	"github.com/xoticdsign/returnauf/models/responses"
+)
+
+// Декоратор Queuer, создающий спан на каждый запрос к БД
+type tracedQueuer struct {
+	next   database.Queuer
+	tracer trace.Tracer
+}
+
+// Оборачивает БД, добавляя спаны. Отсутствие записи ошибкой не считается
+func Queuer(next database.Queuer, tracer trace.Tracer) database.Queuer {
+	return &tracedQueuer{next: next, tracer: tracer}
+}
+
+// Начинает спан запроса
+func (q *tracedQueuer) start(ctx context.Context, operation string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return startDB(ctx, q.tracer, operation, attrs...)
+}
+
+// Завершает спан запроса
+func (q *tracedQueuer) end(span trace.Span, err error) {
+	endDB(span, err)
+}
+
+// Начинает клиентский спан запроса к БД
+func startDB(ctx context.Context, tracer trace.Tracer, operation string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append(attrs,
+		attribute.String("db.system", "sqlite"),
+		attribute.String("db.operation.name", operation),
+	)
+
+	return tracer.Start(ctx, "db."+operation, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+// Завершает спан запроса к БД. Отсутствие записи ошибкой не считается
+func endDB(span trace.Span, err error) {
+	if errors.Is(err, database.ErrNotFound) {
+		span.SetAttributes(attribute.Bool("db.not_found", true))
+		err = nil
+	}
+	end(span, err)
+}
+
+// Возвращает количество записей в БД
+func (q *tracedQueuer) QuotesCount(ctx context.Context) (int, error) {
+	ctx, span := q.start(ctx, "quotes_count")
+
+	count, err := q.next.QuotesCount(ctx)
+	q.end(span, err)
+
+	return count, err
+}
+
+// Возвращает ID записи по смещению в порядке ID
+func (q *tracedQueuer) QuoteIDAt(ctx context.Context, offset int) (int, error) {
+	ctx, span := q.start(ctx, "quote_id_at", attribute.Int("page.offset", offset))
+
+	id, err := q.next.QuoteIDAt(ctx, offset)
+	q.end(span, err)
+
+	return id, err
+}
+
+// Возвращает цитаты, подходящие под фильтр
+func (q *tracedQueuer) ListAll(ctx context.Context, filter database.Filter) ([]responses.Quote, error) {
+	ctx, span := q.start(ctx, "list_all", attribute.Bool("quote.filtered", !filter.IsZero()))
+
+	quotes, err := q.next.ListAll(ctx, filter)
+	q.end(span, err)
+
+	return quotes, err
+}
+
+// Возвращает цитату по ID
+func (q *tracedQueuer) GetQuote(ctx context.Context, id string) (responses.Quote, error) {
+	ctx, span := q.start(ctx, "get_quote", attribute.String("quote.id", id))
+
+	quote, err := q.next.GetQuote(ctx, id)
+	q.end(span, err)
+
+	return quote, err
+}
+
+// Декоратор Importer, создающий спан на каждый импорт
+type tracedImporter struct {
+	next   database.Importer
+	tracer trace.Tracer
+}
+
+// Оборачивает импорт в БД, добавляя спаны
+func Importer(next database.Importer, tracer trace.Tracer) database.Importer {
+	return &tracedImporter{next: next, tracer: tracer}
+}
+
+// Импортирует цитаты
+func (i *tracedImporter) ImportQuotes(ctx context.Context, quotes []responses.Quote, policy string, dryRun bool) (database.ImportResult, error) {
+	ctx, span := startDB(ctx, i.tracer, "import_quotes",
+		attribute.Int("import.quotes", len(quotes)),
+		attribute.String("import.policy", policy),
+		attribute.Bool("import.dry_run", dryRun),
+	)
+
+	imported, err := i.next.ImportQuotes(ctx, quotes, policy, dryRun)
+	endDB(span, err)
+
+	return imported, err
+}
+
+// Декоратор Exporter, создающий спан на каждую выборку по фильтру
+type tracedExporter struct {
+	next   database.Exporter
+	tracer trace.Tracer
+}
+
+// Оборачивает выборки цитат по фильтру, добавляя спаны. Спан выгрузки охватывает и
+// обработку каждой строки
+func Exporter(next database.Exporter, tracer trace.Tracer) database.Exporter {
+	return &tracedExporter{next: next, tracer: tracer}
+}
+
+// Построчно читает цитаты, подходящие под фильтр
+func (e *tracedExporter) StreamQuotes(ctx context.Context, filter database.Filter, fn func(responses.Quote) error) error {
+	ctx, span := startDB(ctx, e.tracer, "stream_quotes", attribute.Bool("quote.filtered", !filter.IsZero()))
+
+	err := e.next.StreamQuotes(ctx, filter, fn)
+	endDB(span, err)
+
+	return err
+}
+
+// Возвращает количество цитат, подходящих под фильтр
+func (e *tracedExporter) CountQuotes(ctx context.Context, filter database.Filter) (int, error) {
+	ctx, span := startDB(ctx, e.tracer, "count_quotes", attribute.Bool("quote.filtered", !filter.IsZero()))
+
+	count, err := e.next.CountQuotes(ctx, filter)
+	endDB(span, err)
+
+	return count, err
+}
+
+// Возвращает страницу цитат, подходящих под фильтр
+func (e *tracedExporter) PageQuotes(ctx context.Context, filter database.Filter, offset int, limit int) ([]responses.Quote, error) {
+	ctx, span := startDB(ctx, e.tracer, "page_quotes",
+		attribute.Bool("quote.filtered", !filter.IsZero()),
+		attribute.Int("page.offset", offset),
+		attribute.Int("page.limit", limit),
+	)
+
+	quotes, err := e.next.PageQuotes(ctx, filter, offset, limit)
+	endDB(span, err)
+
+	return quotes, err
+}
+
+// Декоратор Feeder, создающий спан на каждый запрос лент
+type tracedFeeder struct {
+	next   database.Feeder
+	tracer trace.Tracer
+}
+
+// Оборачивает запросы лент к БД, добавляя спаны
+func Feeder(next database.Feeder, tracer trace.Tracer) database.Feeder {
+	return &tracedFeeder{next: next, tracer: tracer}
+}
+
+// Возвращает состояние таблицы цитат
+func (f *tracedFeeder) FeedState(ctx context.Context) (database.FeedState, error) {
+	ctx, span := startDB(ctx, f.tracer, "feed_state")
+
+	state, err := f.next.FeedState(ctx)
+	endDB(span, err)
+
+	return state, err
+}
+
+// Возвращает последние добавленные цитаты
+func (f *tracedFeeder) LatestQuotes(ctx context.Context, limit int) ([]database.FeedEntry, error) {
+	ctx, span := startDB(ctx, f.tracer, "latest_quotes", attribute.Int("page.limit", limit))
+
+	entries, err := f.next.LatestQuotes(ctx, limit)
+	endDB(span, err)
+
+	return entries, err
+}
+
+// Декоратор BatchQueuer, создающий спан на каждый пакетный запрос
+type tracedBatcher struct {
+	next   database.BatchQueuer
+	tracer trace.Tracer
+}
+
+// Оборачивает пакетное чтение из БД, добавляя спаны
+func Batcher(next database.BatchQueuer, tracer trace.Tracer) database.BatchQueuer {
+	return &tracedBatcher{next: next, tracer: tracer}
+}
+
+// Возвращает цитаты с указанными ID
+func (b *tracedBatcher) GetQuotes(ctx context.Context, ids []int) ([]responses.Quote, error) {
+	ctx, span := startDB(ctx, b.tracer, "get_quotes", attribute.Int("quote.ids", len(ids)))
+
+	quotes, err := b.next.GetQuotes(ctx, ids)
+	endDB(span, err)
+
+	return quotes, err
+}
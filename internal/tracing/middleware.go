@@ -0,0 +1,91 @@
+package tracing
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Заголовок ответа с ID трассировки, чтобы по нему можно было найти трассу
+const TraceIDHeader = "X-Trace-Id"
+
+// Адаптер заголовков запроса Fiber для извлечения контекста трассировки
+type requestCarrier struct {
+	c *fiber.Ctx
+}
+
+// Возвращает значение заголовка запроса
+func (r requestCarrier) Get(key string) string {
+	return r.c.Get(key)
+}
+
+// Заголовки запроса не меняются
+func (r requestCarrier) Set(key string, value string) {}
+
+// Возвращает имена заголовков запроса
+func (r requestCarrier) Keys() []string {
+	var keys []string
+
+	r.c.Request().Header.VisitAll(func(key, _ []byte) {
+		keys = append(keys, string(key))
+	})
+	return keys
+}
+
+// Возвращает промежуточный обработчик, создающий серверный спан для каждого запроса.
+// Родительский контекст берется из заголовка traceparent, а спан связывается с
+// идентификатором запроса uuid, поэтому обработчик должен стоять после requestid.
+// Контекст со спаном передается хендлерам через UserContext, а logging.Middleware,
+// стоящий после этого обработчика, добавляет TraceID в записи запроса
+func (p *Provider) Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := p.propagator.Extract(c.UserContext(), requestCarrier{c: c})
+
+		ctx, span := p.tracer.Start(ctx, c.Method(),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", c.Method()),
+				attribute.String("url.path", c.Path()),
+			),
+		)
+		defer span.End()
+
+		if id, ok := c.Locals("uuid").(string); ok {
+			span.SetAttributes(attribute.String("request.id", id))
+		}
+		if span.SpanContext().HasTraceID() {
+			c.Set(TraceIDHeader, span.SpanContext().TraceID().String())
+		}
+
+		c.SetUserContext(ctx)
+
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			status = fiber.StatusInternalServerError
+
+			var e *fiber.Error
+			if errors.As(err, &e) {
+				status = e.Code
+			}
+			span.RecordError(err)
+		}
+
+		route := c.Route().Path
+
+		span.SetName(c.Method() + " " + route)
+		span.SetAttributes(
+			attribute.String("http.route", route),
+			attribute.Int("http.response.status_code", status),
+		)
+		if status >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, strconv.Itoa(status))
+		}
+		return err
+	}
+}
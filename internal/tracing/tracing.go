@@ -0,0 +1,123 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/xoticdsign/returnauf/config"
+)
+
+// Имя трассировщика приложения
+const tracerName = "github.com/xoticdsign/returnauf"
+
+// Ошибка неизвестного экспортера трассировки
+var ErrUnknownExporter = errors.New("unknown tracing exporter")
+
+// Структура с трассировщиком и функцией, сбрасывающей накопленные спаны при остановке
+type Provider struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+	shutdown   func(ctx context.Context) error
+}
+
+// Создает трассировщик с выбранным экспортером. Для экспортера none спаны не
+// записываются, но заголовки traceparent по-прежнему передаются дальше
+func Init(conf config.Tracing) (*Provider, error) {
+	propagator := propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+	otel.SetTextMapPropagator(propagator)
+
+	var exporter sdktrace.SpanExporter
+	var closer io.Closer
+
+	switch conf.Exporter {
+	case config.TracingExporterNone, "":
+		return &Provider{
+			tracer:     noop.NewTracerProvider().Tracer(tracerName),
+			propagator: propagator,
+			shutdown:   func(ctx context.Context) error { return nil },
+		}, nil
+
+	case config.TracingExporterStdout:
+		var w io.Writer = os.Stdout
+
+		if conf.File != "" {
+			f, err := os.OpenFile(conf.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+			if err != nil {
+				return nil, err
+			}
+			w, closer = f, f
+		}
+
+		e, err := stdouttrace.New(stdouttrace.WithWriter(w))
+		if err != nil {
+			return nil, err
+		}
+		exporter = e
+
+	case config.TracingExporterOTLP:
+		var opts []otlptracehttp.Option
+		if conf.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpointURL(conf.Endpoint))
+		}
+
+		e, err := otlptracehttp.New(context.Background(), opts...)
+		if err != nil {
+			return nil, err
+		}
+		exporter = e
+
+	default:
+		return nil, ErrUnknownExporter
+	}
+
+	res := resource.NewSchemaless(semconv.ServiceName(conf.ServiceName))
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+
+	return &Provider{
+		tracer:     provider.Tracer(tracerName),
+		propagator: propagator,
+		shutdown: func(ctx context.Context) error {
+			err := provider.Shutdown(ctx)
+			if closer != nil {
+				closer.Close()
+			}
+			return err
+		},
+	}, nil
+}
+
+// Создает трассировщик поверх готового TracerProvider, например для тестов
+func New(provider trace.TracerProvider) *Provider {
+	return &Provider{
+		tracer:     provider.Tracer(tracerName),
+		propagator: propagation.TraceContext{},
+		shutdown:   func(ctx context.Context) error { return nil },
+	}
+}
+
+// Возвращает трассировщик
+func (p *Provider) Tracer() trace.Tracer {
+	return p.tracer
+}
+
+// Отправляет накопленные спаны и останавливает экспортер
+func (p *Provider) Shutdown(ctx context.Context) error {
+	return p.shutdown(ctx)
+}
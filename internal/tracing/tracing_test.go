@@ -0,0 +1,443 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/xoticdsign/returnauf/config"
+	"github.com/xoticdsign/returnauf/internal/database"
+	"github.com/xoticdsign/returnauf/models/responses"
+)
+
+// Кэш для тестов, возвращающий заданную ошибку
+type stubCache struct {
+	err error
+}
+
+// Имитация метода Set
+func (s *stubCache) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	return s.err
+}
+
+// Имитация метода Get
+func (s *stubCache) Get(ctx context.Context, key string) (string, error) {
+	return "", s.err
+}
+
+// Имитация метода TTL
+func (s *stubCache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	return 0, s.err
+}
+
+// Имитация метода Delete
+func (s *stubCache) Delete(ctx context.Context, keys ...string) (int, error) {
+	return len(keys), s.err
+}
+
+// Имитация метода DeletePattern
+func (s *stubCache) DeletePattern(ctx context.Context, pattern string) (int, error) {
+	return 0, s.err
+}
+
+// Имитация метода Flush
+func (s *stubCache) Flush(ctx context.Context) (int, error) {
+	return 0, s.err
+}
+
+// БД для тестов, возвращающая заданную ошибку
+type stubQueuer struct {
+	err error
+}
+
+// Имитация метода QuotesCount
+func (s *stubQueuer) QuotesCount(ctx context.Context) (int, error) {
+	return len(responses.TestQuotes), s.err
+}
+
+// Имитация метода QuoteIDAt
+func (s *stubQueuer) QuoteIDAt(ctx context.Context, offset int) (int, error) {
+	return offset + 1, s.err
+}
+
+// Имитация метода ListAll
+func (s *stubQueuer) ListAll(ctx context.Context, filter database.Filter) ([]responses.Quote, error) {
+	return responses.TestQuotes, s.err
+}
+
+// Имитация метода GetQuote
+func (s *stubQueuer) GetQuote(ctx context.Context, id string) (responses.Quote, error) {
+	return responses.TestQuotes[0], s.err
+}
+
+// Имитация метода ImportQuotes
+func (s *stubQueuer) ImportQuotes(ctx context.Context, quotes []responses.Quote, policy string, dryRun bool) (database.ImportResult, error) {
+	return database.ImportResult{Inserted: len(quotes)}, s.err
+}
+
+// Имитация метода StreamQuotes
+func (s *stubQueuer) StreamQuotes(ctx context.Context, filter database.Filter, fn func(responses.Quote) error) error {
+	return s.err
+}
+
+// Имитация метода CountQuotes
+func (s *stubQueuer) CountQuotes(ctx context.Context, filter database.Filter) (int, error) {
+	return len(responses.TestQuotes), s.err
+}
+
+// Имитация метода PageQuotes
+func (s *stubQueuer) PageQuotes(ctx context.Context, filter database.Filter, offset int, limit int) ([]responses.Quote, error) {
+	return responses.TestQuotes, s.err
+}
+
+// Имитация метода FeedState
+func (s *stubQueuer) FeedState(ctx context.Context) (database.FeedState, error) {
+	return database.FeedState{}, s.err
+}
+
+// Имитация метода LatestQuotes
+func (s *stubQueuer) LatestQuotes(ctx context.Context, limit int) ([]database.FeedEntry, error) {
+	return nil, s.err
+}
+
+// Имитация метода GetQuotes
+func (s *stubQueuer) GetQuotes(ctx context.Context, ids []int) ([]responses.Quote, error) {
+	return responses.TestQuotes, s.err
+}
+
+// Создает трассировщик, записывающий спаны в память
+func setupRecorder() (*Provider, *tracetest.SpanRecorder) {
+	rec := tracetest.NewSpanRecorder()
+
+	return New(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))), rec
+}
+
+// Возвращает значение атрибута спана
+func attr(span sdktrace.ReadOnlySpan, key attribute.Key) attribute.Value {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+// Unit тест для функции Init
+func TestUnitInit(t *testing.T) {
+	cases := []struct {
+		name           string
+		conf           config.Tracing
+		wantInitToFail bool
+	}{
+		{
+			name: "none case",
+			conf: config.Tracing{Exporter: config.TracingExporterNone},
+		},
+		{
+			name: "stdout file case",
+			conf: config.Tracing{Exporter: config.TracingExporterStdout, File: t.TempDir() + "/traces.json", ServiceName: "returnauf"},
+		},
+		{
+			name:           "unknown exporter case",
+			conf:           config.Tracing{Exporter: "jaeger"},
+			wantInitToFail: true,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			got, gotErr := Init(cs.conf)
+
+			assert.Equal(t, cs.wantInitToFail, gotErr != nil)
+
+			if got != nil {
+				assert.NoError(t, got.Shutdown(context.Background()))
+			}
+		})
+	}
+}
+
+// Unit тест для функции Middleware
+func TestUnitMiddleware(t *testing.T) {
+	cases := []struct {
+		name           string
+		path           string
+		traceparent    string
+		wantName       string
+		wantStatus     int
+		wantTraceID    string
+		wantErrorState bool
+	}{
+		{
+			name:       "general case",
+			path:       "/1",
+			wantName:   "GET /:id",
+			wantStatus: 200,
+		},
+		{
+			name:        "traceparent case",
+			path:        "/1",
+			traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			wantName:    "GET /:id",
+			wantStatus:  200,
+			wantTraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+		},
+		{
+			name:       "not found case",
+			path:       "/999",
+			wantName:   "GET /:id",
+			wantStatus: 404,
+		},
+		{
+			name:           "internal error case",
+			path:           "/500",
+			wantName:       "GET /:id",
+			wantStatus:     500,
+			wantErrorState: true,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			p, rec := setupRecorder()
+
+			app := fiber.New()
+			app.Use(func(c *fiber.Ctx) error {
+				c.Locals("uuid", "request-uuid")
+				return c.Next()
+			})
+			app.Use(p.Middleware())
+			app.Get("/:id", func(c *fiber.Ctx) error {
+				_, span := p.Tracer().Start(c.UserContext(), "child")
+				span.End()
+
+				switch c.Params("id") {
+				case "999":
+					return fiber.ErrNotFound
+				case "500":
+					return errors.New("error")
+				}
+				return c.SendString("ok")
+			})
+
+			req := httptest.NewRequest("GET", cs.path, nil)
+			if cs.traceparent != "" {
+				req.Header.Set("traceparent", cs.traceparent)
+			}
+
+			resp, _ := app.Test(req, -1)
+			resp.Body.Close()
+
+			spans := rec.Ended()
+
+			assert.Len(t, spans, 2)
+
+			child, server := spans[0], spans[1]
+
+			assert.Equal(t, cs.wantName, server.Name())
+			assert.Equal(t, server.SpanContext().SpanID(), child.Parent().SpanID())
+			assert.Equal(t, "request-uuid", attr(server, "request.id").AsString())
+			assert.Equal(t, int64(cs.wantStatus), attr(server, "http.response.status_code").AsInt64())
+			assert.Equal(t, server.SpanContext().TraceID().String(), resp.Header.Get(TraceIDHeader))
+			assert.Equal(t, cs.wantErrorState, server.Status().Code == codes.Error)
+
+			if cs.wantTraceID != "" {
+				assert.Equal(t, cs.wantTraceID, server.SpanContext().TraceID().String())
+				assert.True(t, server.Parent().IsRemote())
+			}
+		})
+	}
+}
+
+// Unit тест для декоратора Cache
+func TestUnitCache(t *testing.T) {
+	cases := []struct {
+		name          string
+		err           error
+		wantHit       bool
+		wantErrorCode codes.Code
+	}{
+		{
+			name:          "hit case",
+			err:           nil,
+			wantHit:       true,
+			wantErrorCode: codes.Unset,
+		},
+		{
+			name:          "miss case",
+			err:           redis.Nil,
+			wantHit:       false,
+			wantErrorCode: codes.Unset,
+		},
+		{
+			name:          "error case",
+			err:           errors.New("error"),
+			wantHit:       false,
+			wantErrorCode: codes.Error,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			p, rec := setupRecorder()
+			c := Cache(&stubCache{err: cs.err}, p.Tracer())
+
+			_, gotErr := c.Get(context.Background(), "1")
+
+			spans := rec.Ended()
+
+			assert.Equal(t, cs.err, gotErr)
+			assert.Len(t, spans, 1)
+			assert.Equal(t, "cache.get", spans[0].Name())
+			assert.Equal(t, "1", attr(spans[0], "cache.key").AsString())
+			assert.Equal(t, cs.wantHit, attr(spans[0], "cache.hit").AsBool())
+			assert.Equal(t, cs.wantErrorCode, spans[0].Status().Code)
+		})
+	}
+}
+
+// Unit тест для декоратора Queuer
+func TestUnitQueuer(t *testing.T) {
+	cases := []struct {
+		name          string
+		err           error
+		wantErrorCode codes.Code
+	}{
+		{
+			name:          "ok case",
+			err:           nil,
+			wantErrorCode: codes.Unset,
+		},
+		{
+			name:          "not found case",
+			err:           database.ErrNotFound,
+			wantErrorCode: codes.Unset,
+		},
+		{
+			name:          "error case",
+			err:           errors.New("error"),
+			wantErrorCode: codes.Error,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			p, rec := setupRecorder()
+			q := Queuer(&stubQueuer{err: cs.err}, p.Tracer())
+
+			gotQuote, gotErr := q.GetQuote(context.Background(), "1")
+
+			spans := rec.Ended()
+
+			assert.Equal(t, responses.TestQuotes[0], gotQuote)
+			assert.Equal(t, cs.err, gotErr)
+			assert.Len(t, spans, 1)
+			assert.Equal(t, "db.get_quote", spans[0].Name())
+			assert.Equal(t, "sqlite", attr(spans[0], "db.system").AsString())
+			assert.Equal(t, cs.wantErrorCode, spans[0].Status().Code)
+		})
+	}
+}
+
+// Unit тест для декораторов Importer, Exporter, Feeder и Batcher
+func TestUnitDBDecorators(t *testing.T) {
+	cases := []struct {
+		name     string
+		call     func(tracer trace.Tracer, db *stubQueuer) error
+		err      error
+		wantName string
+		wantCode codes.Code
+	}{
+		{
+			name: "importer case",
+			call: func(tracer trace.Tracer, db *stubQueuer) error {
+				_, err := Importer(db, tracer).ImportQuotes(context.Background(), responses.TestQuotes, "skip", false)
+				return err
+			},
+			wantName: "db.import_quotes",
+			wantCode: codes.Unset,
+		},
+		{
+			name: "exporter stream case",
+			call: func(tracer trace.Tracer, db *stubQueuer) error {
+				return Exporter(db, tracer).StreamQuotes(context.Background(), database.Filter{}, nil)
+			},
+			err:      errors.New("error"),
+			wantName: "db.stream_quotes",
+			wantCode: codes.Error,
+		},
+		{
+			name: "exporter count case",
+			call: func(tracer trace.Tracer, db *stubQueuer) error {
+				_, err := Exporter(db, tracer).CountQuotes(context.Background(), database.Filter{})
+				return err
+			},
+			wantName: "db.count_quotes",
+			wantCode: codes.Unset,
+		},
+		{
+			name: "exporter page case",
+			call: func(tracer trace.Tracer, db *stubQueuer) error {
+				_, err := Exporter(db, tracer).PageQuotes(context.Background(), database.Filter{}, 0, 1)
+				return err
+			},
+			wantName: "db.page_quotes",
+			wantCode: codes.Unset,
+		},
+		{
+			name: "feeder state case",
+			call: func(tracer trace.Tracer, db *stubQueuer) error {
+				_, err := Feeder(db, tracer).FeedState(context.Background())
+				return err
+			},
+			err:      errors.New("error"),
+			wantName: "db.feed_state",
+			wantCode: codes.Error,
+		},
+		{
+			name: "feeder latest case",
+			call: func(tracer trace.Tracer, db *stubQueuer) error {
+				_, err := Feeder(db, tracer).LatestQuotes(context.Background(), 1)
+				return err
+			},
+			wantName: "db.latest_quotes",
+			wantCode: codes.Unset,
+		},
+		{
+			name: "batcher case",
+			call: func(tracer trace.Tracer, db *stubQueuer) error {
+				_, err := Batcher(db, tracer).GetQuotes(context.Background(), []int{1})
+				return err
+			},
+			wantName: "db.get_quotes",
+			wantCode: codes.Unset,
+		},
+	}
+
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			p, rec := setupRecorder()
+
+			gotErr := cs.call(p.Tracer(), &stubQueuer{err: cs.err})
+
+			spans := rec.Ended()
+
+			assert.Equal(t, cs.err, gotErr)
+			assert.Len(t, spans, 1)
+			assert.Equal(t, cs.wantName, spans[0].Name())
+			assert.Equal(t, "sqlite", attr(spans[0], "db.system").AsString())
+			assert.Equal(t, cs.wantCode, spans[0].Status().Code)
+		})
+	}
+}
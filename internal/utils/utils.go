@@ -14,7 +14,7 @@ type Supporter interface {
 // Структура, реализующая Supporter
 type Support struct{}
 
-// Генерирует случайное число
+// Генерирует случайное число от 0 до count, не включая count
 func (s *Support) RandInt(count int) (int, string) {
 	rand.New(rand.NewSource(time.Now().UnixNano()))
 	randInt := rand.Intn(count)
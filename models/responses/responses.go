@@ -1,39 +1,75 @@
 package responses
 
 import (
-	"github.com/gofiber/fiber/v2"
+	"encoding/xml"
 )
 
 // Структура для возврата цитаты
 type Quote struct {
-	ID    int    `gorm:"type:BIGINT NOT NULL PRIMARY KEY"`
-	Quote string `gorm:"type:VARCHAR NOT NULL"`
+	ID    int    `gorm:"type:BIGINT NOT NULL PRIMARY KEY" yaml:"ID"`
+	Quote string `gorm:"type:VARCHAR NOT NULL" yaml:"Quote"`
 }
 
-// Структура для возврата ошибки
-type Error struct {
-	Code    int
+// Структура для возврата записи Кэша, TTL в секундах (-1 для ключа без срока действия)
+type CacheEntry struct {
+	Key   string
+	Value string
+	TTL   int
+}
+
+// Структура для возврата результата операции с Кэшом
+type CacheResult struct {
+	Affected int
+}
+
+// Структура для возврата ошибки в строке файла импорта
+type ImportRowError struct {
+	Row     int
+	ID      int
 	Message string
 }
 
-// Словарь ошибок
-var ErrDictionary = map[int]Error{
-	401: {
-		Code:    fiber.StatusUnauthorized,
-		Message: fiber.ErrUnauthorized.Message,
-	},
-	404: {
-		Code:    fiber.StatusNotFound,
-		Message: fiber.ErrNotFound.Message,
-	},
-	405: {
-		Code:    fiber.StatusMethodNotAllowed,
-		Message: fiber.ErrMethodNotAllowed.Message,
-	},
-	500: {
-		Code:    fiber.StatusInternalServerError,
-		Message: fiber.ErrInternalServerError.Message,
-	},
+// Структура для возврата отчета об импорте цитат
+type ImportReport struct {
+	Format   string
+	Policy   string
+	DryRun   bool
+	Total    int
+	Inserted int
+	Updated  int
+	Skipped  int
+	Errors   []ImportRowError
+}
+
+// Структура для возврата состояния процесса
+type Health struct {
+	Status string
+}
+
+// Структура для возврата состояния зависимости, задержка в миллисекундах
+type DependencyStatus struct {
+	Status  string
+	Latency float64
+	Error   string
+}
+
+// Структура для возврата готовности приложения принимать запросы
+type Readiness struct {
+	Status string
+	Reason string
+	Checks map[string]DependencyStatus
+}
+
+// Структура для возврата ошибки в формате RFC 7807: type - URI вида ошибки, title - его
+// описание, detail - подробности случая, instance - URI запроса, code - стабильный код вида
+type Problem struct {
+	XMLName  xml.Name `json:"-" yaml:"-" msgpack:"-" xml:"urn:ietf:rfc:7807 problem"`
+	Type     string   `json:"type" yaml:"type" msgpack:"type" xml:"type"`
+	Title    string   `json:"title" yaml:"title" msgpack:"title" xml:"title"`
+	Status   int      `json:"status" yaml:"status" msgpack:"status" xml:"status"`
+	Detail   string   `json:"detail,omitempty" yaml:"detail,omitempty" msgpack:"detail,omitempty" xml:"detail,omitempty"`
+	Instance string   `json:"instance,omitempty" yaml:"instance,omitempty" msgpack:"instance,omitempty" xml:"instance,omitempty"`
+	Code     string   `json:"code" yaml:"code" msgpack:"code" xml:"code"`
 }
 
 // Цитаты для тестов в БД и Кэше